@@ -38,6 +38,10 @@ type CtlArgs struct {
 	ConfigFile  string
 	Name        string
 	Remote      string
+	Port        string
+	Speed       string
+	URL         string
+	Timeout     string
 }
 
 type ClientArgs struct {
@@ -105,6 +109,10 @@ var (
 		ConfigFile:  "file",
 		Name:        "name",
 		Remote:      "remote",
+		Port:        "port",
+		Speed:       "speed",
+		URL:         "url",
+		Timeout:     "timeout",
 	}
 	cs = struct{ CtlArgs }{CtlArgs{
 		ContextFile: "ctxf",
@@ -112,6 +120,10 @@ var (
 		ConfigFile:  "f",
 		Name:        "n",
 		Remote:      "r",
+		Port:        "p",
+		Speed:       "s",
+		URL:         "u",
+		Timeout:     "t",
 	}}
 	ctlh = struct{ CtlArgs }{CtlArgs{
 		ContextFile: "Context file path (default .goto_ctx)",
@@ -119,6 +131,10 @@ var (
 		Context:     "Context name (default 'default')",
 		Name:        "Context name (default 'default')",
 		Remote:      "Remote Goto URL (default empty, leads to localhost:8080)",
+		Port:        "Port of the remote goto server whose recording to replay (default: the port of the remote URL)",
+		Speed:       "Replay speed multiplier (default 1)",
+		URL:         "Base URL to send the replayed requests to (default: the recorded URLs)",
+		Timeout:     "Max time to wait for the thresholds verdicts of invoked targets (default 10m)",
 	}}
 	ca = ClientArgs{
 		ClientMode:   "client",
@@ -264,8 +280,14 @@ func setupCtlArgs() {
 	stringFlag(&global.CtlConfig.ContextFile, c.ContextFile, cs.ContextFile, ctlh.ConfigFile, "")
 	stringFlag(&global.CtlConfig.Context, c.Context, cs.Context, ctlh.Context, "default")
 	stringFlagSet(ctl.ApplyFlagSet, &global.CtlConfig.ConfigFile, c.ConfigFile, cs.ConfigFile, ctlh.ConfigFile, "")
+	stringFlagSet(ctl.ApplyFlagSet, &global.CtlConfig.Timeout, c.Timeout, cs.Timeout, ctlh.Timeout, "10m")
 	stringFlagSet(ctl.CtxFlagSet, &global.CtlConfig.Name, c.Name, cs.Name, ctlh.Name, "default")
 	stringFlagSet(ctl.CtxFlagSet, &global.CtlConfig.RemoteURL, c.Remote, cs.Remote, ctlh.Remote, "http://localhost:8080")
+	stringFlagSet(ctl.ReplayFlagSet, &global.CtlConfig.ConfigFile, c.ConfigFile, cs.ConfigFile, "Recording file path (default: fetch the recording from the remote goto server)", "")
+	stringFlagSet(ctl.ReplayFlagSet, &global.CtlConfig.Name, c.Name, cs.Name, "Replay target name (default 'replay')", "replay")
+	stringFlagSet(ctl.ReplayFlagSet, &global.CtlConfig.Port, c.Port, cs.Port, ctlh.Port, "")
+	stringFlagSet(ctl.ReplayFlagSet, &global.CtlConfig.Speed, c.Speed, cs.Speed, ctlh.Speed, "1")
+	stringFlagSet(ctl.ReplayFlagSet, &global.CtlConfig.URL, c.URL, cs.URL, ctlh.URL, "")
 }
 
 func setupClientArgs() {
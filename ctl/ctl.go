@@ -54,6 +54,8 @@ func Ctl(args []string) {
 		ctlCtx(args[1:])
 	case "apply":
 		ctlApply(args[1:])
+	case "replay":
+		ctlReplay(args[1:])
 	}
 }
 
@@ -68,6 +70,9 @@ func ctlApply(args []string) {
 		processMCP(config)
 		processA2A(config)
 		processProxy(config)
+		if !processTraffic(config) {
+			os.Exit(1)
+		}
 	}
 }
 
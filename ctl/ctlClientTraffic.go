@@ -16,6 +16,21 @@
 
 package ctl
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"goto/pkg/global"
+	"goto/pkg/invocation"
+	"goto/pkg/util"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type Traffic struct {
 	Config *TrafficConfig `yaml:"config,omitempty"`
 	Invoke []string       `yaml:"invoke,omitempty"`
@@ -36,16 +51,17 @@ type TrackTime struct {
 }
 
 type TrafficTarget struct {
-	Name          string      `yaml:"name"`
-	Method        string      `yaml:"method"`
-	Protocol      string      `yaml:"protocol"`
-	URL           string      `yaml:"url"`
-	Replicas      int         `yaml:"replicas"`
-	RequestCount  int         `yaml:"requestCount"`
-	Expectation   Expectation `yaml:"expectation"`
-	AutoInvoke    bool        `yaml:"autoInvoke"`
-	StreamPayload []string    `yaml:"streamPayload,omitempty"`
-	StreamDelay   string      `yaml:"streamDelay,omitempty"`
+	Name          string                 `yaml:"name"`
+	Method        string                 `yaml:"method"`
+	Protocol      string                 `yaml:"protocol"`
+	URL           string                 `yaml:"url"`
+	Replicas      int                    `yaml:"replicas"`
+	RequestCount  int                    `yaml:"requestCount"`
+	Expectation   Expectation            `yaml:"expectation"`
+	AutoInvoke    bool                   `yaml:"autoInvoke"`
+	StreamPayload []string               `yaml:"streamPayload,omitempty"`
+	StreamDelay   string                 `yaml:"streamDelay,omitempty"`
+	Thresholds    *invocation.Thresholds `yaml:"thresholds,omitempty"`
 }
 
 type Expectation struct {
@@ -54,3 +70,145 @@ type Expectation struct {
 	PayloadLength int               `yaml:"payloadLength,omitempty"`
 	Headers       map[string]string `yaml:"headers,omitempty"`
 }
+
+// processTraffic sends the traffic config to the goto instance and invokes the listed targets.
+// If any invoked target has thresholds, it waits for the run to finish and returns false when a verdict failed
+// or didn't arrive within the apply timeout.
+func processTraffic(config *GotoConfig) bool {
+	if config.Traffic == nil || config.Traffic.Config == nil {
+		log.Println("No Traffic to configure")
+		return true
+	}
+	traffic := config.Traffic
+	tracking := traffic.Config.Tracking
+	if len(tracking.Headers) > 0 {
+		sendTrafficRequest("Tracking Headers", fmt.Sprintf("/client/track/headers/%s", strings.Join(tracking.Headers, ",")), nil)
+	}
+	if len(tracking.Time.Buckets) > 0 {
+		sendTrafficRequest("Tracking Time Buckets", fmt.Sprintf("/client/track/time/%s", strings.Join(tracking.Time.Buckets, ",")), nil)
+	}
+	runToken := uuid.New().String()
+	awaitVerdicts := map[string]bool{}
+	invoked := map[string]bool{}
+	for _, name := range traffic.Invoke {
+		invoked[name] = true
+	}
+	for _, target := range traffic.Config.Targets {
+		if target.Thresholds != nil {
+			thresholds := *target.Thresholds
+			thresholds.RunToken = runToken
+			target.Thresholds = &thresholds
+		}
+		if !sendTrafficTarget(target) {
+			continue
+		}
+		if target.Thresholds != nil && (target.AutoInvoke || invoked[target.Name]) {
+			awaitVerdicts[target.Name] = true
+		}
+	}
+	if len(traffic.Invoke) > 0 {
+		sendTrafficRequest("Invocation", fmt.Sprintf("/client/targets/%s/invoke", strings.Join(traffic.Invoke, ",")), nil)
+	}
+	if len(awaitVerdicts) == 0 {
+		return true
+	}
+	timeout, err := time.ParseDuration(global.CtlConfig.Timeout)
+	if err != nil || timeout <= 0 {
+		log.Printf("Invalid verdict timeout [%s]\n", global.CtlConfig.Timeout)
+		return false
+	}
+	return checkTrafficVerdicts(awaitVerdicts, runToken, timeout)
+}
+
+func sendTrafficTarget(target TrafficTarget) bool {
+	spec := map[string]any{
+		"name":          target.Name,
+		"method":        target.Method,
+		"protocol":      target.Protocol,
+		"url":           target.URL,
+		"replicas":      target.Replicas,
+		"requestCount":  target.RequestCount,
+		"autoInvoke":    target.AutoInvoke,
+		"streamPayload": target.StreamPayload,
+		"streamDelay":   target.StreamDelay,
+		"thresholds":    target.Thresholds,
+	}
+	e := target.Expectation
+	if e.StatusCode > 0 || e.Payload != "" || e.PayloadLength > 0 || len(e.Headers) > 0 {
+		spec["assertions"] = []*invocation.Assert{{StatusCode: e.StatusCode, Payload: e.Payload, PayloadSize: e.PayloadLength, Headers: e.Headers}}
+	}
+	json := util.ToJSONBytes(spec)
+	if json == nil {
+		log.Printf("JSON marshalling failed for Traffic Target [%s] JSON: %+v", target.Name, target)
+		return false
+	}
+	return sendTrafficRequest(fmt.Sprintf("Traffic Target [%s]", target.Name), "/client/targets/add", json)
+}
+
+func sendTrafficRequest(what, path string, json []byte) bool {
+	url := fmt.Sprintf("%s%s", currentContext.RemoteGotoURL, path)
+	log.Printf("Sending %s to URL [%s]\n", what, url)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(json))
+	if err != nil {
+		log.Printf("Failed to send %s. Error [%s]\n", what, err)
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		log.Printf("Non-OK status for %s: %s\n", what, resp.Status)
+		if json != nil {
+			log.Println(string(json))
+		}
+		return false
+	}
+	log.Printf("%s sent successfully. Response: [%s]\n", what, util.Read(resp.Body))
+	return true
+}
+
+// checkTrafficVerdicts polls the goto instance until all the given targets report a verdict carrying the given
+// run token, and logs each target's violations. It gives up once the timeout elapses.
+func checkTrafficVerdicts(targets map[string]bool, runToken string, timeout time.Duration) bool {
+	url := fmt.Sprintf("%s/client/results/verdicts", currentContext.RemoteGotoURL)
+	log.Printf("Waiting up to %s for verdicts of %d target(s) from URL [%s]\n", timeout, len(targets), url)
+	deadline := time.Now().Add(timeout)
+	failed := false
+	for {
+		if time.Now().After(deadline) {
+			for name := range targets {
+				log.Printf("Timed out waiting for verdict of target [%s]\n", name)
+			}
+			return false
+		}
+		time.Sleep(time.Second)
+		resp, err := http.Get(url)
+		if err != nil {
+			log.Printf("Failed to fetch verdicts. Error [%s]\n", err)
+			return false
+		}
+		verdicts := map[string]*invocation.Verdict{}
+		err = json.NewDecoder(resp.Body).Decode(&verdicts)
+		resp.Body.Close()
+		if err != nil {
+			log.Printf("Failed to read verdicts. Error [%s]\n", err)
+			return false
+		}
+		for name := range targets {
+			v := verdicts[name]
+			if v == nil || v.RunToken != runToken {
+				continue
+			}
+			delete(targets, name)
+			if v.Pass {
+				log.Printf("Target [%s] passed thresholds: requests [%d], failures [%d], throughput [%s]\n", name, v.Requests, v.Failures, v.Throughput)
+				continue
+			}
+			failed = true
+			for _, violation := range v.Violations {
+				log.Printf("Target [%s] failed thresholds: %s [%s] violates threshold [%s]\n", name, violation.Metric, violation.Actual, violation.Threshold)
+			}
+		}
+		if len(targets) == 0 {
+			return !failed
+		}
+	}
+}
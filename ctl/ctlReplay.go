@@ -0,0 +1,110 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ctl
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"goto/pkg/global"
+	"goto/pkg/util"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+type Recording struct {
+	Port      int               `json:"port"`
+	Exchanges []json.RawMessage `json:"exchanges"`
+}
+
+var (
+	ReplayFlagSet = flag.NewFlagSet("replay", flag.ExitOnError)
+)
+
+// ctlReplay reads a recording from a file or from the remote goto server, adds it to the remote goto
+// server as a replay target and invokes it.
+func ctlReplay(args []string) {
+	ReplayFlagSet.Parse(args)
+	loadContext()
+	speed, err := strconv.ParseFloat(global.CtlConfig.Speed, 64)
+	if err != nil || speed <= 0 {
+		log.Printf("Invalid replay speed [%s]\n", global.CtlConfig.Speed)
+		os.Exit(1)
+	}
+	recording := readRecording()
+	if recording == nil {
+		os.Exit(1)
+	}
+	if len(recording.Exchanges) == 0 {
+		log.Println("Recording has no exchanges to replay")
+		os.Exit(1)
+	}
+	name := global.CtlConfig.Name
+	spec := map[string]any{
+		"name": name,
+		"url":  global.CtlConfig.URL,
+		"replay": map[string]any{
+			"speed":     speed,
+			"exchanges": recording.Exchanges,
+		},
+	}
+	json := util.ToJSONBytes(spec)
+	if json == nil {
+		log.Printf("JSON marshalling failed for Replay Target [%s]\n", name)
+		os.Exit(1)
+	}
+	if !sendTrafficRequest(fmt.Sprintf("Replay Target [%s] with [%d] exchanges", name, len(recording.Exchanges)), "/client/targets/add", json) ||
+		!sendTrafficRequest("Invocation", fmt.Sprintf("/client/targets/%s/invoke", name), nil) {
+		os.Exit(1)
+	}
+}
+
+func readRecording() *Recording {
+	var data []byte
+	var err error
+	if global.CtlConfig.ConfigFile != "" {
+		if data, err = os.ReadFile(global.CtlConfig.ConfigFile); err != nil {
+			log.Printf("Failed to read recording file [%s] with error: %s\n", global.CtlConfig.ConfigFile, err.Error())
+			return nil
+		}
+	} else {
+		url := fmt.Sprintf("%s/server/request/record", currentContext.RemoteGotoURL)
+		if global.CtlConfig.Port != "" {
+			url = fmt.Sprintf("%s/port=%s/server/request/record", currentContext.RemoteGotoURL, global.CtlConfig.Port)
+		}
+		log.Printf("Fetching recording from URL [%s]\n", url)
+		resp, err := http.Get(url)
+		if err != nil {
+			log.Printf("Failed to fetch recording. Error [%s]\n", err)
+			return nil
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			log.Printf("Non-OK status for recording: %s\n", resp.Status)
+			return nil
+		}
+		data = util.ReadBytes(resp.Body)
+	}
+	recording := &Recording{}
+	if err := util.ReadJsonFromBytes(data, recording); err != nil {
+		log.Printf("Failed to parse recording with error: %s\n", err.Error())
+		return nil
+	}
+	return recording
+}
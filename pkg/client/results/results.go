@@ -23,6 +23,7 @@ import (
 	"goto/pkg/global"
 	"goto/pkg/invocation"
 	"goto/pkg/transport"
+	"goto/pkg/types"
 	"goto/pkg/util"
 	"log"
 	"reflect"
@@ -49,34 +50,72 @@ type HeaderCounts struct {
 	CountsByValuesStatusCodes map[string]map[string]*CountInfo    `json:"countsByValuesStatusCodes,omitempty"`
 	CrossHeaders              map[string]*HeaderCounts            `json:"crossHeaders,omitempty"`
 	CrossHeadersByValues      map[string]map[string]*HeaderCounts `json:"crossHeadersByValues,omitempty"`
+	LatencyByValues           map[string]*types.Histogram         `json:"latencyByValues,omitempty"`
 }
 
 type KeyResultCounts struct {
 	CountInfo
-	ByStatusCodes KeyResult `json:"byStatusCodes,omitempty"`
-	ByTimeBuckets KeyResult `json:"byTimeBuckets,omitempty"`
+	ByStatusCodes KeyResult        `json:"byStatusCodes,omitempty"`
+	ByTimeBuckets KeyResult        `json:"byTimeBuckets,omitempty"`
+	Latency       *types.Histogram `json:"latency,omitempty"`
 }
 
 type KeyResult map[string]*KeyResultCounts
 
+type WSCounts struct {
+	Connections       int            `json:"connections"`
+	MessagesOut       int            `json:"messagesOut"`
+	MessagesIn        int            `json:"messagesIn"`
+	BytesOut          int            `json:"bytesOut"`
+	BytesIn           int            `json:"bytesIn"`
+	CountsByCloseCode map[string]int `json:"countsByCloseCode"`
+}
+
+type SSECounts struct {
+	Streams            int              `json:"streams"`
+	Events             int              `json:"events"`
+	Reconnects         int              `json:"reconnects"`
+	FailedAssertions   int              `json:"failedAssertions"`
+	CountsByEventType  map[string]int   `json:"countsByEventType"`
+	CountsByStopReason map[string]int   `json:"countsByStopReason"`
+	InterEventLatency  *types.Histogram `json:"interEventLatency"`
+}
+
+type ReplayCounts struct {
+	Exchanges        int                      `json:"exchanges"`
+	Matched          int                      `json:"matched"`
+	StatusMismatches int                      `json:"statusMismatches"`
+	BodyMismatches   int                      `json:"bodyMismatches"`
+	HeaderMismatches int                      `json:"headerMismatches"`
+	Mismatches       []*invocation.ReplayDiff `json:"mismatches,omitempty"`
+}
+
 type TargetResults struct {
-	Target                       string                   `json:"target"`
-	InvocationCount              int                      `json:"invocationCount"`
-	FirstResultAt                time.Time                `json:"firstResultAt,omitempty"`
-	LastResultAt                 time.Time                `json:"lastResultAt,omitempty"`
-	ClientStreamCount            int                      `json:"clientStreamCount"`
-	ServerStreamCount            int                      `json:"serverStreamCount"`
-	RetriedInvocationCounts      int                      `json:"retriedInvocationCounts"`
-	CountsByHeaders              map[string]*HeaderCounts `json:"countsByHeaders,omitempty"`
-	CountsByStatus               map[string]int           `json:"countsByStatus,omitempty"`
-	CountsByStatusCodes          KeyResult                `json:"countsByStatusCodes,omitempty"`
-	CountsByURIs                 KeyResult                `json:"countsByURIs,omitempty"`
-	CountsByRequestPayloadSizes  KeyResult                `json:"countsByRequestPayloadSizes,omitempty"`
-	CountsByResponsePayloadSizes KeyResult                `json:"countsByResponsePayloadSizes,omitempty"`
-	CountsByRetries              KeyResult                `json:"countsByRetries,omitempty"`
-	CountsByRetryReasons         KeyResult                `json:"countsByRetryReasons,omitempty"`
-	CountsByErrors               KeyResult                `json:"countsByErrors,omitempty"`
-	CountsByTimeBuckets          KeyResult                `json:"countsByTimeBuckets,omitempty"`
+	Target                       string                     `json:"target"`
+	InvocationCount              int                        `json:"invocationCount"`
+	FirstResultAt                time.Time                  `json:"firstResultAt,omitempty"`
+	LastResultAt                 time.Time                  `json:"lastResultAt,omitempty"`
+	ClientStreamCount            int                        `json:"clientStreamCount"`
+	ServerStreamCount            int                        `json:"serverStreamCount"`
+	RetriedInvocationCounts      int                        `json:"retriedInvocationCounts"`
+	CountsByHeaders              map[string]*HeaderCounts   `json:"countsByHeaders,omitempty"`
+	CountsByStatus               map[string]int             `json:"countsByStatus,omitempty"`
+	CountsByStatusCodes          KeyResult                  `json:"countsByStatusCodes,omitempty"`
+	CountsByURIs                 KeyResult                  `json:"countsByURIs,omitempty"`
+	CountsByRequestPayloadSizes  KeyResult                  `json:"countsByRequestPayloadSizes,omitempty"`
+	CountsByResponsePayloadSizes KeyResult                  `json:"countsByResponsePayloadSizes,omitempty"`
+	CountsByRetries              KeyResult                  `json:"countsByRetries,omitempty"`
+	CountsByRetryReasons         KeyResult                  `json:"countsByRetryReasons,omitempty"`
+	CountsByErrors               KeyResult                  `json:"countsByErrors,omitempty"`
+	CountsByTimeBuckets          KeyResult                  `json:"countsByTimeBuckets,omitempty"`
+	Latency                      *types.Histogram           `json:"latency,omitempty"`
+	FirstByteLatency             *types.Histogram           `json:"firstByteLatency,omitempty"`
+	WS                           *WSCounts                  `json:"ws,omitempty"`
+	SSE                          *SSECounts                 `json:"sse,omitempty"`
+	Replay                       *ReplayCounts              `json:"replay,omitempty"`
+	Steps                        map[string]*TargetResults  `json:"steps,omitempty"`
+	Verdict                      *invocation.Verdict        `json:"verdict,omitempty"`
+	Capacity                     *invocation.CapacityReport `json:"capacity,omitempty"`
 	trackingHeaders              []string
 	crossTrackingHeaders         map[string][]string
 	crossHeadersMap              map[string]string
@@ -106,6 +145,10 @@ type AggregateResultsView struct {
 	CountsByRetryReasons         SummaryResult             `json:"countsByRetryReasons,omitempty"`
 	CountsByErrors               SummaryResult             `json:"countsByErrors,omitempty"`
 	CountsByTimeBuckets          SummaryResult             `json:"countsByTimeBuckets,omitempty"`
+	Latency                      *types.HistogramSummary   `json:"latency,omitempty"`
+	FirstByteLatency             *types.HistogramSummary   `json:"firstByteLatency,omitempty"`
+	latency                      *types.Histogram
+	firstByteLatency             *types.Histogram
 }
 
 type ClientAggregateResultsView struct {
@@ -129,6 +172,7 @@ type InvocationResults struct {
 	InvocationIndex     uint32                         `json:"invocationIndex"`
 	Target              *invocation.InvocationSpec     `json:"target"`
 	Status              *invocation.InvocationStatus   `json:"status"`
+	Verdict             *invocation.Verdict            `json:"verdict,omitempty"`
 	Results             []*invocation.InvocationResult `json:"results"`
 	Finished            bool                           `json:"finished"`
 	pendingRegistrySend bool
@@ -144,6 +188,8 @@ const (
 	SenderCount  = 10
 	SendDelayMin = 3
 	SendDelayMax = 5
+
+	maxReplayMismatches = 20
 )
 
 var (
@@ -235,6 +281,11 @@ func (tr *TargetResults) unsafeInit(reset bool) {
 		tr.CountsByRetryReasons = KeyResult{}
 		tr.CountsByTimeBuckets = KeyResult{}
 		tr.CountsByErrors = KeyResult{}
+		tr.Latency = types.NewHistogram()
+		tr.FirstByteLatency = types.NewHistogram()
+		tr.WS = nil
+		tr.SSE = nil
+		tr.Replay = nil
 	}
 }
 
@@ -269,6 +320,12 @@ func (tr *TargetResults) unsafeInitMissing() {
 	if tr.CountsByErrors == nil {
 		tr.CountsByErrors = KeyResult{}
 	}
+	if tr.Latency == nil {
+		tr.Latency = types.NewHistogram()
+	}
+	if tr.FirstByteLatency == nil {
+		tr.FirstByteLatency = types.NewHistogram()
+	}
 }
 
 func NewTargetResults(target string, trackingHeaders []string, crossTrackingHeaders map[string][]string, trackingTimeBuckets [][]int) *TargetResults {
@@ -289,6 +346,7 @@ func newHeaderCounts(header string) *HeaderCounts {
 		CountsByValuesStatusCodes: map[string]map[string]*CountInfo{},
 		CrossHeaders:              map[string]*HeaderCounts{},
 		CrossHeadersByValues:      map[string]map[string]*HeaderCounts{},
+		LatencyByValues:           map[string]*types.Histogram{},
 	}
 }
 
@@ -303,6 +361,23 @@ func newKeyResultCounts(withStatusCodes, withTimeBuckets bool) *KeyResultCounts
 	return r
 }
 
+func (c *HeaderCounts) recordLatency(value string, took time.Duration) {
+	if c.LatencyByValues == nil {
+		c.LatencyByValues = map[string]*types.Histogram{}
+	}
+	if c.LatencyByValues[value] == nil {
+		c.LatencyByValues[value] = types.NewHistogram()
+	}
+	c.LatencyByValues[value].Record(took)
+}
+
+func (c *KeyResultCounts) recordLatency(took time.Duration) {
+	if c.Latency == nil {
+		c.Latency = types.NewHistogram()
+	}
+	c.Latency.Record(took)
+}
+
 func (c *HeaderCounts) setTimestamps(ts time.Time) {
 	if c.FirstResultAt.IsZero() || ts.Before(c.FirstResultAt) {
 		c.FirstResultAt = ts
@@ -421,7 +496,7 @@ func processSubCrossHeadersForHeader(header string, values []string, statusCode
 	}
 }
 
-func (tr *TargetResults) addHeaderResult(header string, values []string, statusCode string, retries, csCount, ssCount int, ts time.Time) {
+func (tr *TargetResults) addHeaderResult(header string, values []string, statusCode string, retries, csCount, ssCount int, ts time.Time, took time.Duration) {
 	if tr.CountsByHeaders[header] == nil {
 		tr.CountsByHeaders[header] = newHeaderCounts(header)
 	}
@@ -435,6 +510,7 @@ func (tr *TargetResults) addHeaderResult(header string, values []string, statusC
 			headerCounts.CountsByValuesStatusCodes[value] = map[string]*CountInfo{}
 		}
 		incrementHeaderCount(headerCounts.CountsByValuesStatusCodes[value], statusCode, retries, csCount, ssCount, ts)
+		headerCounts.recordLatency(value, took)
 	}
 }
 
@@ -494,7 +570,22 @@ func (tr *TargetResults) addTimeBucketResult(tb []int, ir *invocation.Invocation
 func (tr *TargetResults) addResult(ir *invocation.InvocationResult) {
 	tr.lock.Lock()
 	defer tr.lock.Unlock()
+	tr.unsafeAddResult(ir)
+}
 
+func (tr *TargetResults) addStepResult(step string, ir *invocation.InvocationResult) {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+	if tr.Steps == nil {
+		tr.Steps = map[string]*TargetResults{}
+	}
+	if tr.Steps[step] == nil {
+		tr.Steps[step] = NewTargetResults(step, tr.trackingHeaders, tr.crossTrackingHeaders, tr.trackingTimeBuckets)
+	}
+	tr.Steps[step].unsafeAddResult(ir)
+}
+
+func (tr *TargetResults) unsafeAddResult(ir *invocation.InvocationResult) {
 	tr.unsafeInit(false)
 	tr.InvocationCount++
 	finishedAt := ir.Request.LastRequestAt
@@ -517,11 +608,26 @@ func (tr *TargetResults) addResult(ir *invocation.InvocationResult) {
 
 	uri := strings.ToLower(ir.Request.URI)
 	addKeyResultCounts(tr.CountsByURIs, uri, statusCode, ir.Retries, ir.Response.ClientStreamCount, ir.Response.ServerStreamCount, ir.Request.LastRequestAt, true, true)
+	tr.CountsByURIs[uri].recordLatency(ir.TookNanos)
+	tr.Latency.Record(ir.TookNanos)
+	if ir.FirstByteNanos > 0 {
+		tr.FirstByteLatency.Record(ir.FirstByteNanos)
+	}
+
+	if ir.WS != nil {
+		tr.addWSResult(ir.WS)
+	}
+	if ir.SSE != nil {
+		tr.addSSEResult(ir.SSE)
+	}
+	if ir.ReplayDiff != nil {
+		tr.addReplayDiff(ir.ReplayDiff)
+	}
 
 	for _, h := range tr.trackingHeaders {
 		for rh, values := range ir.Response.Headers {
 			if strings.EqualFold(h, rh) {
-				tr.addHeaderResult(h, values, statusCode, ir.Retries, ir.Response.ClientStreamCount, ir.Response.ServerStreamCount, finishedAt)
+				tr.addHeaderResult(h, values, statusCode, ir.Retries, ir.Response.ClientStreamCount, ir.Response.ServerStreamCount, finishedAt, ir.TookNanos)
 				tr.processCrossHeadersForHeader(h, values, statusCode, ir.Retries, ir.Response.ClientStreamCount, ir.Response.ServerStreamCount, ir.Request.LastRequestAt, ir.Response.Headers)
 			}
 		}
@@ -551,6 +657,109 @@ func (tr *TargetResults) addResult(ir *invocation.InvocationResult) {
 	}
 }
 
+func (tr *TargetResults) addWSResult(ws *invocation.WSResult) {
+	tr.WS = tr.WS.add(&WSCounts{
+		Connections:       1,
+		MessagesOut:       ws.MessagesOut,
+		MessagesIn:        ws.MessagesIn,
+		BytesOut:          ws.BytesOut,
+		BytesIn:           ws.BytesIn,
+		CountsByCloseCode: map[string]int{strconv.Itoa(ws.CloseCode): 1},
+	})
+}
+
+func (c *WSCounts) add(delta *WSCounts) *WSCounts {
+	if delta == nil {
+		return c
+	}
+	if c == nil {
+		c = &WSCounts{CountsByCloseCode: map[string]int{}}
+	}
+	c.Connections += delta.Connections
+	c.MessagesOut += delta.MessagesOut
+	c.MessagesIn += delta.MessagesIn
+	c.BytesOut += delta.BytesOut
+	c.BytesIn += delta.BytesIn
+	for code, count := range delta.CountsByCloseCode {
+		c.CountsByCloseCode[code] += count
+	}
+	return c
+}
+
+func (tr *TargetResults) addSSEResult(sse *invocation.SSEResult) {
+	tr.SSE = tr.SSE.add(&SSECounts{
+		Streams:            1,
+		Events:             sse.Events,
+		Reconnects:         sse.Reconnects,
+		FailedAssertions:   sse.FailedAssertions,
+		CountsByEventType:  sse.EventsByType,
+		CountsByStopReason: map[string]int{sse.StopReason: 1},
+		InterEventLatency:  sse.InterEventLatency,
+	})
+}
+
+func (c *SSECounts) add(delta *SSECounts) *SSECounts {
+	if delta == nil {
+		return c
+	}
+	if c == nil {
+		c = &SSECounts{CountsByEventType: map[string]int{}, CountsByStopReason: map[string]int{}, InterEventLatency: types.NewHistogram()}
+	}
+	c.Streams += delta.Streams
+	c.Events += delta.Events
+	c.Reconnects += delta.Reconnects
+	c.FailedAssertions += delta.FailedAssertions
+	for eventType, count := range delta.CountsByEventType {
+		c.CountsByEventType[eventType] += count
+	}
+	for reason, count := range delta.CountsByStopReason {
+		c.CountsByStopReason[reason] += count
+	}
+	c.InterEventLatency.Merge(delta.InterEventLatency)
+	return c
+}
+
+func (tr *TargetResults) addReplayDiff(diff *invocation.ReplayDiff) {
+	delta := &ReplayCounts{Exchanges: 1}
+	if diff.Match {
+		delta.Matched = 1
+	} else {
+		delta.Mismatches = []*invocation.ReplayDiff{diff}
+	}
+	if diff.Status != nil {
+		delta.StatusMismatches = 1
+	}
+	if diff.Body != nil {
+		delta.BodyMismatches = 1
+	}
+	if len(diff.Headers) > 0 {
+		delta.HeaderMismatches = 1
+	}
+	tr.Replay = tr.Replay.add(delta)
+}
+
+// add merges the delta counts, keeping only the first few mismatch details.
+func (c *ReplayCounts) add(delta *ReplayCounts) *ReplayCounts {
+	if delta == nil {
+		return c
+	}
+	if c == nil {
+		c = &ReplayCounts{}
+	}
+	c.Exchanges += delta.Exchanges
+	c.Matched += delta.Matched
+	c.StatusMismatches += delta.StatusMismatches
+	c.BodyMismatches += delta.BodyMismatches
+	c.HeaderMismatches += delta.HeaderMismatches
+	for _, diff := range delta.Mismatches {
+		if len(c.Mismatches) >= maxReplayMismatches {
+			break
+		}
+		c.Mismatches = append(c.Mismatches, diff)
+	}
+	return c
+}
+
 func (tr *TargetsResults) init(reset bool) {
 	tr.lock.Lock()
 	defer tr.lock.Unlock()
@@ -703,6 +912,26 @@ func ResultChannelSinkFactory(target *invocation.InvocationSpec, trackingHeaders
 	}
 }
 
+func ScenarioStepSinkFactory(scenario *invocation.InvocationSpec, trackingHeaders []string,
+	crossTrackingHeaders map[string][]string, trackingTimeBuckets [][]int) invocation.StepSinkFactory {
+	targetResults, allResults := targetsResults.getTargetResults(scenario.Name)
+	processTrackingConfig(targetResults, allResults, trackingHeaders, crossTrackingHeaders, trackingTimeBuckets)
+	return func(step *invocation.ScenarioStep) invocation.ResultSinkFactory {
+		return func(tracker *invocation.InvocationTracker) invocation.ResultSink {
+			invocationResults := invocationsResults.getInvocation(tracker.ID)
+			invocationResults.Target = tracker.Target
+			invocationResults.Status = tracker.Status
+			startRegistrySender()
+			return func(result *invocation.InvocationResult) {
+				if result != nil && collectTargetsResults {
+					targetResults.addStepResult(step.Name, result)
+				}
+				resultSink(tracker.ID, result, invocationResults, targetResults, allResults)
+			}
+		}
+	}
+}
+
 func ResultSinkFactory(target *invocation.InvocationSpec, trackingHeaders []string,
 	crossTrackingHeaders map[string][]string, trackingTimeBuckets [][]int) invocation.ResultSinkFactory {
 	targetResults, allResults := targetsResults.getTargetResults(target.Name)
@@ -718,6 +947,54 @@ func ResultSinkFactory(target *invocation.InvocationSpec, trackingHeaders []stri
 	}
 }
 
+// SetVerdict records the thresholds verdict of a finished invocation against its target's results,
+// so that the target reports the verdict of its latest run.
+func SetVerdict(target string, invocationIndex uint32, verdict *invocation.Verdict) {
+	if verdict == nil {
+		return
+	}
+	targetResults, _ := targetsResults.getTargetResults(target)
+	targetResults.lock.Lock()
+	targetResults.Verdict = verdict
+	targetResults.lock.Unlock()
+	if collectTargetsResults {
+		chanSendTargetsToRegistry <- targetResults
+	}
+	if collectInvocationResults {
+		invocationResults := invocationsResults.getInvocation(invocationIndex)
+		invocationResults.lock.Lock()
+		invocationResults.Verdict = verdict
+		invocationResults.lock.Unlock()
+		chanSendInvocationToRegistry <- invocationResults
+	}
+}
+
+// SetCapacityReport records the latest state of a capacity search against the target's results.
+func SetCapacityReport(target string, report *invocation.CapacityReport) {
+	if report == nil {
+		return
+	}
+	targetResults, _ := targetsResults.getTargetResults(target)
+	targetResults.lock.Lock()
+	targetResults.Capacity = report
+	targetResults.lock.Unlock()
+	if collectTargetsResults {
+		chanSendTargetsToRegistry <- targetResults
+	}
+}
+
+func GetVerdicts() map[string]*invocation.Verdict {
+	targetsResults.lock.RLock()
+	defer targetsResults.lock.RUnlock()
+	verdicts := map[string]*invocation.Verdict{}
+	for target, tr := range targetsResults.Results {
+		if tr.Verdict != nil {
+			verdicts[target] = tr.Verdict
+		}
+	}
+	return verdicts
+}
+
 func ClearResults() {
 	invocationsResults.init(true)
 	targetsResults.init(true)
@@ -791,6 +1068,15 @@ func addDeltaHeaderCounts(result, delta *HeaderCounts, crossTrackingHeaders map[
 	for value, count := range delta.CountsByValues {
 		incrementHeaderValueCountBy(result.CountsByValues, value, 0, count)
 	}
+	for value, latency := range delta.LatencyByValues {
+		if result.LatencyByValues == nil {
+			result.LatencyByValues = map[string]*types.Histogram{}
+		}
+		if result.LatencyByValues[value] == nil {
+			result.LatencyByValues[value] = types.NewHistogram()
+		}
+		result.LatencyByValues[value].Merge(latency)
+	}
 	if result.CountsByStatusCodes == nil {
 		result.CountsByStatusCodes = map[string]*CountInfo{}
 	}
@@ -856,6 +1142,12 @@ func processDeltaKeyResultCounts(delta KeyResult, resultPtr *KeyResult, updateSt
 		}
 		resultCount := result[key]
 		resultCount.incrementBy(deltaCount.Retries, &deltaCount.CountInfo)
+		if deltaCount.Latency != nil {
+			if resultCount.Latency == nil {
+				resultCount.Latency = types.NewHistogram()
+			}
+			resultCount.Latency.Merge(deltaCount.Latency)
+		}
 		if updateStatusCodes {
 			for sc, count := range deltaCount.ByStatusCodes {
 				if resultCount.ByStatusCodes[sc] == nil {
@@ -870,6 +1162,18 @@ func processDeltaKeyResultCounts(delta KeyResult, resultPtr *KeyResult, updateSt
 	}
 }
 
+// mergesVerdict tells whether an incoming verdict replaces the stored one. Verdicts of the same run are combined from
+// its peers, where any peer failing fails the run. Otherwise the latest verdict wins, like the capacity report.
+func mergesVerdict(stored, incoming *invocation.Verdict) bool {
+	if stored == nil {
+		return true
+	}
+	if stored.RunToken != "" && stored.RunToken == incoming.RunToken {
+		return stored.Pass || !incoming.Pass
+	}
+	return !incoming.At.Before(stored.At)
+}
+
 func AddDeltaResults(results, delta *TargetResults, detailed bool) {
 	fmt.Printf("AddDeltaResults: Target [%s] first response [%s] last response [%s]\n", delta.Target, delta.FirstResultAt.UTC().String(), delta.LastResultAt.UTC().String())
 	if results.FirstResultAt.IsZero() || delta.FirstResultAt.Before(results.FirstResultAt) {
@@ -884,6 +1188,14 @@ func AddDeltaResults(results, delta *TargetResults, detailed bool) {
 	for k, v := range delta.CountsByStatus {
 		results.CountsByStatus[k] += v
 	}
+	if results.Latency == nil {
+		results.Latency = types.NewHistogram()
+	}
+	results.Latency.Merge(delta.Latency)
+	if results.FirstByteLatency == nil {
+		results.FirstByteLatency = types.NewHistogram()
+	}
+	results.FirstByteLatency.Merge(delta.FirstByteLatency)
 
 	if delta.CountsByHeaders != nil {
 		if results.CountsByHeaders == nil {
@@ -900,6 +1212,27 @@ func AddDeltaResults(results, delta *TargetResults, detailed bool) {
 	processDeltaKeyResultCounts(delta.CountsByRetryReasons, &results.CountsByRetryReasons, detailed, detailed)
 	processDeltaKeyResultCounts(delta.CountsByErrors, &results.CountsByErrors, detailed, detailed)
 	processDeltaKeyResultCounts(delta.CountsByTimeBuckets, &results.CountsByTimeBuckets, detailed, false)
+
+	results.WS = results.WS.add(delta.WS)
+	results.SSE = results.SSE.add(delta.SSE)
+	results.Replay = results.Replay.add(delta.Replay)
+
+	if delta.Verdict != nil && mergesVerdict(results.Verdict, delta.Verdict) {
+		results.Verdict = delta.Verdict
+	}
+	if delta.Capacity != nil && (results.Capacity == nil || !delta.Capacity.StartedAt.Before(results.Capacity.StartedAt)) {
+		results.Capacity = delta.Capacity
+	}
+
+	for step, stepDelta := range delta.Steps {
+		if results.Steps == nil {
+			results.Steps = map[string]*TargetResults{}
+		}
+		if results.Steps[step] == nil {
+			results.Steps[step] = NewTargetResults(step, results.trackingHeaders, results.crossTrackingHeaders, results.trackingTimeBuckets)
+		}
+		AddDeltaResults(results.Steps[step], stepDelta, detailed)
+	}
 }
 
 func incrementKeyResultCounts(result SummaryResult, delta interface{}, detailed bool) {
@@ -975,6 +1308,14 @@ func (sr *AggregateResultsView) addTargetResult(tr *TargetResults, detailed bool
 	if tr.CountsByTimeBuckets != nil {
 		incrementKeyResultCounts(sr.CountsByTimeBuckets, tr.CountsByTimeBuckets, detailed)
 	}
+	if tr.Latency != nil && tr.Latency.Count() > 0 {
+		sr.latency.Merge(tr.Latency)
+		sr.Latency = sr.latency.Summary()
+	}
+	if tr.FirstByteLatency != nil && tr.FirstByteLatency.Count() > 0 {
+		sr.firstByteLatency.Merge(tr.FirstByteLatency)
+		sr.FirstByteLatency = sr.firstByteLatency.Summary()
+	}
 }
 
 func (car *ClientAggregateResultsView) addTargetResult(tr *TargetResults, detailed bool) {
@@ -1010,6 +1351,8 @@ func (ar *AggregateResultsView) init() {
 	ar.CountsByRetryReasons = SummaryResult{}
 	ar.CountsByErrors = SummaryResult{}
 	ar.CountsByTimeBuckets = SummaryResult{}
+	ar.latency = types.NewHistogram()
+	ar.firstByteLatency = types.NewHistogram()
 }
 
 func NewClientTargetsAggregateResults() *ClientTargetsAggregateResultsView {
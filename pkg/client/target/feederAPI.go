@@ -0,0 +1,107 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package target
+
+import (
+	"fmt"
+	"goto/pkg/events"
+	"goto/pkg/global"
+	"goto/pkg/invocation"
+	"goto/pkg/util"
+	"net/http"
+)
+
+func addFeeder(w http.ResponseWriter, r *http.Request) {
+	msg := ""
+	f := &invocation.Feeder{}
+	if err := util.ReadJsonPayload(r, f); err == nil {
+		if err := invocation.AddFeeder(f); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg = fmt.Sprintf("Invalid feeder: %s", err.Error())
+		} else {
+			msg = fmt.Sprintf("Added feeder [%s] with [%d] rows and [%d] generators", f.Name, len(f.Rows), len(f.Generators))
+			events.SendRequestEvent(events.Client_FeederAdded, msg, r)
+		}
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		msg = fmt.Sprintf("Failed to parse json with error: %s", err.Error())
+	}
+	if global.Flags.EnableClientLogs {
+		util.AddLogMessage(msg, r)
+	}
+	fmt.Fprintln(w, msg)
+}
+
+func uploadFeeder(w http.ResponseWriter, r *http.Request) {
+	msg := ""
+	name := util.GetStringParamValue(r, "name")
+	feederType := util.GetStringParamValue(r, "type")
+	field := util.GetStringParamValue(r, "field")
+	if f, err := invocation.ParseFeeder(name, feederType, field, r.Body); err == nil {
+		invocation.AddFeeder(f)
+		msg = fmt.Sprintf("Added %s feeder [%s] with [%d] rows", f.Type, f.Name, len(f.Rows))
+		events.SendRequestEvent(events.Client_FeederAdded, msg, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		msg = fmt.Sprintf("Invalid feeder [%s]: %s", name, err.Error())
+	}
+	if global.Flags.EnableClientLogs {
+		util.AddLogMessage(msg, r)
+	}
+	fmt.Fprintln(w, msg)
+}
+
+func removeFeeders(w http.ResponseWriter, r *http.Request) {
+	msg := ""
+	if names, present := util.GetListParam(r, "feeders"); present {
+		invocation.RemoveFeeders(names)
+		msg = fmt.Sprintf("Feeders Removed: %+v", names)
+		events.SendRequestEventJSON(events.Client_FeedersRemoved, util.GetStringParamValue(r, "feeders"), names, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "No feeder given"
+	}
+	if global.Flags.EnableClientLogs {
+		util.AddLogMessage(msg, r)
+	}
+	fmt.Fprintln(w, msg)
+}
+
+func clearFeeders(w http.ResponseWriter, r *http.Request) {
+	invocation.ClearFeeders()
+	msg := "Feeders cleared"
+	events.SendRequestEvent(events.Client_FeedersRemoved, msg, r)
+	if global.Flags.EnableClientLogs {
+		util.AddLogMessage(msg, r)
+	}
+	fmt.Fprintln(w, msg)
+}
+
+func getFeeders(w http.ResponseWriter, r *http.Request) {
+	if name, present := util.GetStringParam(r, "feeder"); present {
+		if f := invocation.GetFeeder(name); f != nil {
+			util.WriteJsonPayload(w, f)
+		} else {
+			util.WriteErrorJson(w, "Feeder not found: "+name)
+		}
+	} else {
+		util.WriteJsonPayload(w, invocation.GetFeeders())
+	}
+	if global.Flags.EnableClientLogs {
+		util.AddLogMessage("Reporting feeders", r)
+	}
+}
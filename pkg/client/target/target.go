@@ -77,6 +77,9 @@ func (tc *TargetClient) AddTarget(t *Target, r ...*http.Request) error {
 		tc.targets[t.Name] = t
 		tc.targetsLock.Unlock()
 		invocation.RemoveHttpClientForTarget(t.Name)
+		if t.Headers == nil {
+			t.Headers = map[string]string{}
+		}
 		t.Headers[constants.HeaderFromGoto] = global.Self.Name
 		t.Headers[constants.HeaderFromGotoHost] = global.Self.HostLabel
 		if t.AutoInvoke {
@@ -248,12 +251,25 @@ func (tc *TargetClient) stopTargets(targetNames []string) (bool, bool) {
 }
 
 func (tc *TargetClient) invokeTarget(target *invocation.InvocationSpec) {
+	if target.IsScenario() {
+		tc.invokeScenario(target)
+		return
+	}
+	if target.IsReplay() {
+		tc.invokeReplay(target)
+		return
+	}
+	if target.IsCapacitySearch() {
+		tc.invokeCapacitySearch(target)
+		return
+	}
 	if tracker, err := invocation.RegisterInvocation(tc.clientPort, target, results.ResultChannelSinkFactory(target, tc.trackHeaders, tc.crossTrackHeaders, tc.trackTimeBuckets)); err == nil {
 		tc.targetsLock.Lock()
 		tc.activeTargetsCount++
 		tc.targetsLock.Unlock()
 		events.SendEventJSON(events.Client_TargetInvoked, target.Name, tracker)
 		invocation.StartInvocation(tracker)
+		results.SetVerdict(target.Name, tracker.ID, tracker.Verdict)
 		tc.targetsLock.Lock()
 		tc.activeTargetsCount--
 		tc.targetsLock.Unlock()
@@ -262,6 +278,42 @@ func (tc *TargetClient) invokeTarget(target *invocation.InvocationSpec) {
 	}
 }
 
+func (tc *TargetClient) invokeScenario(scenario *invocation.InvocationSpec) {
+	tc.targetsLock.Lock()
+	tc.activeTargetsCount++
+	tc.targetsLock.Unlock()
+	events.SendEventJSON(events.Client_TargetInvoked, scenario.Name, scenario)
+	invocation.StartScenario(tc.clientPort, scenario, results.ScenarioStepSinkFactory(scenario, tc.trackHeaders, tc.crossTrackHeaders, tc.trackTimeBuckets))
+	tc.targetsLock.Lock()
+	tc.activeTargetsCount--
+	tc.targetsLock.Unlock()
+}
+
+func (tc *TargetClient) invokeReplay(replay *invocation.InvocationSpec) {
+	tc.targetsLock.Lock()
+	tc.activeTargetsCount++
+	tc.targetsLock.Unlock()
+	events.SendEventJSON(events.Client_TargetInvoked, replay.Name, replay)
+	invocation.StartReplay(tc.clientPort, replay, results.ResultSinkFactory(replay, tc.trackHeaders, tc.crossTrackHeaders, tc.trackTimeBuckets))
+	tc.targetsLock.Lock()
+	tc.activeTargetsCount--
+	tc.targetsLock.Unlock()
+}
+
+func (tc *TargetClient) invokeCapacitySearch(target *invocation.InvocationSpec) {
+	tc.targetsLock.Lock()
+	tc.activeTargetsCount++
+	tc.targetsLock.Unlock()
+	events.SendEventJSON(events.Client_TargetInvoked, target.Name, target)
+	invocation.StartCapacitySearch(tc.clientPort, target, results.ResultSinkFactory(target, tc.trackHeaders, tc.crossTrackHeaders, tc.trackTimeBuckets),
+		func(report *invocation.CapacityReport) {
+			results.SetCapacityReport(target.Name, report)
+		})
+	tc.targetsLock.Lock()
+	tc.activeTargetsCount--
+	tc.targetsLock.Unlock()
+}
+
 func (tc *TargetClient) InvokeAll() {
 	wg := &sync.WaitGroup{}
 	for _, t := range tc.targets {
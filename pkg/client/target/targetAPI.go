@@ -55,10 +55,19 @@ func SetRoutes(r *mux.Router) {
 	util.AddRoute(r, "/track/time/{buckets}", addTrackingTimeBuckets, "POST", "PUT")
 	util.AddRoute(r, "/track/time", getTrackingTimeBuckets, "GET")
 
+	feedersRouter := util.PathRouter(r, "/feeders")
+	util.AddRoute(feedersRouter, "/add", addFeeder, "POST", "PUT")
+	util.AddRouteQO(feedersRouter, "/add/{name}/{type}", uploadFeeder, "field", "POST", "PUT")
+	util.AddRoute(feedersRouter, "/{feeders}/remove", removeFeeders, "POST")
+	util.AddRoute(feedersRouter, "/clear", clearFeeders, "POST")
+	util.AddRoute(feedersRouter, "/{feeder}", getFeeders, "GET")
+	util.AddRoute(feedersRouter, "", getFeeders, "GET")
+
 	util.AddRoute(r, "/results/all/{enable}", enableAllTargetsResultsCollection, "POST", "PUT")
 	util.AddRoute(r, "/results/invocations/{enable}", enableInvocationResultsCollection, "POST", "PUT")
 	util.AddRoute(r, "/results", getResults, "GET")
 	util.AddRoute(r, "/results/invocations", getInvocationResults, "GET")
+	util.AddRoute(r, "/results/verdicts", getVerdicts, "GET")
 	util.AddRoute(r, "/results/clear", clearResults, "POST")
 }
 
@@ -231,6 +240,13 @@ func getActiveTargets(w http.ResponseWriter, r *http.Request) {
 	util.WriteJsonPayload(w, result)
 }
 
+func getVerdicts(w http.ResponseWriter, r *http.Request) {
+	util.WriteJsonPayload(w, results.GetVerdicts())
+	if global.Flags.EnableClientLogs {
+		util.AddLogMessage("Reporting target verdicts", r)
+	}
+}
+
 func clearResults(w http.ResponseWriter, r *http.Request) {
 	results.ClearResults()
 	w.WriteHeader(http.StatusOK)
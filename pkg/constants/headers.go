@@ -48,6 +48,19 @@ const (
 	HeaderGotoForcedStatus          = "Goto-Forced-Status"
 	HeaderGotoForcedStatusRemaining = "Goto-Forced-Status-Remaining"
 	HeaderGotoStatusFlip            = "Goto-Status-Flip"
+	HeaderGotoFault                 = "Goto-Fault"
+	HeaderGotoBandwidth             = "Goto-Bandwidth"
+	HeaderGotoChaos                 = "Goto-Chaos"
+	HeaderGotoOpenAPIOperation      = "Goto-OpenAPI-Operation"
+	HeaderGotoResource              = "Goto-Resource"
+	HeaderGotoSequence              = "Goto-Sequence"
+	HeaderGotoCache                 = "Goto-Cache"
+	HeaderGotoAuth                  = "Goto-Auth"
+	HeaderGotoBodyValidation        = "Goto-Body-Validation"
+	HeaderGotoCircuitBreaker        = "Goto-Circuit-Breaker"
+	HeaderGotoUpstreamHealth        = "Goto-Upstream-Health"
+	HeaderGotoLoadBalancer          = "Goto-Load-Balancer"
+	HeaderGotoShadow                = "Goto-Shadow"
 	HeaderGotoInAt                  = "Goto-In-At"
 	HeaderGotoOutAt                 = "Goto-Out-At"
 	HeaderGotoTook                  = "Goto-Took"
@@ -35,6 +35,17 @@ const (
 	Client_InvocationRepeatedFailure  = "Invocation Repeated Failure"
 	Client_InvocationResponse         = "Invocation Response"
 	Client_InvocationFailure          = "Invocation Failure"
+	Client_TargetVerdict              = "Target Verdict"
+	Client_ScenarioStarted            = "Scenario Started"
+	Client_ScenarioFinished           = "Scenario Finished"
+	Client_ScenarioStepFailed         = "Scenario Step Failed"
+	Client_ReplayStarted              = "Replay Started"
+	Client_ReplayFinished             = "Replay Finished"
+	Client_CapacitySearchStarted      = "Capacity Search Started"
+	Client_CapacityStepFinished       = "Capacity Step Finished"
+	Client_CapacitySearchFinished     = "Capacity Search Finished"
+	Client_FeederAdded                = "Feeder Added"
+	Client_FeedersRemoved             = "Feeders Removed"
 
 	Jobs_JobAdded          = "Job Added"
 	Jobs_JobScriptStored   = "Job Script Stored"
@@ -46,6 +57,12 @@ const (
 	Jobs_JobFinished       = "Job Finished"
 	Jobs_JobStopped        = "Job Stopped"
 
+	Proxy_CircuitBreakerOpened   = "Proxy: Circuit Breaker Opened"
+	Proxy_CircuitBreakerHalfOpen = "Proxy: Circuit Breaker Half-Open"
+	Proxy_CircuitBreakerClosed   = "Proxy: Circuit Breaker Closed"
+	Proxy_UpstreamHealthy        = "Proxy: Upstream Healthy"
+	Proxy_UpstreamUnhealthy      = "Proxy: Upstream Unhealthy"
+
 	Registry_PeerEventsCleared              = "Registry: Peer Events Cleared"
 	Registry_PeerResultsCleared             = "Registry: Peer Results Cleared"
 	Registry_PeerAdded                      = "Registry: Peer Added"
@@ -0,0 +1,315 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"fmt"
+	"goto/pkg/events"
+	"goto/pkg/types"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+type CapacitySearch struct {
+	Mode          string  `json:"mode"`
+	Start         float64 `json:"start"`
+	Step          float64 `json:"step"`
+	Max           float64 `json:"max"`
+	StepDuration  string  `json:"stepDuration"`
+	SearchSteps   int     `json:"searchSteps"`
+	stepDurationD time.Duration
+	rate          bool
+}
+
+type CapacityStep struct {
+	Phase      string                  `json:"phase"`
+	Level      float64                 `json:"level"`
+	Pass       bool                    `json:"pass"`
+	Requests   int                     `json:"requests"`
+	Failures   int                     `json:"failures"`
+	ErrorRate  string                  `json:"errorRate"`
+	Throughput string                  `json:"throughput"`
+	Latency    *types.HistogramSummary `json:"latency"`
+	Violations []*Violation            `json:"violations,omitempty"`
+	At         time.Time               `json:"at"`
+	throughput float64
+}
+
+type CapacityReport struct {
+	Mode           string          `json:"mode"`
+	Running        bool            `json:"running"`
+	MaxSustainable float64         `json:"maxSustainable"`
+	MaxThroughput  string          `json:"maxThroughput"`
+	BreakingPoint  float64         `json:"breakingPoint"`
+	StopReason     string          `json:"stopReason"`
+	Steps          []*CapacityStep `json:"steps"`
+	StartedAt      time.Time       `json:"startedAt"`
+	FinishedAt     time.Time       `json:"finishedAt,omitempty"`
+	maxThroughput  float64
+}
+
+type CapacityReporter func(*CapacityReport)
+
+const (
+	CapacityModeConcurrency = "concurrency"
+	CapacityModeRate        = "rate"
+
+	CapacityPhaseRamp   = "ramp"
+	CapacityPhaseSearch = "search"
+
+	CapacityStopBreached = "breached"
+	CapacityStopMax      = "maxReached"
+	CapacityStopStopped  = "stopped"
+
+	defaultCapacityStepDuration = 10 * time.Second
+	defaultCapacitySearchSteps  = 5
+	maxCapacityStepRequests     = 1 << 30
+)
+
+func (is *InvocationSpec) IsCapacitySearch() bool {
+	return is.Capacity != nil
+}
+
+func (is *InvocationSpec) validateCapacity() error {
+	cs := is.Capacity
+	if is.Thresholds == nil || (is.Thresholds.maxErrorRate < 0 && len(is.Thresholds.maxLatency) == 0 && is.Thresholds.minThroughput <= 0) {
+		return fmt.Errorf("capacity search requires thresholds to use as SLO")
+	}
+	if is.Rate != "" || len(is.RampUp) > 0 || len(is.RampDown) > 0 {
+		return fmt.Errorf("rate is set by capacity search and cannot be configured on the target")
+	}
+	switch strings.ToLower(cs.Mode) {
+	case "", CapacityModeConcurrency:
+		cs.Mode = CapacityModeConcurrency
+	case CapacityModeRate:
+		cs.Mode = CapacityModeRate
+		cs.rate = true
+	default:
+		return fmt.Errorf("invalid capacity search mode [%s]", cs.Mode)
+	}
+	if cs.Start < 0 || cs.Step < 0 || cs.Max <= 0 {
+		return fmt.Errorf("capacity search needs a positive max, and non-negative start and step")
+	}
+	if cs.Start == 0 {
+		cs.Start = cs.Step
+	}
+	if cs.Step == 0 {
+		cs.Step = cs.Start
+	}
+	if !cs.rate {
+		cs.Start = math.Round(cs.Start)
+		cs.Step = math.Round(cs.Step)
+		cs.Max = math.Floor(cs.Max)
+	}
+	if cs.Start <= 0 || cs.Step <= 0 {
+		return fmt.Errorf("capacity search needs a start or step")
+	}
+	if cs.Start > cs.Max {
+		return fmt.Errorf("capacity search start [%g] is above max [%g]", cs.Start, cs.Max)
+	}
+	if cs.StepDuration != "" {
+		var err error
+		if cs.stepDurationD, err = time.ParseDuration(cs.StepDuration); err != nil || cs.stepDurationD <= 0 {
+			return fmt.Errorf("invalid capacity search stepDuration")
+		}
+	} else {
+		cs.stepDurationD = defaultCapacityStepDuration
+	}
+	if cs.SearchSteps < 0 {
+		return fmt.Errorf("invalid capacity search searchSteps")
+	} else if cs.SearchSteps == 0 {
+		cs.SearchSteps = defaultCapacitySearchSteps
+	}
+	return nil
+}
+
+// stepSpec builds the invocation for one step of the search. In rate mode the step runs open-loop at the
+// given rate for the step duration, and in concurrency mode it runs as many replicas as the level until
+// the step duration elapses.
+func (cs *CapacitySearch) stepSpec(target *InvocationSpec, level float64) *InvocationSpec {
+	spec := target.Clone()
+	spec.Capacity = nil
+	spec.InitialDelay = ""
+	spec.initialDelayD = 0
+	if cs.rate {
+		spec.Rate = fmt.Sprintf("%g/s", level)
+		spec.ratePerSecond = level
+		spec.Replicas = 1
+		spec.RequestCount = max(1, int(math.Ceil(level*cs.stepDurationD.Seconds())))
+	} else {
+		spec.Replicas = int(level)
+		spec.RequestCount = maxCapacityStepRequests / spec.Replicas
+	}
+	return spec
+}
+
+func (cs *CapacitySearch) next(level float64) float64 {
+	return math.Min(level+cs.Step, cs.Max)
+}
+
+func (cs *CapacitySearch) mid(lo, hi float64) float64 {
+	if cs.rate {
+		return math.Round((lo+hi)*50) / 100
+	}
+	return math.Floor((lo + hi) / 2)
+}
+
+// StartCapacitySearch increases the target's concurrency (or rate) by `step` from `start` until a step breaches
+// the target's thresholds or `max` is reached. Once a step breaches, it binary-searches between the last passing
+// and the first failing level for up to `searchSteps` more steps. The report is passed to the reporter after
+// every step so that the latency curve can be followed while the search runs.
+func StartCapacitySearch(clientPort int, target *InvocationSpec, sinks ResultSinkFactory, reporter CapacityReporter) *CapacityReport {
+	run := &scenarioRun{}
+	invocationsLock.Lock()
+	activeScenarios[target.Name] = run
+	invocationsLock.Unlock()
+	defer func() {
+		invocationsLock.Lock()
+		if activeScenarios[target.Name] == run {
+			delete(activeScenarios, target.Name)
+		}
+		invocationsLock.Unlock()
+	}()
+	cs := target.Capacity
+	report := &CapacityReport{Mode: cs.Mode, Running: true, Steps: []*CapacityStep{}, StartedAt: time.Now()}
+	time.Sleep(target.initialDelayD)
+	events.SendEventJSON(events.Client_CapacitySearchStarted, target.Name, map[string]any{"target": target.Name, "capacity": cs})
+	runStep := func(phase string, level float64) *CapacityStep {
+		step := cs.runStep(clientPort, target, run, phase, len(report.Steps)+1, level, sinks)
+		if step == nil {
+			return nil
+		}
+		report.addStep(step)
+		events.SendEventJSON(events.Client_CapacityStepFinished, target.Name, map[string]any{"target": target.Name, "step": step})
+		if reporter != nil {
+			reporter(report.snapshot())
+		}
+		return step
+	}
+	lo, hi := 0.0, 0.0
+	for level := cs.Start; ; level = cs.next(level) {
+		step := runStep(CapacityPhaseRamp, level)
+		if step == nil {
+			break
+		}
+		if !step.Pass {
+			hi = level
+			break
+		}
+		lo = level
+		if level >= cs.Max {
+			break
+		}
+	}
+	for i := 0; hi > 0 && lo > 0 && i < cs.SearchSteps; i++ {
+		level := cs.mid(lo, hi)
+		if level <= lo || level >= hi {
+			break
+		}
+		step := runStep(CapacityPhaseSearch, level)
+		if step == nil {
+			break
+		} else if step.Pass {
+			lo = level
+		} else {
+			hi = level
+		}
+	}
+	if run.isStopRequested() {
+		report.StopReason = CapacityStopStopped
+	} else if hi > 0 {
+		report.StopReason = CapacityStopBreached
+	} else {
+		report.StopReason = CapacityStopMax
+	}
+	report.Running = false
+	report.FinishedAt = time.Now()
+	events.SendEventJSON(events.Client_CapacitySearchFinished, target.Name, map[string]any{"target": target.Name, "report": report})
+	if reporter != nil {
+		reporter(report.snapshot())
+	}
+	return report
+}
+
+// runStep runs one step of the search and returns nil if the search was stopped during the step.
+func (cs *CapacitySearch) runStep(clientPort int, target *InvocationSpec, run *scenarioRun, phase string, index int,
+	level float64, sinks ResultSinkFactory) *CapacityStep {
+	if run.isStopRequested() {
+		return nil
+	}
+	tracker, err := RegisterInvocation(clientPort, cs.stepSpec(target, level), sinks)
+	if err != nil {
+		log.Printf("Capacity Search [%s]: failed to register step [%d] with error: %s\n", target.Name, index, err.Error())
+		return nil
+	}
+	tracker.CustomID = fmt.Sprintf("step%d", index)
+	done := make(chan bool)
+	go func() {
+		deadline := time.Now().Add(cs.stepDurationD)
+		for !run.isStopRequested() && (cs.rate || time.Now().Before(deadline)) {
+			select {
+			case <-done:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		tracker.Status.StopRequested = true
+	}()
+	StartInvocation(tracker)
+	close(done)
+	if run.isStopRequested() || tracker.Verdict == nil {
+		return nil
+	}
+	verdict := tracker.Verdict
+	step := &CapacityStep{
+		Phase:      phase,
+		Level:      level,
+		Pass:       verdict.Pass && verdict.Requests > 0,
+		Requests:   verdict.Requests,
+		Failures:   verdict.Failures,
+		ErrorRate:  verdict.ErrorRate,
+		Throughput: verdict.Throughput,
+		Latency:    verdict.Latency,
+		Violations: verdict.Violations,
+		At:         verdict.At,
+	}
+	tracker.stats.lock.Lock()
+	step.throughput = tracker.stats.throughput()
+	tracker.stats.lock.Unlock()
+	return step
+}
+
+func (r *CapacityReport) addStep(step *CapacityStep) {
+	r.Steps = append(r.Steps, step)
+	if step.Pass && step.Level > r.MaxSustainable {
+		r.MaxSustainable = step.Level
+	} else if !step.Pass && (r.BreakingPoint == 0 || step.Level < r.BreakingPoint) {
+		r.BreakingPoint = step.Level
+	}
+	if step.Pass && step.throughput > r.maxThroughput {
+		r.maxThroughput = step.throughput
+		r.MaxThroughput = step.Throughput
+	}
+}
+
+func (r *CapacityReport) snapshot() *CapacityReport {
+	r2 := *r
+	r2.Steps = append([]*CapacityStep{}, r.Steps...)
+	return &r2
+}
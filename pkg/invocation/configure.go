@@ -56,6 +56,19 @@ type InvocationSpec struct {
 	WarmupCount          int               `json:"warmupCount"`
 	InitialDelay         string            `json:"initialDelay"`
 	Delay                string            `json:"delay"`
+	Rate                 string            `json:"rate"`
+	RampUp               []*RateStage      `json:"rampUp"`
+	RampDown             []*RateStage      `json:"rampDown"`
+	MaxInFlight          int               `json:"maxInFlight"`
+	Feed                 *FeedSpec         `json:"feed"`
+	Thresholds           *Thresholds       `json:"thresholds"`
+	Steps                []*ScenarioStep   `json:"steps"`
+	WS                   *WSSpec           `json:"ws"`
+	SSE                  *SSESpec          `json:"sse"`
+	Replay               *ReplaySpec       `json:"replay"`
+	Capacity             *CapacitySearch   `json:"capacity"`
+	Vars                 map[string]string `json:"vars"`
+	ContinueOnFailure    bool              `json:"continueOnFailure"`
 	Retries              int               `json:"retries"`
 	RetryDelay           string            `json:"retryDelay"`
 	RetriableStatusCodes []int             `json:"retriableStatusCodes"`
@@ -92,6 +105,8 @@ type InvocationSpec struct {
 	grpc                 bool
 	http                 bool
 	h2                   bool
+	h3                   bool
+	ws                   bool
 	authority            string
 	connTimeoutD         time.Duration
 	connIdleTimeoutD     time.Duration
@@ -102,6 +117,7 @@ type InvocationSpec struct {
 	retryDelayD          time.Duration
 	keepOpenD            time.Duration
 	autoPayloadSize      int
+	ratePerSecond        float64
 	parent               *InvocationSpec
 	lock                 *sync.RWMutex
 }
@@ -130,8 +146,11 @@ type InvocationTracker struct {
 	Payloads   [][]byte            `json:"-"`
 	Channels   *InvocationChannels `json:"-"`
 	CustomID   string              `json:"customID"`
+	Verdict    *Verdict            `json:"verdict,omitempty"`
 	OnHeaders  func(http.Header, int, *gototls.PeerCertInfo)
 	client     *InvocationClient
+	feed       *feedCursor
+	stats      *runStats
 }
 
 type InvocationChannels struct {
@@ -181,25 +200,86 @@ func Shutdown() {
 
 func ValidateSpec(spec *InvocationSpec) error {
 	var err error
+	if spec.IsReplay() {
+		return spec.validateReplay()
+	}
+	if spec.IsScenario() {
+		return spec.validateScenario()
+	}
 	if err = spec.validateTrafficConfig(); err != nil {
 		return err
 	}
+	if err = spec.validateRateConfig(); err != nil {
+		return err
+	}
+	if spec.Feed != nil {
+		if err = spec.Feed.validate(); err != nil {
+			return err
+		}
+	}
+	if spec.Thresholds != nil {
+		if err = spec.Thresholds.validate(); err != nil {
+			return err
+		}
+	}
 	if err = spec.validateConnectionAndRequestConfigs(); err != nil {
 		return err
 	}
 	if err = spec.validatePayload(); err != nil {
 		return err
 	}
+	if strings.EqualFold(spec.Protocol, "sse") {
+		spec.Protocol = ""
+		if spec.SSE == nil {
+			spec.SSE = &SSESpec{}
+		}
+	}
 	spec.processProtocol()
 	spec.processAuthority()
+	if spec.SSE != nil {
+		if !spec.http || spec.ws || spec.h3 {
+			return fmt.Errorf("sse requires an HTTP/1.1 or HTTP/2 target")
+		}
+		if err = spec.SSE.validate(); err != nil {
+			return err
+		}
+		if spec.Headers == nil {
+			spec.Headers = map[string]string{}
+		}
+		hasAccept := false
+		for h := range spec.Headers {
+			hasAccept = hasAccept || strings.EqualFold(h, "Accept")
+		}
+		if !hasAccept {
+			spec.Headers["Accept"] = "text/event-stream"
+		}
+	}
+	if spec.ws {
+		if spec.WS == nil {
+			spec.WS = &WSSpec{}
+		}
+		if err = spec.WS.validate(); err != nil {
+			return err
+		}
+	} else if spec.WS != nil {
+		return fmt.Errorf("ws config requires ws or wss protocol")
+	}
 	if spec.Assertions != nil {
 		spec.prepareAssertions()
 	}
+	if spec.IsCapacitySearch() {
+		if err = spec.validateCapacity(); err != nil {
+			return err
+		}
+	}
 	spec.lock = &sync.RWMutex{}
 	return nil
 }
 
 func (is *InvocationSpec) processProtocol() {
+	if lowerURL := strings.ToLower(is.URL); strings.HasPrefix(lowerURL, "ws://") || strings.HasPrefix(lowerURL, "wss://") {
+		is.ws = true
+	}
 	if is.Protocol != "" {
 		lowerProto := strings.ToLower(is.Protocol)
 		if strings.EqualFold(lowerProto, "tcp") {
@@ -211,6 +291,15 @@ func (is *InvocationSpec) processProtocol() {
 			is.TLS = strings.EqualFold(lowerProto, "grpcs")
 			is.httpVersionMajor = 2
 			is.httpVersionMinor = 0
+		} else if lowerProto == "h3" || lowerProto == "http/3" || lowerProto == "http/3.0" {
+			is.h3 = true
+			is.TLS = true
+			is.httpVersionMajor = 3
+			is.httpVersionMinor = 0
+			is.Protocol = "HTTP/3.0"
+		} else if lowerProto == "ws" || lowerProto == "wss" {
+			is.ws = true
+			is.TLS = is.TLS || lowerProto == "wss"
 		} else if major, minor, ok := http.ParseHTTPVersion(is.Protocol); ok {
 			if major == 1 && (minor == 0 || minor == 1) {
 				is.httpVersionMajor = major
@@ -229,6 +318,10 @@ func (is *InvocationSpec) processProtocol() {
 			is.httpVersionMinor = 1
 		}
 	}
+	if is.ws {
+		is.httpVersionMajor = 1
+		is.httpVersionMinor = 1
+	}
 	if !is.tcp && is.httpVersionMajor == 0 {
 		is.httpVersionMajor = 1
 		is.httpVersionMinor = 1
@@ -242,7 +335,29 @@ func (is *InvocationSpec) processProtocol() {
 	if strings.HasPrefix(strings.ToLower(is.URL), "https") {
 		is.TLS = true
 	}
-	if is.http && !strings.HasPrefix(is.URL, "http") {
+	if is.h3 {
+		if strings.HasPrefix(strings.ToLower(is.URL), "http://") {
+			is.URL = "https://" + is.URL[len("http://"):]
+		} else if !strings.HasPrefix(strings.ToLower(is.URL), "https://") {
+			is.URL = "https://" + is.URL
+		}
+	}
+	if is.ws {
+		lowerURL := strings.ToLower(is.URL)
+		if strings.HasPrefix(lowerURL, "wss://") || strings.HasPrefix(lowerURL, "https://") {
+			is.TLS = true
+		}
+		if i := strings.Index(is.URL, "://"); i >= 0 {
+			is.URL = is.URL[i+3:]
+		}
+		if is.TLS {
+			is.URL = "wss://" + is.URL
+			is.Protocol = "wss"
+		} else {
+			is.URL = "ws://" + is.URL
+			is.Protocol = "ws"
+		}
+	} else if is.http && !strings.HasPrefix(is.URL, "http") {
 		is.URL = "http://" + is.URL
 	}
 }
@@ -460,7 +575,7 @@ func IsAnyTargetActive(targets []string) bool {
 	invocationsLock.RLock()
 	defer invocationsLock.RUnlock()
 	for _, target := range targets {
-		if activeTargets[target] != nil {
+		if activeTargets[target] != nil || isScenarioActive(target) {
 			return true
 		}
 	}
@@ -468,6 +583,9 @@ func IsAnyTargetActive(targets []string) bool {
 }
 
 func StopTarget(target string) {
+	if stopScenario(target) {
+		return
+	}
 	invocationsLock.RLock()
 	targetInvocations := activeTargets[target]
 	invocationsLock.RUnlock()
@@ -554,13 +672,21 @@ func newTracker(id uint32, clientPort int, target *InvocationSpec, sinks ...Resu
 	if err := tracker.createClient(target); err != nil {
 		return nil, err
 	}
+	var err error
+	if tracker.feed, err = newFeedCursor(target); err != nil {
+		return nil, err
+	}
+	tracker.stats = newRunStats(target)
 	tracker.Status = &InvocationStatus{tracker: tracker, lastStatusCode: -1}
 	for _, sinkFactory := range sinks {
 		if sink := sinkFactory(tracker); sink != nil {
 			tracker.Channels.Sinks = append(tracker.Channels.Sinks, sink)
 		}
 	}
-	if len(target.StreamPayload) > 0 {
+	if target.ws && len(target.WS.Messages) > 0 {
+		tracker.Payloads = target.WS.payloads()
+		target.Body = ""
+	} else if len(target.StreamPayload) > 0 {
 		for _, p := range target.StreamPayload {
 			if target.Binary {
 				if b, err := base64.RawStdEncoding.DecodeString(p); err == nil {
@@ -602,9 +728,14 @@ func getHttpClientForTarget(tracker *InvocationTracker) transport.ClientTranspor
 	client := targetClients[target.Name]
 	invocationsLock.RUnlock()
 	if client == nil || client.HTTP() == nil {
-		client = transport.CreateHTTPClient(0, target.Name, target.h2, target.AutoUpgrade, target.TLS, target.NoSNI,
-			target.authority, target.requestTimeoutD, target.connTimeoutD,
-			target.connIdleTimeoutD, metrics.ConnTracker)
+		if target.h3 {
+			client = transport.CreateHTTP3Client(0, target.Name, target.NoSNI, target.authority,
+				target.requestTimeoutD, target.connTimeoutD, target.connIdleTimeoutD, metrics.ConnTracker)
+		} else {
+			client = transport.CreateHTTPClient(0, target.Name, target.h2, target.AutoUpgrade, target.TLS, target.NoSNI,
+				target.authority, target.requestTimeoutD, target.connTimeoutD,
+				target.connIdleTimeoutD, metrics.ConnTracker)
+		}
 		if !target.LongRunning {
 			invocationsLock.Lock()
 			targetClients[target.Name] = client
@@ -662,6 +793,7 @@ func (tracker *InvocationTracker) activate() {
 }
 
 func (tracker *InvocationTracker) deactivate() {
+	tracker.evaluateThresholds()
 	if tracker.Channels != nil {
 		tracker.Channels.Done()
 		tracker.Channels.ReadStopRequest()
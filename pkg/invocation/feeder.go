@@ -0,0 +1,331 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"goto/pkg/types"
+	"goto/pkg/util"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Feeder struct {
+	Name       string                    `json:"name"`
+	Type       string                    `json:"type"`
+	Fields     []string                  `json:"fields"`
+	Rows       []map[string]string       `json:"rows"`
+	Generators map[string]*FeedGenerator `json:"generators"`
+}
+
+type FeedGenerator struct {
+	Type   string   `json:"type"`
+	Start  int      `json:"start"`
+	Step   int      `json:"step"`
+	Min    int      `json:"min"`
+	Max    int      `json:"max"`
+	Length int      `json:"length"`
+	Prefix string   `json:"prefix"`
+	Values []string `json:"values"`
+}
+
+type FeedSpec struct {
+	Feeder   string `json:"feeder"`
+	Strategy string `json:"strategy"`
+}
+
+type feedCursor struct {
+	feeder    *Feeder
+	strategy  string
+	replicas  int
+	next      int
+	generated int
+	partition map[int]int
+	lock      sync.Mutex
+}
+
+const (
+	FeederCSV       = "csv"
+	FeederJSONL     = "jsonl"
+	FeederList      = "list"
+	FeederGenerator = "generator"
+
+	FeedSequential = "sequential"
+	FeedCircular   = "circular"
+	FeedRandom     = "random"
+	FeedPartition  = "partition"
+
+	FeedGeneratorSequence = "sequence"
+	FeedGeneratorRandom   = "random"
+)
+
+var (
+	feeders     = map[string]*Feeder{}
+	feedersLock sync.RWMutex
+)
+
+// ParseFeeder builds a feeder from uploaded content of the given type. CSV content must carry a header row
+// naming the fields, JSONL content carries one JSON object per line, and list content carries one value per line
+// for the single given field.
+func ParseFeeder(name, feederType, field string, content io.Reader) (*Feeder, error) {
+	f := &Feeder{Name: name, Type: strings.ToLower(feederType)}
+	switch f.Type {
+	case FeederCSV:
+		records, err := csv.NewReader(content).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("csv content must have a header row")
+		}
+		f.Fields = records[0]
+		for _, record := range records[1:] {
+			row := map[string]string{}
+			for i, field := range f.Fields {
+				if i < len(record) {
+					row[field] = record[i]
+				}
+			}
+			f.Rows = append(f.Rows, row)
+		}
+	case FeederJSONL:
+		scanner := bufio.NewScanner(content)
+		scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			data := map[string]any{}
+			if err := json.Unmarshal([]byte(line), &data); err != nil {
+				return nil, fmt.Errorf("invalid jsonl line [%s]: %s", line, err.Error())
+			}
+			row := map[string]string{}
+			for k, v := range data {
+				if s, ok := v.(string); ok {
+					row[k] = s
+				} else {
+					row[k] = util.ToJSONText(v)
+				}
+			}
+			f.Rows = append(f.Rows, row)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case FeederList:
+		if field == "" {
+			field = "value"
+		}
+		scanner := bufio.NewScanner(content)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				f.Rows = append(f.Rows, map[string]string{field: line})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported feeder type [%s]", feederType)
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Feeder) validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("feeder name is required")
+	}
+	if len(f.Rows) == 0 && len(f.Generators) == 0 {
+		return fmt.Errorf("feeder [%s] has no rows or generators", f.Name)
+	}
+	if f.Type == "" {
+		if len(f.Rows) > 0 {
+			f.Type = FeederList
+		} else {
+			f.Type = FeederGenerator
+		}
+	}
+	for field, g := range f.Generators {
+		if g == nil {
+			return fmt.Errorf("generator [%s] is empty", field)
+		}
+		g.Type = strings.ToLower(g.Type)
+		switch g.Type {
+		case FeedGeneratorSequence:
+			if g.Step == 0 {
+				g.Step = 1
+			}
+		case FeedGeneratorRandom:
+			if len(g.Values) == 0 && g.Length <= 0 && g.Max < g.Min {
+				return fmt.Errorf("generator [%s] has invalid range [%d-%d]", field, g.Min, g.Max)
+			}
+		default:
+			return fmt.Errorf("generator [%s] has invalid type [%s]", field, g.Type)
+		}
+	}
+	if len(f.Fields) == 0 {
+		fields := map[string]bool{}
+		for _, row := range f.Rows {
+			for k := range row {
+				fields[k] = true
+			}
+		}
+		for k := range f.Generators {
+			fields[k] = true
+		}
+		for k := range fields {
+			f.Fields = append(f.Fields, k)
+		}
+		sort.Strings(f.Fields)
+	}
+	return nil
+}
+
+func AddFeeder(f *Feeder) error {
+	if err := f.validate(); err != nil {
+		return err
+	}
+	feedersLock.Lock()
+	defer feedersLock.Unlock()
+	feeders[f.Name] = f
+	return nil
+}
+
+func RemoveFeeders(names []string) {
+	feedersLock.Lock()
+	defer feedersLock.Unlock()
+	for _, name := range names {
+		delete(feeders, name)
+	}
+}
+
+func ClearFeeders() {
+	feedersLock.Lock()
+	defer feedersLock.Unlock()
+	feeders = map[string]*Feeder{}
+}
+
+func GetFeeder(name string) *Feeder {
+	feedersLock.RLock()
+	defer feedersLock.RUnlock()
+	return feeders[name]
+}
+
+func GetFeeders() map[string]*Feeder {
+	feedersLock.RLock()
+	defer feedersLock.RUnlock()
+	result := map[string]*Feeder{}
+	for name, f := range feeders {
+		result[name] = f
+	}
+	return result
+}
+
+func (fs *FeedSpec) validate() error {
+	if fs.Feeder == "" {
+		return fmt.Errorf("feed requires a feeder name")
+	}
+	fs.Strategy = strings.ToLower(fs.Strategy)
+	switch fs.Strategy {
+	case "":
+		fs.Strategy = FeedCircular
+	case FeedSequential, FeedCircular, FeedRandom, FeedPartition:
+	default:
+		return fmt.Errorf("invalid feed strategy [%s]", fs.Strategy)
+	}
+	return nil
+}
+
+func newFeedCursor(target *InvocationSpec) (*feedCursor, error) {
+	if target.Feed == nil {
+		return nil, nil
+	}
+	feeder := GetFeeder(target.Feed.Feeder)
+	if feeder == nil {
+		return nil, fmt.Errorf("feeder [%s] not found", target.Feed.Feeder)
+	}
+	return &feedCursor{feeder: feeder, strategy: target.Feed.Strategy, replicas: max(target.Replicas, 1), partition: map[int]int{}}, nil
+}
+
+// nextRow returns the row to use for the next request sent by the given replica (0-based). It returns false
+// once a sequential feed runs out of rows, or when a replica's partition has no rows.
+func (fc *feedCursor) nextRow(replica int) (map[string]string, bool) {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	rows := fc.feeder.Rows
+	row := map[string]string{}
+	if count := len(rows); count > 0 {
+		index := 0
+		switch fc.strategy {
+		case FeedSequential:
+			if fc.next >= count {
+				return nil, false
+			}
+			index = fc.next
+			fc.next++
+		case FeedRandom:
+			index = types.Random(count)
+		case FeedPartition:
+			replica = replica % fc.replicas
+			size := 0
+			if replica < count {
+				size = (count - replica + fc.replicas - 1) / fc.replicas
+			}
+			if size == 0 {
+				return nil, false
+			}
+			index = replica + (fc.partition[replica]%size)*fc.replicas
+			fc.partition[replica]++
+		default:
+			index = fc.next % count
+			fc.next++
+		}
+		for k, v := range rows[index] {
+			row[k] = v
+		}
+	}
+	seq := fc.generated
+	fc.generated++
+	for field, g := range fc.feeder.Generators {
+		row[field] = g.generate(seq)
+	}
+	return row, true
+}
+
+func (g *FeedGenerator) generate(seq int) string {
+	value := ""
+	switch g.Type {
+	case FeedGeneratorSequence:
+		value = strconv.Itoa(g.Start + seq*g.Step)
+	case FeedGeneratorRandom:
+		if len(g.Values) > 0 {
+			value = g.Values[types.Random(len(g.Values))]
+		} else if g.Length > 0 {
+			value = types.GenerateRandomString(g.Length)
+		} else {
+			value = strconv.Itoa(g.Min + types.Random(g.Max-g.Min+1))
+		}
+	}
+	return g.Prefix + value
+}
@@ -28,10 +28,12 @@ type RunnerId uint32
 type InvocationIDs struct {
 	targetID  string
 	requestID string
+	replica   int
 }
 
 type TargetRunner struct {
 	id           RunnerId
+	replica      int
 	tracker      *InvocationTracker
 	requestsChan chan *InvocationIDs
 	doneChan     chan RunnerId
@@ -60,12 +62,19 @@ func StartInvocation(tracker *InvocationTracker, waitForResponse ...bool) []*Inv
 
 	go processStopRequest(tracker)
 
+	if target.IsOpenLoop() {
+		startOpenLoop(tracker)
+		tracker.deactivate()
+		return results
+	}
+
 	runners := make(map[RunnerId]*TargetRunner, target.Replicas)
 	runnersDoneChan := make(chan RunnerId, target.Replicas)
 
 	for i := 0; i < target.Replicas; i++ {
 		id := RunnerId(NextRunnerId.Add(1))
 		runners[id] = NewTargetRunner(id, tracker, runnersDoneChan)
+		runners[id].replica = i
 		runners[id].sendInvocation(computeInvocationIDs(tracker, id))
 		go runners[id].processInvocations()
 	}
@@ -121,6 +130,7 @@ func (t *TargetRunner) processInvocations() {
 		time.Sleep(t.tracker.Target.delayD)
 		select {
 		case i := <-t.requestsChan:
+			i.replica = t.replica
 			t.invoke(i, true)
 			if t.doneChan != nil {
 				t.doneChan <- t.id
@@ -133,10 +143,19 @@ func (t *TargetRunner) processInvocations() {
 
 func (t *TargetRunner) invoke(i *InvocationIDs, publish bool) {
 	target := t.tracker.Target
-	if result := t.tracker.invokeWithRetries(i.requestID, i.targetID); result != nil {
+	var row map[string]string
+	if t.tracker.feed != nil {
+		var ok bool
+		if row, ok = t.tracker.feed.nextRow(i.replica); !ok {
+			t.tracker.logFeedExhausted(i.replica)
+			t.tracker.Status.StopRequested = true
+			return
+		}
+	}
+	if result := t.tracker.invokeWithRetries(i.requestID, i.targetID, row); result != nil {
 		if !t.tracker.Status.StopRequested && !t.tracker.Status.Stopped {
 			if publish && target.AB {
-				handleABCall(t.tracker, i.requestID, i.targetID, publish)
+				handleABCall(t.tracker, i.requestID, i.targetID, row, publish)
 			}
 		}
 		if publish && !t.tracker.Status.StopRequested && !t.tracker.Status.Stopped {
@@ -157,13 +176,13 @@ func doWarmupRounds(tracker *InvocationTracker) {
 	}
 }
 
-func handleABCall(tracker *InvocationTracker, requestID string, targetID string, publish bool) {
+func handleABCall(tracker *InvocationTracker, requestID string, targetID string, row map[string]string, publish bool) {
 	for i, burl := range tracker.Target.BURLS {
 		if tracker.Status.StopRequested || tracker.Status.Stopped {
 			break
 		}
 		bRequestID := fmt.Sprintf("%s-B-%d", requestID, i+1)
-		if result := tracker.invokeWithRetries(bRequestID, targetID, burl); result != nil {
+		if result := tracker.invokeWithRetries(bRequestID, targetID, row, burl); result != nil {
 			if publish && !tracker.Status.StopRequested && !tracker.Status.Stopped {
 				tracker.publishResult(result)
 				tracker.Status.incrementABCount()
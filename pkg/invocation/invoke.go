@@ -30,6 +30,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/http/httptrace"
 	"strconv"
 	"time"
 
@@ -43,6 +44,7 @@ type InvocationRequest struct {
 	uri             string
 	host            string
 	headers         types.SimpleHTTPHeaders
+	payloads        [][]byte
 	httpRequest     *http.Request
 	grpcInput       *pb.Input
 	grpcStreamInput *pb.StreamConfig
@@ -51,12 +53,13 @@ type InvocationRequest struct {
 	tracker         *InvocationTracker
 	result          *InvocationResult
 	lowerHeaders    bool
+	firstByteAt     time.Time
 }
 
-func (tracker *InvocationTracker) invokeWithRetries(requestID string, targetID string, urls ...string) *InvocationResult {
+func (tracker *InvocationTracker) invokeWithRetries(requestID string, targetID string, row map[string]string, urls ...string) *InvocationResult {
 	status := tracker.Status
 	target := tracker.Target
-	request := tracker.newInvocationRequest(requestID, targetID, urls...)
+	request := tracker.newInvocationRequest(requestID, targetID, row, urls...)
 	if request == nil {
 		return nil
 	}
@@ -84,7 +87,7 @@ func (tracker *InvocationTracker) invokeWithRetries(requestID string, targetID s
 			request.requestID = fmt.Sprintf("%s-%d", requestID, i+2)
 			request.addOrUpdateHeader(HeaderGotoRetryCount, strconv.Itoa(i+1))
 			if target.Fallback && len(target.BURLS) > i {
-				request.url = target.BURLS[i]
+				request.url = util.FillPlaceholders(target.BURLS[i], row)
 				if !tracker.client.prepareRequest(request) {
 					tracker.logBRequestCreationFailed(result, target.BURLS[i])
 				} else {
@@ -106,22 +109,37 @@ func (tracker *InvocationTracker) invokeWithRetries(requestID string, targetID s
 	return result
 }
 
-func (tracker *InvocationTracker) newInvocationRequest(requestID, targetID string, substituteURL ...string) *InvocationRequest {
+func (tracker *InvocationTracker) newInvocationRequest(requestID, targetID string, row map[string]string, substituteURL ...string) *InvocationRequest {
 	if tracker.client == nil {
 		return nil
 	}
-	url := tracker.prepareRequestURL(requestID, targetID, substituteURL...)
+	url := util.FillPlaceholders(tracker.prepareRequestURL(requestID, targetID, substituteURL...), row)
 	headers := tracker.prepareRequestHeaders(requestID, targetID, url)
-	return tracker.newRequest(requestID, targetID, url, headers)
+	payloads := tracker.Payloads
+	if row != nil {
+		for h, v := range headers {
+			headers[h] = util.FillPlaceholders(v, row)
+		}
+		if !tracker.Target.Binary {
+			payloads = make([][]byte, len(tracker.Payloads))
+			for i, p := range tracker.Payloads {
+				if p != nil {
+					payloads[i] = []byte(util.FillPlaceholders(string(p), row))
+				}
+			}
+		}
+	}
+	return tracker.newRequest(requestID, targetID, url, headers, payloads)
 }
 
-func (tracker *InvocationTracker) newRequest(requestID, targetID, url string, headers types.SimpleHTTPHeaders) *InvocationRequest {
+func (tracker *InvocationTracker) newRequest(requestID, targetID, url string, headers types.SimpleHTTPHeaders, payloads [][]byte) *InvocationRequest {
 	ir := &InvocationRequest{
 		requestID: requestID,
 		targetID:  targetID,
 		url:       url,
 		host:      tracker.Target.Host,
 		headers:   headers,
+		payloads:  payloads,
 		client:    tracker.client.transportClient,
 		tracker:   tracker,
 	}
@@ -198,13 +216,18 @@ func (client *InvocationClient) prepareRequest(ir *InvocationRequest) bool {
 		} else if client.transportClient.IsHTTP() {
 			var requestReader io.ReadCloser
 			var requestWriter io.WriteCloser
-			if len(client.tracker.Payloads) > 1 {
+			if len(ir.payloads) > 1 && !client.tracker.Target.ws {
 				requestReader, requestWriter = io.Pipe()
-			} else if len(client.tracker.Payloads) == 1 && len(client.tracker.Payloads[0]) > 0 {
-				requestReader = io.NopCloser(bytes.NewReader(client.tracker.Payloads[0]))
-				ir.result.Request.PayloadSize = len(client.tracker.Payloads[0])
+			} else if len(ir.payloads) == 1 && len(ir.payloads[0]) > 0 {
+				requestReader = io.NopCloser(bytes.NewReader(ir.payloads[0]))
+				ir.result.Request.PayloadSize = len(ir.payloads[0])
 			}
 			if req, err := http.NewRequest(client.tracker.Target.Method, ir.url, requestReader); err == nil {
+				req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+					GotFirstResponseByte: func() {
+						ir.firstByteAt = time.Now()
+					},
+				}))
 				req.Proto = "HTTP/2"
 				ir.httpRequest = req
 				ir.addOrUpdateRequestId()
@@ -244,7 +267,7 @@ func (tracker *InvocationTracker) prepareRequestHeaders(requestID, targetID, url
 func (ir *InvocationRequest) writeRequestPayload() {
 	if ir.requestWriter != nil {
 		go func() {
-			size, first, last, err := util.WriteAndTrack(ir.requestWriter, ir.tracker.Payloads, ir.tracker.Target.streamDelayD)
+			size, first, last, err := util.WriteAndTrack(ir.requestWriter, ir.payloads, ir.tracker.Target.streamDelayD)
 			if ir.tracker.Target.TrackPayload {
 				if err == nil {
 					ir.result.Request.PayloadSize = size
@@ -261,6 +284,10 @@ func (ir *InvocationRequest) writeRequestPayload() {
 func (ir *InvocationRequest) invoke() {
 	if ir.client.IsGRPC() {
 		ir.invokeGRPC()
+	} else if ir.tracker.Target.ws {
+		ir.invokeWS()
+	} else if ir.tracker.Target.SSE != nil {
+		ir.invokeSSE()
 	} else {
 		ir.invokeHTTP()
 	}
@@ -290,10 +317,14 @@ func (ir *InvocationRequest) invokeHTTP() {
 		ir.client.UpdateTLSCerts(gototls.RootCAs, certs)
 	}
 	ir.writeRequestPayload()
+	ir.firstByteAt = time.Time{}
 	start := time.Now()
 	resp, err := ir.client.HTTP().Do(ir.httpRequest)
 	end := time.Now()
 	ir.result.trackRequest(start, end)
+	if !ir.firstByteAt.IsZero() {
+		ir.result.FirstByteNanos = ir.firstByteAt.Sub(start)
+	}
 	ir.tracker.Status.trackRequest(end)
 	ir.result.processHTTPResponse(ir, resp, err)
 }
@@ -304,7 +335,7 @@ func (ir *InvocationRequest) invokeGRPC() {
 		return
 	}
 	payloads := &grpc.GRPCPayloads{Linear: []*grpc.GRPCPayload{}}
-	for _, p := range ir.tracker.Payloads {
+	for _, p := range ir.payloads {
 		payload := &grpc.GRPCPayload{Payload: string(p)}
 		payloads.Linear = append(payloads.Linear, payload)
 	}
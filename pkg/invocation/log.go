@@ -44,6 +44,33 @@ func (tracker *InvocationTracker) logStoppingInvocation() {
 	}
 }
 
+func (tracker *InvocationTracker) logFeedExhausted(replica int) {
+	if global.Flags.EnableInvocationLogs {
+		log.Printf("[%s]: Invocation[%d]: Stopping target [%s] as feeder [%s] has no more rows for replica [%d]\n",
+			global.Self.Name, tracker.ID, tracker.Target.Name, tracker.Target.Feed.Feeder, replica+1)
+	}
+}
+
+func (tracker *InvocationTracker) logThresholdsBreached(violations []*Violation) {
+	if global.Flags.EnableInvocationLogs {
+		log.Printf("[%s]: Invocation[%d]: Aborting target [%s] as thresholds were breached: %s\n",
+			global.Self.Name, tracker.ID, tracker.Target.Name, formatViolations(violations))
+	}
+}
+
+func (tracker *InvocationTracker) logVerdict() {
+	verdict := tracker.Verdict
+	events.SendEventJSON(events.Client_TargetVerdict, fmt.Sprintf("%d-%s", tracker.ID, tracker.Target.Name),
+		map[string]interface{}{"id": tracker.ID, "target": tracker.Target.Name, "verdict": verdict})
+	if global.Flags.EnableInvocationLogs {
+		if verdict.Pass {
+			log.Printf("[%s]: Invocation[%d]: Target [%s] passed thresholds\n", global.Self.Name, tracker.ID, tracker.Target.Name)
+		} else {
+			log.Printf("[%s]: Invocation[%d]: Target [%s] failed thresholds: %s\n", global.Self.Name, tracker.ID, tracker.Target.Name, formatViolations(verdict.Violations))
+		}
+	}
+}
+
 func (tracker *InvocationTracker) logFinishedInvocation(remaining int) {
 	events.SendEventJSON(events.Client_InvocationFinished, fmt.Sprintf("%d-%s", tracker.ID, tracker.Target.Name),
 		map[string]interface{}{"id": tracker.ID, "target": tracker.Target.Name, "status": tracker.Status})
@@ -85,6 +112,20 @@ func (tracker *InvocationTracker) logConnectionFailed(details string) {
 	}
 }
 
+func (tracker *InvocationTracker) logWSClosed(result *InvocationResult) {
+	if global.Flags.EnableInvocationLogs {
+		log.Printf("[%s]: Invocation[%d]: Target [%s] websocket closed by %s with code [%d] after [%s], messages out [%d] in [%d].\n",
+			global.Self.Name, tracker.ID, tracker.Target.Name, result.WS.ClosedBy, result.WS.CloseCode, result.WS.DurationNanos, result.WS.MessagesOut, result.WS.MessagesIn)
+	}
+}
+
+func (tracker *InvocationTracker) logSSEFinished(result *InvocationResult) {
+	if global.Flags.EnableInvocationLogs {
+		log.Printf("[%s]: Invocation[%d]: Target [%s] event stream finished with reason [%s] after [%s], events [%d], reconnects [%d].\n",
+			global.Self.Name, tracker.ID, tracker.Target.Name, result.SSE.StopReason, result.TookNanos, result.SSE.Events, result.SSE.Reconnects)
+	}
+}
+
 func (tracker *InvocationTracker) logResultChannelBacklog(result *InvocationResult, size int) {
 	if global.Flags.EnableInvocationLogs {
 		log.Printf("[%s]: Invocation[%d]: Target %s ResultChannel length %d\n",
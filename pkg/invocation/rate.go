@@ -0,0 +1,232 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RateStage struct {
+	Rate      string `json:"rate"`
+	Duration  string `json:"duration"`
+	perSecond float64
+	durationD time.Duration
+}
+
+const (
+	maxScheduleSleep   = 500 * time.Millisecond
+	defaultMaxInFlight = 1000
+)
+
+// ParseRate parses a rate given as `<count>/<unit>` (e.g. `500/s`, `100/m`, `10/100ms`)
+// or a plain number treated as requests per second, and returns the rate per second.
+func ParseRate(rate string) (float64, error) {
+	rate = strings.TrimSpace(rate)
+	count := rate
+	unit := time.Second
+	if pieces := strings.SplitN(rate, "/", 2); len(pieces) == 2 {
+		count = strings.TrimSpace(pieces[0])
+		u := strings.TrimSpace(pieces[1])
+		switch strings.ToLower(u) {
+		case "s", "sec", "second":
+			unit = time.Second
+		case "m", "min", "minute":
+			unit = time.Minute
+		case "h", "hour":
+			unit = time.Hour
+		default:
+			d, err := time.ParseDuration(u)
+			if err != nil || d <= 0 {
+				return 0, fmt.Errorf("invalid rate unit [%s]", u)
+			}
+			unit = d
+		}
+	}
+	c, err := strconv.ParseFloat(count, 64)
+	if err != nil || c < 0 {
+		return 0, fmt.Errorf("invalid rate [%s]", rate)
+	}
+	return c / unit.Seconds(), nil
+}
+
+func (rs *RateStage) validate() error {
+	var err error
+	if rs.perSecond, err = ParseRate(rs.Rate); err != nil {
+		return err
+	}
+	if rs.durationD, err = time.ParseDuration(rs.Duration); err != nil || rs.durationD <= 0 {
+		return fmt.Errorf("invalid duration [%s] for rate stage [%s]", rs.Duration, rs.Rate)
+	}
+	return nil
+}
+
+func (is *InvocationSpec) validateRateConfig() error {
+	if is.MaxInFlight < 0 {
+		return fmt.Errorf("maxInFlight can't be negative")
+	}
+	if is.Rate == "" {
+		if len(is.RampUp) > 0 || len(is.RampDown) > 0 {
+			return fmt.Errorf("rampUp/rampDown stages require a rate")
+		}
+		if is.MaxInFlight > 0 && is.Capacity == nil {
+			return fmt.Errorf("maxInFlight requires a rate")
+		}
+		return nil
+	}
+	var err error
+	if is.ratePerSecond, err = ParseRate(is.Rate); err != nil {
+		return err
+	}
+	if is.ratePerSecond <= 0 {
+		return fmt.Errorf("rate must be greater than zero")
+	}
+	for _, stage := range is.RampUp {
+		if err = stage.validate(); err != nil {
+			return err
+		}
+	}
+	for _, stage := range is.RampDown {
+		if err = stage.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (is *InvocationSpec) IsOpenLoop() bool {
+	return is.ratePerSecond > 0
+}
+
+// startOpenLoop dispatches requests on a fixed schedule derived from the target's rate,
+// without waiting for earlier responses. Each replica runs its own schedule, as replicas are
+// parallel invocations in closed-loop mode too. Each scheduled request runs on its own goroutine
+// so that a slow response never delays the requests that follow it, up to the replica's
+// `maxInFlight` requests.
+func startOpenLoop(tracker *InvocationTracker) {
+	maxInFlight := tracker.Target.MaxInFlight
+	if maxInFlight == 0 {
+		maxInFlight = defaultMaxInFlight
+	}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < tracker.Target.Replicas; i++ {
+		runner := NewTargetRunner(RunnerId(NextRunnerId.Add(1)), tracker, nil)
+		runner.replica = i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runner.runSchedule(wg, make(chan struct{}, maxInFlight))
+		}()
+	}
+	wg.Wait()
+}
+
+// runSchedule sends the replica's requests through the ramp-up stages, `requestCount` requests at the
+// steady rate, and the ramp-down stages.
+func (t *TargetRunner) runSchedule(wg *sync.WaitGroup, inFlight chan struct{}) {
+	target := t.tracker.Target
+	fromRate := 0.0
+	for _, stage := range target.RampUp {
+		t.dispatchAtRate(wg, inFlight, fromRate, stage.perSecond, stage.durationD, 0)
+		fromRate = stage.perSecond
+	}
+	t.dispatchAtRate(wg, inFlight, target.ratePerSecond, target.ratePerSecond, 0, target.RequestCount)
+	fromRate = target.ratePerSecond
+	for _, stage := range target.RampDown {
+		t.dispatchAtRate(wg, inFlight, fromRate, stage.perSecond, stage.durationD, 0)
+		fromRate = stage.perSecond
+	}
+}
+
+// dispatchAtRate sends requests while the rate changes linearly from `from` to `to` over the given duration.
+// When duration is zero, the rate stays at `from` until `count` requests have been dispatched.
+// A request that comes due while the replica already has as many requests in flight as the inFlight
+// channel holds is skipped and counted, rather than queued, so that the schedule isn't shifted.
+func (t *TargetRunner) dispatchAtRate(wg *sync.WaitGroup, inFlight chan struct{}, from, to float64, duration time.Duration, count int) {
+	tracker := t.tracker
+	start := time.Now()
+	for n := 1; count == 0 || n <= count; n++ {
+		at, ok := scheduleOffset(n, from, to, duration)
+		if !ok {
+			if duration > 0 {
+				t.sleepUntil(start.Add(duration))
+			}
+			return
+		}
+		if !t.sleepUntil(start.Add(at)) {
+			return
+		}
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			tracker.Status.incrementSkippedCount()
+			continue
+		}
+		ids := &InvocationIDs{replica: t.replica}
+		tracker.Status.lock.Lock()
+		ids.targetID, ids.requestID = computeInvocationIDs(tracker, t.id)
+		tracker.Status.lock.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+			t.invoke(ids, true)
+			tracker.Status.incrementCompletedCount()
+		}()
+	}
+}
+
+func (t *TargetRunner) sleepUntil(when time.Time) bool {
+	for {
+		if t.tracker.Status.StopRequested || t.tracker.Status.Stopped {
+			return false
+		}
+		wait := time.Until(when)
+		if wait <= 0 {
+			return true
+		}
+		time.Sleep(min(wait, maxScheduleSleep))
+	}
+}
+
+// scheduleOffset returns the time offset of the nth request within a stage whose rate ramps
+// linearly from `from` to `to`, by solving from*t + (to-from)*t^2/(2*duration) = n for t.
+func scheduleOffset(n int, from, to float64, duration time.Duration) (time.Duration, bool) {
+	var seconds float64
+	if duration <= 0 || from == to {
+		if from <= 0 {
+			return 0, false
+		}
+		seconds = float64(n) / from
+	} else {
+		accel := (to - from) / duration.Seconds()
+		disc := from*from + 2*accel*float64(n)
+		if disc < 0 {
+			return 0, false
+		}
+		seconds = (math.Sqrt(disc) - from) / accel
+	}
+	at := time.Duration(seconds * float64(time.Second))
+	if duration > 0 && at > duration {
+		return 0, false
+	}
+	return at, true
+}
@@ -0,0 +1,298 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"fmt"
+	"goto/pkg/events"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ReplaySpec struct {
+	Speed       float64           `json:"speed"`
+	DiffHeaders []string          `json:"diffHeaders"`
+	IgnoreBody  bool              `json:"ignoreBody"`
+	Exchanges   []*ReplayExchange `json:"exchanges"`
+	specs       []*InvocationSpec
+	offsets     []time.Duration
+}
+
+// ReplayExchange is a request/response pair as recorded by a goto server's request recorder.
+type ReplayExchange struct {
+	At              time.Time           `json:"at"`
+	Method          string              `json:"method"`
+	URL             string              `json:"url"`
+	URI             string              `json:"uri"`
+	RequestHeaders  map[string][]string `json:"requestHeaders"`
+	RequestBody     string              `json:"requestBody"`
+	StatusCode      int                 `json:"statusCode"`
+	ResponseHeaders map[string][]string `json:"responseHeaders"`
+	ResponseBody    string              `json:"responseBody"`
+	Truncated       bool                `json:"truncated,omitempty"`
+}
+
+type ReplayMismatch struct {
+	Expected any `json:"expected"`
+	Actual   any `json:"actual"`
+}
+
+type ReplayDiff struct {
+	Exchange int                        `json:"exchange"`
+	Method   string                     `json:"method"`
+	URI      string                     `json:"uri"`
+	Match    bool                       `json:"match"`
+	Status   *ReplayMismatch            `json:"status,omitempty"`
+	Body     *ReplayMismatch            `json:"body,omitempty"`
+	Headers  map[string]*ReplayMismatch `json:"headers,omitempty"`
+}
+
+const (
+	maxReplayBodySnippet = 100
+)
+
+var (
+	replaySkipHeaders = map[string]bool{
+		"Host": true, "Content-Length": true, "Connection": true, "Keep-Alive": true, "Transfer-Encoding": true,
+		"Te": true, "Trailer": true, "Upgrade": true, "Proxy-Connection": true, "Accept-Encoding": true,
+	}
+)
+
+func (is *InvocationSpec) IsReplay() bool {
+	return is.Replay != nil
+}
+
+func (is *InvocationSpec) validateReplay() error {
+	var err error
+	if is.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if is.IsScenario() {
+		return fmt.Errorf("replay cannot have steps")
+	}
+	if is.Rate != "" {
+		return fmt.Errorf("rate is not supported for replay")
+	}
+	replay := is.Replay
+	if replay.Speed < 0 {
+		return fmt.Errorf("invalid replay speed")
+	} else if replay.Speed == 0 {
+		replay.Speed = 1
+	}
+	if len(replay.Exchanges) == 0 {
+		return fmt.Errorf("replay needs at least one exchange")
+	}
+	var base *url.URL
+	if is.URL != "" {
+		if base, err = url.Parse(is.URL); err != nil || base.Host == "" {
+			return fmt.Errorf("invalid replay base url [%s]", is.URL)
+		}
+	}
+	if is.RequestCount < 0 {
+		return fmt.Errorf("invalid requestCount")
+	} else if is.RequestCount == 0 {
+		is.RequestCount = 1
+	}
+	if is.InitialDelay != "" {
+		if is.initialDelayD, err = time.ParseDuration(is.InitialDelay); err != nil {
+			return fmt.Errorf("invalid initial delay")
+		}
+	}
+	if is.Delay != "" {
+		if is.delayD, err = time.ParseDuration(is.Delay); err != nil {
+			return fmt.Errorf("invalid delay")
+		}
+	}
+	first := time.Time{}
+	for _, ex := range replay.Exchanges {
+		if ex != nil && (first.IsZero() || ex.At.Before(first)) {
+			first = ex.At
+		}
+	}
+	replay.specs = nil
+	replay.offsets = nil
+	for i, ex := range replay.Exchanges {
+		if ex == nil {
+			return fmt.Errorf("replay exchange [%d] is empty", i+1)
+		}
+		spec, err := is.replaySpec(ex, base)
+		if err != nil {
+			return fmt.Errorf("replay exchange [%d]: %s", i+1, err.Error())
+		}
+		if err = ValidateSpec(spec); err != nil {
+			return fmt.Errorf("replay exchange [%d]: %s", i+1, err.Error())
+		}
+		replay.specs = append(replay.specs, spec)
+		replay.offsets = append(replay.offsets, time.Duration(float64(ex.At.Sub(first))/replay.Speed))
+	}
+	is.lock = &sync.RWMutex{}
+	return nil
+}
+
+// replaySpec builds the invocation for one recorded exchange, using the replay target as the template
+// for everything other than the request itself. Headers configured on the replay target override the
+// recorded headers, and a base url on the replay target replaces the recorded scheme and host.
+func (is *InvocationSpec) replaySpec(ex *ReplayExchange, base *url.URL) (*InvocationSpec, error) {
+	u, err := url.Parse(ex.URL)
+	if err != nil || (u.Host == "" && base == nil) {
+		return nil, fmt.Errorf("invalid url [%s]", ex.URL)
+	}
+	if base != nil {
+		u.Scheme = base.Scheme
+		u.Host = base.Host
+		if base.Path != "" && base.Path != "/" {
+			u.Path = strings.TrimSuffix(base.Path, "/") + u.Path
+			u.RawPath = ""
+		}
+	}
+	spec := is.Clone()
+	spec.Replay = nil
+	spec.Feed = nil
+	spec.Thresholds = nil
+	spec.Method = ex.Method
+	spec.URL = u.String()
+	spec.Body = ex.RequestBody
+	spec.Replicas = 1
+	spec.RequestCount = 1
+	spec.InitialDelay = ""
+	spec.Delay = ""
+	spec.CollectResponse = true
+	spec.Headers = map[string]string{}
+	for h, values := range ex.RequestHeaders {
+		if !replaySkipHeaders[http.CanonicalHeaderKey(h)] && len(values) > 0 {
+			spec.Headers[h] = strings.Join(values, ", ")
+		}
+	}
+	for h, v := range is.Headers {
+		spec.Headers[h] = v
+	}
+	return spec, nil
+}
+
+// StartReplay sends the recorded requests with the same relative timing as they were recorded,
+// divided by the replay speed. The whole recording is replayed `requestCount` times, waiting for
+// all requests of one pass to finish before the next pass. Each response is diffed against the
+// recorded response, and the diff is attached to the invocation result.
+func StartReplay(clientPort int, replay *InvocationSpec, sinks ResultSinkFactory) {
+	run := &scenarioRun{}
+	invocationsLock.Lock()
+	activeScenarios[replay.Name] = run
+	invocationsLock.Unlock()
+	defer func() {
+		invocationsLock.Lock()
+		if activeScenarios[replay.Name] == run {
+			delete(activeScenarios, replay.Name)
+		}
+		invocationsLock.Unlock()
+	}()
+	time.Sleep(replay.initialDelayD)
+	details := map[string]any{"target": replay.Name, "exchanges": len(replay.Replay.Exchanges), "speed": replay.Replay.Speed}
+	events.SendEventJSON(events.Client_ReplayStarted, replay.Name, details)
+	for i := 1; i <= replay.RequestCount && !run.isStopRequested(); i++ {
+		if i > 1 {
+			time.Sleep(replay.delayD)
+		}
+		runReplayPass(clientPort, replay, run, i, sinks)
+	}
+	events.SendEventJSON(events.Client_ReplayFinished, replay.Name, details)
+}
+
+func runReplayPass(clientPort int, replay *InvocationSpec, run *scenarioRun, pass int, sinks ResultSinkFactory) {
+	spec := replay.Replay
+	start := time.Now()
+	wg := &sync.WaitGroup{}
+	for i, ex := range spec.Exchanges {
+		for wait := spec.offsets[i] - time.Since(start); wait > 0 && !run.isStopRequested(); wait = spec.offsets[i] - time.Since(start) {
+			time.Sleep(min(wait, 100*time.Millisecond))
+		}
+		if run.isStopRequested() {
+			break
+		}
+		tracker, err := RegisterInvocation(clientPort, spec.specs[i], ex.diffSinkFactory(i+1, spec, sinks))
+		if err != nil {
+			log.Printf("Replay [%s]: failed to register exchange [%d] with error: %s\n", replay.Name, i+1, err.Error())
+			continue
+		}
+		tracker.CustomID = fmt.Sprintf("%d-%d", pass, i+1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			StartInvocation(tracker, true)
+		}()
+	}
+	wg.Wait()
+}
+
+func (ex *ReplayExchange) diffSinkFactory(index int, spec *ReplaySpec, sinks ResultSinkFactory) ResultSinkFactory {
+	return func(tracker *InvocationTracker) ResultSink {
+		var sink ResultSink
+		if sinks != nil {
+			sink = sinks(tracker)
+		}
+		return func(result *InvocationResult) {
+			if result != nil {
+				result.ReplayDiff = ex.diff(index, spec, result)
+			}
+			if sink != nil {
+				sink(result)
+			}
+		}
+	}
+}
+
+// diff compares the replayed response with the recorded response. Bodies are not compared if the
+// recorded body was truncated or if the replay is configured to ignore bodies.
+func (ex *ReplayExchange) diff(index int, spec *ReplaySpec, result *InvocationResult) *ReplayDiff {
+	diff := &ReplayDiff{Exchange: index, Method: ex.Method, URI: ex.URI}
+	statusCode := 0
+	var payload []byte
+	var headers http.Header
+	if result.Response != nil {
+		statusCode = result.Response.StatusCode
+		payload = result.Response.Payload
+		headers = result.Response.Headers
+	}
+	if statusCode != ex.StatusCode {
+		diff.Status = &ReplayMismatch{Expected: ex.StatusCode, Actual: statusCode}
+	}
+	if !spec.IgnoreBody && !ex.Truncated && string(payload) != ex.ResponseBody {
+		diff.Body = &ReplayMismatch{Expected: snippet(ex.ResponseBody), Actual: snippet(string(payload))}
+	}
+	for _, h := range spec.DiffHeaders {
+		expected := http.Header(ex.ResponseHeaders).Get(h)
+		actual := headers.Get(h)
+		if expected != actual {
+			if diff.Headers == nil {
+				diff.Headers = map[string]*ReplayMismatch{}
+			}
+			diff.Headers[h] = &ReplayMismatch{Expected: expected, Actual: actual}
+		}
+	}
+	diff.Match = diff.Status == nil && diff.Body == nil && diff.Headers == nil
+	return diff
+}
+
+func snippet(text string) string {
+	if len(text) > maxReplayBodySnippet {
+		return text[:maxReplayBodySnippet] + "..."
+	}
+	return text
+}
@@ -72,6 +72,10 @@ type InvocationResult struct {
 	ValidAssertionIndex int                       `json:"validAssertionIndex"`
 	Errors              []map[string]interface{}  `json:"errors"`
 	TookNanos           time.Duration             `json:"tookNanos"`
+	FirstByteNanos      time.Duration             `json:"firstByteNanos"`
+	WS                  *WSResult                 `json:"ws,omitempty"`
+	SSE                 *SSEResult                `json:"sse,omitempty"`
+	ReplayDiff          *ReplayDiff               `json:"replayDiff,omitempty"`
 	httpResponse        *http.Response
 	grpcResponse        interface{}
 	grpcStatus          int
@@ -85,6 +89,7 @@ type InvocationStatus struct {
 	TotalRequests     int    `json:"totalRequests"`
 	AssignedRequests  int    `json:"assignedRequests"`
 	CompletedRequests int    `json:"completedRequests"`
+	SkippedRequests   int    `json:"skippedRequests"`
 	SuccessCount      int    `json:"successCount"`
 	FailureCount      int    `json:"failureCount"`
 	RetriesCount      int    `json:"retriesCount"`
@@ -129,6 +134,7 @@ func (t *InvocationTracker) SendResult(r *InvocationResult) {
 }
 
 func (tracker *InvocationTracker) publishResult(result *InvocationResult) {
+	tracker.recordRunStats(result)
 	if tracker.Channels != nil {
 		tracker.Channels.publish(result)
 	}
@@ -170,7 +176,9 @@ func (result *InvocationResult) processHTTPResponse(req *InvocationRequest, r *h
 	result.err = err
 	result.Response.PeerCertInfo = req.client.GetPeerCertInfo()
 	if err == nil {
-		result.tracker.OnHeaders(r.Header, r.StatusCode, result.Response.PeerCertInfo)
+		if result.tracker.OnHeaders != nil {
+			result.tracker.OnHeaders(r.Header, r.StatusCode, result.Response.PeerCertInfo)
+		}
 		result.readHTTPResponsePayload()
 		if r != nil {
 			result.updateResult(req.url, req.uri, r.Status, r.StatusCode, r.Header)
@@ -302,7 +310,22 @@ func (result *InvocationResult) validateResponse() {
 			allErrors = append(allErrors, errors)
 		}
 	}
-	result.Errors = allErrors
+	result.Errors = append(result.Errors, allErrors...)
+}
+
+// failureReason reports why a response counts as failed: a request error, failed assertions,
+// or (for targets without assertions) a missing or 4xx/5xx status code.
+func (result *InvocationResult) failureReason() string {
+	if result.err != nil {
+		return result.err.Error()
+	}
+	if len(result.Errors) > 0 {
+		return "assertions failed"
+	}
+	if len(result.tracker.Target.Assertions) == 0 && (result.Response.StatusCode == 0 || result.Response.StatusCode >= 400) {
+		return fmt.Sprintf("response status [%s]", result.Response.Status)
+	}
+	return ""
 }
 
 func (ir *InvocationResult) trackRequest(start, end time.Time) {
@@ -330,6 +353,18 @@ func (is *InvocationStatus) incrementRetriesCount() {
 	is.RetriesCount++
 }
 
+func (is *InvocationStatus) incrementCompletedCount() {
+	is.lock.Lock()
+	defer is.lock.Unlock()
+	is.CompletedRequests++
+}
+
+func (is *InvocationStatus) incrementSkippedCount() {
+	is.lock.Lock()
+	defer is.lock.Unlock()
+	is.SkippedRequests++
+}
+
 func (is *InvocationStatus) incrementABCount() {
 	is.lock.Lock()
 	defer is.lock.Unlock()
@@ -0,0 +1,335 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"encoding/json"
+	"fmt"
+	"goto/pkg/events"
+	"goto/pkg/global"
+	"goto/pkg/util"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ScenarioStep struct {
+	InvocationSpec
+	Capture map[string]*StepCapture `json:"capture"`
+}
+
+type StepCapture struct {
+	Header   string `json:"header"`
+	JSONPath string `json:"jsonPath"`
+	Regex    string `json:"regex"`
+	jsonPath *util.JSONPath
+	regex    *regexp.Regexp
+}
+
+type scenarioRun struct {
+	stopRequested bool
+	lock          sync.RWMutex
+}
+
+type StepSinkFactory func(step *ScenarioStep) ResultSinkFactory
+
+var (
+	activeScenarios = map[string]*scenarioRun{}
+)
+
+func (is *InvocationSpec) IsScenario() bool {
+	return len(is.Steps) > 0
+}
+
+func (is *InvocationSpec) validateScenario() error {
+	var err error
+	if is.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if is.Rate != "" {
+		return fmt.Errorf("rate is not supported for scenarios")
+	}
+	if is.Replicas < 0 {
+		return fmt.Errorf("invalid replicas")
+	} else if is.Replicas == 0 {
+		is.Replicas = 1
+	}
+	if is.RequestCount < 0 {
+		return fmt.Errorf("invalid requestCount")
+	} else if is.RequestCount == 0 {
+		is.RequestCount = 1
+	}
+	if is.InitialDelay != "" {
+		if is.initialDelayD, err = time.ParseDuration(is.InitialDelay); err != nil {
+			return fmt.Errorf("invalid initial delay")
+		}
+	}
+	if is.Delay != "" {
+		if is.delayD, err = time.ParseDuration(is.Delay); err != nil {
+			return fmt.Errorf("invalid delay")
+		}
+	}
+	if is.Headers == nil {
+		is.Headers = map[string]string{}
+	}
+	stepNames := map[string]bool{}
+	for i, step := range is.Steps {
+		if step == nil {
+			return fmt.Errorf("step [%d] is empty", i+1)
+		}
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step%d", i+1)
+		}
+		if stepNames[step.Name] {
+			return fmt.Errorf("duplicate step name [%s]", step.Name)
+		}
+		stepNames[step.Name] = true
+		if step.IsScenario() {
+			return fmt.Errorf("step [%s] cannot have nested steps", step.Name)
+		}
+		if step.Rate != "" {
+			return fmt.Errorf("rate is not supported for scenario step [%s]", step.Name)
+		}
+		step.Replicas = 1
+		step.RequestCount = 1
+		if step.Delay == "" {
+			step.Delay = "0s"
+		}
+		if step.Headers == nil {
+			step.Headers = map[string]string{}
+		}
+		if err = step.prepareCaptures(); err != nil {
+			return fmt.Errorf("step [%s]: %s", step.Name, err.Error())
+		}
+		if err = ValidateSpec(&step.InvocationSpec); err != nil {
+			return fmt.Errorf("step [%s]: %s", step.Name, err.Error())
+		}
+	}
+	is.lock = &sync.RWMutex{}
+	return nil
+}
+
+func (step *ScenarioStep) prepareCaptures() error {
+	for name, c := range step.Capture {
+		if c == nil || (c.Header == "" && c.JSONPath == "" && c.Regex == "") {
+			return fmt.Errorf("capture [%s] needs a header, jsonPath or regex", name)
+		}
+		if c.Header != "" && c.JSONPath != "" {
+			return fmt.Errorf("capture [%s] cannot use both header and jsonPath", name)
+		}
+		if c.JSONPath != "" {
+			path := strings.TrimPrefix(strings.TrimSpace(c.JSONPath), "$")
+			if !strings.HasPrefix(path, "{") {
+				path = "{" + path + "}"
+			}
+			c.jsonPath = util.NewJSONPath()
+			c.jsonPath.Parse2(name + "=" + path)
+		}
+		if c.Regex != "" {
+			var err error
+			if c.regex, err = regexp.Compile(c.Regex); err != nil {
+				return fmt.Errorf("invalid regex for capture [%s]: %s", name, err.Error())
+			}
+		}
+		if c.Header == "" {
+			step.CollectResponse = true
+		}
+	}
+	return nil
+}
+
+// StartScenario runs the scenario's steps in order for each iteration. Each replica runs `requestCount`
+// iterations, and every iteration starts with the scenario vars, adding values captured from each step's
+// response so that later steps can reference them as `${name}` placeholders in their URL, headers and body.
+func StartScenario(clientPort int, scenario *InvocationSpec, sinks StepSinkFactory) {
+	run := &scenarioRun{}
+	invocationsLock.Lock()
+	activeScenarios[scenario.Name] = run
+	invocationsLock.Unlock()
+	defer func() {
+		invocationsLock.Lock()
+		if activeScenarios[scenario.Name] == run {
+			delete(activeScenarios, scenario.Name)
+		}
+		invocationsLock.Unlock()
+	}()
+	time.Sleep(scenario.initialDelayD)
+	events.SendEventJSON(events.Client_ScenarioStarted, scenario.Name, scenario)
+	wg := &sync.WaitGroup{}
+	for r := 1; r <= scenario.Replicas; r++ {
+		wg.Add(1)
+		go func(replica int) {
+			defer wg.Done()
+			for i := 1; i <= scenario.RequestCount && !run.isStopRequested(); i++ {
+				if i > 1 {
+					time.Sleep(scenario.delayD)
+				}
+				runScenarioIteration(clientPort, scenario, run, replica, i, sinks)
+			}
+		}(r)
+	}
+	wg.Wait()
+	events.SendEventJSON(events.Client_ScenarioFinished, scenario.Name, scenario)
+}
+
+func runScenarioIteration(clientPort int, scenario *InvocationSpec, run *scenarioRun, replica, iteration int, sinks StepSinkFactory) {
+	vars := map[string]string{}
+	for k, v := range scenario.Vars {
+		vars[k] = v
+	}
+	vars["replica"] = strconv.Itoa(replica)
+	vars["iteration"] = strconv.Itoa(iteration)
+	for _, step := range scenario.Steps {
+		if run.isStopRequested() {
+			return
+		}
+		spec := step.prepare(scenario, vars)
+		tracker, err := RegisterInvocation(clientPort, spec, sinks(step))
+		if err != nil {
+			log.Printf("Scenario [%s]: failed to register step [%s] with error: %s\n", scenario.Name, step.Name, err.Error())
+			return
+		}
+		tracker.CustomID = fmt.Sprintf("%d-%d", replica, iteration)
+		results := StartInvocation(tracker, true)
+		var result *InvocationResult
+		if len(results) > 0 {
+			result = results[0]
+		}
+		missing := step.captureValues(result, vars)
+		if reason := stepFailureReason(result, missing); reason != "" {
+			events.SendEventJSON(events.Client_ScenarioStepFailed, fmt.Sprintf("%s/%s", scenario.Name, step.Name),
+				map[string]any{"scenario": scenario.Name, "step": step.Name, "replica": replica, "iteration": iteration, "reason": reason})
+			if global.Flags.EnableClientLogs {
+				log.Printf("Scenario [%s]: step [%s] failed in replica [%d] iteration [%d]: %s\n", scenario.Name, step.Name, replica, iteration, reason)
+			}
+			if !scenario.ContinueOnFailure {
+				return
+			}
+		}
+	}
+}
+
+func (step *ScenarioStep) prepare(scenario *InvocationSpec, vars map[string]string) *InvocationSpec {
+	spec := step.InvocationSpec.Clone()
+	spec.Name = fmt.Sprintf("%s/%s", scenario.Name, step.Name)
+	spec.URL = util.FillPlaceholders(step.URL, vars)
+	spec.Body = util.FillPlaceholders(step.Body, vars)
+	spec.Headers = map[string]string{}
+	for h, v := range scenario.Headers {
+		spec.Headers[h] = util.FillPlaceholders(v, vars)
+	}
+	for h, v := range step.Headers {
+		spec.Headers[h] = util.FillPlaceholders(v, vars)
+	}
+	spec.processAuthority()
+	return spec
+}
+
+func (step *ScenarioStep) captureValues(result *InvocationResult, vars map[string]string) (missing []string) {
+	for name, c := range step.Capture {
+		value, found := c.capture(result)
+		if found {
+			vars[name] = value
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	return
+}
+
+func (c *StepCapture) capture(result *InvocationResult) (string, bool) {
+	if result == nil || result.Response == nil {
+		return "", false
+	}
+	source := ""
+	if c.Header != "" {
+		found := false
+		for h, values := range result.Response.Headers {
+			if strings.EqualFold(h, c.Header) && len(values) > 0 {
+				source = values[0]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", false
+		}
+	} else if c.jsonPath != nil {
+		var data any
+		if err := json.Unmarshal(result.Response.Payload, &data); err != nil {
+			return "", false
+		}
+		captures, allMatched := c.jsonPath.FindResults(data)
+		if !allMatched || len(captures) == 0 {
+			return "", false
+		}
+		for _, v := range captures {
+			source = v
+		}
+	} else {
+		source = string(result.Response.Payload)
+	}
+	if c.regex != nil {
+		matches := c.regex.FindStringSubmatch(source)
+		if len(matches) == 0 {
+			return "", false
+		} else if len(matches) > 1 {
+			return matches[1], true
+		}
+		return matches[0], true
+	}
+	return source, true
+}
+
+func stepFailureReason(result *InvocationResult, missingCaptures []string) string {
+	if result == nil {
+		return "no response"
+	}
+	if reason := result.failureReason(); reason != "" {
+		return reason
+	}
+	if len(missingCaptures) > 0 {
+		return fmt.Sprintf("failed to capture %s", strings.Join(missingCaptures, ","))
+	}
+	return ""
+}
+
+func (r *scenarioRun) isStopRequested() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.stopRequested
+}
+
+func stopScenario(name string) bool {
+	invocationsLock.RLock()
+	run := activeScenarios[name]
+	invocationsLock.RUnlock()
+	if run == nil {
+		return false
+	}
+	run.lock.Lock()
+	run.stopRequested = true
+	run.lock.Unlock()
+	return true
+}
+
+func isScenarioActive(name string) bool {
+	return activeScenarios[name] != nil
+}
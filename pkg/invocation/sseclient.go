@@ -0,0 +1,356 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"goto/pkg/types"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SSESpec struct {
+	MaxEvents       int          `json:"maxEvents"`
+	Duration        string       `json:"duration"`
+	IdleTimeout     string       `json:"idleTimeout"`
+	Reconnects      int          `json:"reconnects"`
+	ReconnectDelay  string       `json:"reconnectDelay"`
+	Assertions      []*SSEAssert `json:"assertions"`
+	durationD       time.Duration
+	idleTimeoutD    time.Duration
+	reconnectDelayD time.Duration
+}
+
+type SSEAssert struct {
+	Event      string `json:"event"`
+	Data       string `json:"data"`
+	ID         string `json:"id"`
+	dataRegexp *regexp.Regexp
+	idRegexp   *regexp.Regexp
+}
+
+type SSEEvent struct {
+	ID    string    `json:"id"`
+	Event string    `json:"event"`
+	Data  string    `json:"data"`
+	At    time.Time `json:"at"`
+}
+
+type SSEResult struct {
+	Events            int              `json:"events"`
+	EventsByType      map[string]int   `json:"eventsByType"`
+	Reconnects        int              `json:"reconnects"`
+	LastEventID       string           `json:"lastEventID"`
+	FailedAssertions  int              `json:"failedAssertions"`
+	StopReason        string           `json:"stopReason"`
+	InterEventLatency *types.Histogram `json:"interEventLatency"`
+	EventLog          []*SSEEvent      `json:"eventLog,omitempty"`
+}
+
+type sseStream struct {
+	result       *InvocationResult
+	spec         *SSESpec
+	retry        time.Duration
+	lastEventAt  time.Time
+	stopReason   string
+	payload      bytes.Buffer
+	cancel       context.CancelFunc
+	idleTimer    *time.Timer
+	collectEvent bool
+	lock         sync.Mutex
+}
+
+const (
+	SSEStopMaxEvents = "maxEvents"
+	SSEStopDuration  = "duration"
+	SSEStopIdle      = "idleTimeout"
+	SSEStopClosed    = "closed"
+	SSEStopError     = "error"
+
+	defaultSSEReconnectDelay = time.Second
+	maxSSEErrorDetails       = 10
+)
+
+func (sse *SSESpec) validate() error {
+	var err error
+	if sse.MaxEvents < 0 {
+		return fmt.Errorf("invalid sse maxEvents")
+	}
+	if sse.Reconnects < 0 {
+		return fmt.Errorf("invalid sse reconnects")
+	}
+	if sse.Duration != "" {
+		if sse.durationD, err = time.ParseDuration(sse.Duration); err != nil || sse.durationD <= 0 {
+			return fmt.Errorf("invalid sse duration")
+		}
+	}
+	if sse.IdleTimeout != "" {
+		if sse.idleTimeoutD, err = time.ParseDuration(sse.IdleTimeout); err != nil || sse.idleTimeoutD <= 0 {
+			return fmt.Errorf("invalid sse idleTimeout")
+		}
+	}
+	if sse.ReconnectDelay != "" {
+		if sse.reconnectDelayD, err = time.ParseDuration(sse.ReconnectDelay); err != nil {
+			return fmt.Errorf("invalid sse reconnectDelay")
+		}
+	} else {
+		sse.reconnectDelayD = defaultSSEReconnectDelay
+	}
+	for i, a := range sse.Assertions {
+		if a == nil {
+			return fmt.Errorf("sse assertion [%d] is empty", i+1)
+		}
+		if a.Data != "" {
+			if a.dataRegexp, err = regexp.Compile(a.Data); err != nil {
+				return fmt.Errorf("invalid data regex for sse assertion [%d]: %s", i+1, err.Error())
+			}
+		}
+		if a.ID != "" {
+			if a.idRegexp, err = regexp.Compile(a.ID); err != nil {
+				return fmt.Errorf("invalid id regex for sse assertion [%d]: %s", i+1, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// invokeSSE opens the event stream and reads events until the stream reaches `maxEvents`, `duration` or `idleTimeout`.
+// If the server closes the stream earlier, it reconnects up to `reconnects` times, sending the last received event ID
+// as `Last-Event-ID` and waiting for the server's `retry` interval (or `reconnectDelay`) before reconnecting.
+func (ir *InvocationRequest) invokeSSE() {
+	if ir.client == nil || ir.client.HTTP() == nil || ir.httpRequest == nil {
+		return
+	}
+	target := ir.tracker.Target
+	sni := ir.httpRequest.Host
+	if target.NoSNI {
+		sni = ""
+	}
+	ir.client.UpdateTLSConfig(sni, target.TLSVersion, target.VerifyTLS, target.ALPN)
+	stream := &sseStream{
+		result:       ir.result,
+		spec:         target.SSE,
+		retry:        target.SSE.reconnectDelayD,
+		collectEvent: target.CollectResponse,
+	}
+	ir.result.SSE = &SSEResult{EventsByType: map[string]int{}, InterEventLatency: types.NewHistogram()}
+	ctx, cancel := context.WithCancel(ir.httpRequest.Context())
+	defer cancel()
+	stream.cancel = cancel
+	if target.SSE.durationD > 0 {
+		durationTimer := time.AfterFunc(target.SSE.durationD, func() { stream.stop(SSEStopDuration) })
+		defer durationTimer.Stop()
+	}
+	start := time.Now()
+	var resp *http.Response
+	var err error
+	for attempt := 0; attempt <= target.SSE.Reconnects; attempt++ {
+		if attempt > 0 {
+			ir.result.SSE.Reconnects++
+			select {
+			case <-ctx.Done():
+			case <-time.After(stream.retry):
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		req := ir.httpRequest.Clone(ctx)
+		if len(ir.payloads) == 1 && len(ir.payloads[0]) > 0 {
+			req.Body = io.NopCloser(bytes.NewReader(ir.payloads[0]))
+		}
+		if ir.result.SSE.LastEventID != "" {
+			req.Header.Set("Last-Event-ID", ir.result.SSE.LastEventID)
+		}
+		var r *http.Response
+		r, err = ir.client.HTTP().Do(req)
+		if err != nil {
+			if stream.stopped() != "" {
+				err = nil
+			}
+			break
+		}
+		if resp == nil {
+			resp = r
+			if !ir.firstByteAt.IsZero() {
+				ir.result.FirstByteNanos = ir.firstByteAt.Sub(start)
+			}
+		}
+		if r.StatusCode != http.StatusOK {
+			if r != resp {
+				r.Body.Close()
+			}
+			break
+		}
+		stream.read(r.Body)
+		r.Body.Close()
+		if stream.stopped() != "" {
+			break
+		}
+	}
+	end := time.Now()
+	ir.result.trackRequest(start, end)
+	ir.tracker.Status.trackRequest(end)
+	if resp != nil && resp.StatusCode != http.StatusOK {
+		ir.result.SSE = nil
+		ir.result.processHTTPResponse(ir, resp, nil)
+		return
+	}
+	if err != nil {
+		stream.stop(SSEStopError)
+	} else {
+		stream.stop(SSEStopClosed)
+	}
+	ir.result.SSE.StopReason = stream.stopped()
+	if resp == nil {
+		if err == nil {
+			err = fmt.Errorf("event stream stopped by [%s] before connecting", stream.stopped())
+		}
+		ir.result.SSE = nil
+		ir.result.processHTTPResponse(ir, nil, err)
+		return
+	}
+	ir.result.httpResponse = resp
+	ir.result.Response.PeerCertInfo = ir.client.GetPeerCertInfo()
+	ir.result.Response.PayloadSize = stream.payload.Len()
+	if target.CollectResponse {
+		ir.result.Response.Payload = stream.payload.Bytes()
+	}
+	ir.result.updateResult(ir.url, ir.uri, resp.Status, resp.StatusCode, resp.Header)
+	ir.tracker.logSSEFinished(ir.result)
+}
+
+func (s *sseStream) stop(reason string) {
+	s.lock.Lock()
+	if s.stopReason == "" {
+		s.stopReason = reason
+	}
+	s.lock.Unlock()
+	s.cancel()
+}
+
+func (s *sseStream) stopped() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stopReason
+}
+
+func (s *sseStream) read(body io.Reader) {
+	if s.spec.idleTimeoutD > 0 {
+		s.idleTimer = time.AfterFunc(s.spec.idleTimeoutD, func() { s.stop(SSEStopIdle) })
+		defer s.idleTimer.Stop()
+	}
+	reader := bufio.NewReader(body)
+	event := &SSEEvent{}
+	data := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if len(data) > 0 {
+				event.Data = strings.Join(data, "\n")
+				if s.dispatch(event) {
+					return
+				}
+			}
+			event = &SSEEvent{}
+			data = data[:0]
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			event.ID = value
+			s.result.SSE.LastEventID = value
+		case "retry":
+			if ms, e := strconv.Atoi(value); e == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// dispatch records a received event and returns true once the stream has received `maxEvents` events.
+func (s *sseStream) dispatch(event *SSEEvent) bool {
+	event.At = time.Now()
+	if event.Event == "" {
+		event.Event = "message"
+	}
+	if s.idleTimer != nil {
+		s.idleTimer.Reset(s.spec.idleTimeoutD)
+	}
+	sse := s.result.SSE
+	sse.Events++
+	sse.EventsByType[event.Event]++
+	if !s.lastEventAt.IsZero() {
+		sse.InterEventLatency.Record(event.At.Sub(s.lastEventAt))
+	}
+	s.lastEventAt = event.At
+	s.payload.WriteString(event.Data)
+	s.payload.WriteByte('\n')
+	if s.collectEvent {
+		sse.EventLog = append(sse.EventLog, event)
+	}
+	for i, a := range s.spec.Assertions {
+		if a.Event != "" && a.Event != event.Event {
+			continue
+		}
+		errors := map[string]interface{}{}
+		if a.dataRegexp != nil && !a.dataRegexp.MatchString(event.Data) {
+			errors["data"] = map[string]interface{}{"expected": a.Data, "actual": event.Data}
+		}
+		if a.idRegexp != nil && !a.idRegexp.MatchString(event.ID) {
+			errors["id"] = map[string]interface{}{"expected": a.ID, "actual": event.ID}
+		}
+		if len(errors) > 0 {
+			sse.FailedAssertions++
+			if sse.FailedAssertions <= maxSSEErrorDetails {
+				errors["sseAssertionIndex"] = i + 1
+				errors["eventIndex"] = sse.Events
+				errors["event"] = event.Event
+				s.result.Errors = append(s.result.Errors, errors)
+			}
+		}
+	}
+	if s.spec.MaxEvents > 0 && sse.Events >= s.spec.MaxEvents {
+		s.stop(SSEStopMaxEvents)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"fmt"
+	"goto/pkg/types"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Thresholds struct {
+	MaxErrorRate  string            `json:"maxErrorRate"`
+	MaxLatency    map[string]string `json:"maxLatency"`
+	MinThroughput string            `json:"minThroughput"`
+	AbortOnBreach bool              `json:"abortOnBreach"`
+	MinRequests   int               `json:"minRequests"`
+	RunToken      string            `json:"runToken"`
+	maxErrorRate  float64
+	maxLatency    map[string]time.Duration
+	minThroughput float64
+}
+
+type Violation struct {
+	Metric    string `json:"metric"`
+	Threshold string `json:"threshold"`
+	Actual    string `json:"actual"`
+}
+
+type Verdict struct {
+	Pass       bool                    `json:"pass"`
+	Aborted    bool                    `json:"aborted"`
+	Requests   int                     `json:"requests"`
+	Failures   int                     `json:"failures"`
+	ErrorRate  string                  `json:"errorRate"`
+	Throughput string                  `json:"throughput"`
+	Latency    *types.HistogramSummary `json:"latency"`
+	Violations []*Violation            `json:"violations"`
+	RunToken   string                  `json:"runToken,omitempty"`
+	At         time.Time               `json:"at"`
+}
+
+type runStats struct {
+	requests int
+	failures int
+	latency  *types.Histogram
+	start    time.Time
+	end      time.Time
+	aborted  []*Violation
+	lock     sync.Mutex
+}
+
+const (
+	defaultThresholdMinRequests = 100
+	thresholdCheckInterval      = 10
+)
+
+var (
+	latencyMetrics = map[string]float64{"p50": 50, "p90": 90, "p95": 95, "p99": 99, "p99.9": 99.9}
+)
+
+func (th *Thresholds) validate() error {
+	if th.MaxErrorRate != "" {
+		text := strings.TrimSpace(th.MaxErrorRate)
+		percent := strings.HasSuffix(text, "%")
+		rate, err := strconv.ParseFloat(strings.TrimSuffix(text, "%"), 64)
+		if err != nil || rate < 0 {
+			return fmt.Errorf("invalid maxErrorRate [%s]", th.MaxErrorRate)
+		}
+		if percent {
+			rate /= 100
+		}
+		th.maxErrorRate = rate
+	} else {
+		th.maxErrorRate = -1
+	}
+	th.maxLatency = map[string]time.Duration{}
+	for metric, value := range th.MaxLatency {
+		metric = strings.ToLower(metric)
+		if _, ok := latencyMetrics[metric]; !ok && metric != "mean" && metric != "max" {
+			return fmt.Errorf("invalid latency metric [%s]", metric)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid latency threshold [%s] for [%s]", value, metric)
+		}
+		th.maxLatency[metric] = d
+	}
+	if th.MinThroughput != "" {
+		var err error
+		if th.minThroughput, err = ParseRate(th.MinThroughput); err != nil {
+			return fmt.Errorf("invalid minThroughput: %s", err.Error())
+		}
+	}
+	if th.MinRequests <= 0 {
+		th.MinRequests = defaultThresholdMinRequests
+	}
+	return nil
+}
+
+func newRunStats(target *InvocationSpec) *runStats {
+	if target.Thresholds == nil {
+		return nil
+	}
+	return &runStats{latency: types.NewHistogram()}
+}
+
+func (tracker *InvocationTracker) recordRunStats(result *InvocationResult) {
+	stats := tracker.stats
+	if stats == nil || result == nil {
+		return
+	}
+	stats.lock.Lock()
+	stats.requests++
+	if result.failureReason() != "" {
+		stats.failures++
+	}
+	stats.latency.Record(result.TookNanos)
+	if stats.start.IsZero() {
+		stats.start = result.Request.LastRequestAt.Add(-result.TookNanos)
+	}
+	stats.end = result.Request.LastRequestAt
+	th := tracker.Target.Thresholds
+	checkAbort := th.AbortOnBreach && stats.aborted == nil && stats.requests >= th.MinRequests && stats.requests%thresholdCheckInterval == 0
+	var violations []*Violation
+	if checkAbort {
+		if violations = th.check(stats, false); len(violations) > 0 {
+			stats.aborted = violations
+		}
+	}
+	stats.lock.Unlock()
+	if len(violations) > 0 {
+		tracker.logThresholdsBreached(violations)
+		tracker.Status.StopRequested = true
+	}
+}
+
+// check evaluates the thresholds against the stats collected so far. Throughput is only checked
+// for a finished run, since it can't be judged fairly while the run is still in progress.
+func (th *Thresholds) check(stats *runStats, finished bool) (violations []*Violation) {
+	if stats.requests == 0 {
+		return
+	}
+	if th.maxErrorRate >= 0 {
+		if rate := float64(stats.failures) / float64(stats.requests); rate > th.maxErrorRate {
+			violations = append(violations, &Violation{Metric: "errorRate", Threshold: formatPercent(th.maxErrorRate), Actual: formatPercent(rate)})
+		}
+	}
+	for metric, limit := range th.maxLatency {
+		var actual time.Duration
+		switch metric {
+		case "mean":
+			actual = stats.latency.Mean()
+		case "max":
+			actual = stats.latency.Max()
+		default:
+			actual = stats.latency.Percentile(latencyMetrics[metric])
+		}
+		if actual > limit {
+			violations = append(violations, &Violation{Metric: "latency." + metric, Threshold: limit.String(), Actual: actual.String()})
+		}
+	}
+	if finished && th.minThroughput > 0 {
+		if actual := stats.throughput(); actual < th.minThroughput {
+			violations = append(violations, &Violation{Metric: "throughput", Threshold: formatRate(th.minThroughput), Actual: formatRate(actual)})
+		}
+	}
+	return
+}
+
+func (stats *runStats) throughput() float64 {
+	if elapsed := stats.end.Sub(stats.start).Seconds(); elapsed > 0 {
+		return float64(stats.requests) / elapsed
+	}
+	return 0
+}
+
+func (tracker *InvocationTracker) evaluateThresholds() {
+	stats := tracker.stats
+	if stats == nil {
+		return
+	}
+	stats.lock.Lock()
+	verdict := &Verdict{
+		Requests: stats.requests,
+		Failures: stats.failures,
+		Latency:  stats.latency.Summary(),
+		RunToken: tracker.Target.Thresholds.RunToken,
+		At:       time.Now(),
+	}
+	if stats.requests > 0 {
+		verdict.ErrorRate = formatPercent(float64(stats.failures) / float64(stats.requests))
+		verdict.Throughput = formatRate(stats.throughput())
+	}
+	if stats.aborted != nil {
+		verdict.Aborted = true
+		verdict.Violations = stats.aborted
+	} else {
+		verdict.Violations = tracker.Target.Thresholds.check(stats, true)
+	}
+	stats.lock.Unlock()
+	verdict.Pass = len(verdict.Violations) == 0
+	tracker.Verdict = verdict
+	tracker.logVerdict()
+}
+
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', -1, 64) + "%"
+}
+
+func formatRate(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64) + "/s"
+}
+
+func formatViolations(violations []*Violation) string {
+	messages := []string{}
+	for _, v := range violations {
+		messages = append(messages, fmt.Sprintf("%s [%s] violates threshold [%s]", v.Metric, v.Actual, v.Threshold))
+	}
+	return strings.Join(messages, ", ")
+}
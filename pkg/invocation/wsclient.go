@@ -0,0 +1,323 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package invocation
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"goto/pkg/transport"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type WSSpec struct {
+	Messages     []*WSMessage `json:"messages"`
+	ReceiveCount int          `json:"receiveCount"`
+	IdleTimeout  string       `json:"idleTimeout"`
+	CloseCode    int          `json:"closeCode"`
+	idleTimeoutD time.Duration
+}
+
+type WSMessage struct {
+	Type   string `json:"type"`
+	Data   string `json:"data"`
+	Delay  string `json:"delay"`
+	delayD time.Duration
+	binary bool
+}
+
+type WSFrame struct {
+	Type string    `json:"type"`
+	Data string    `json:"data"`
+	Size int       `json:"size"`
+	At   time.Time `json:"at"`
+}
+
+type WSResult struct {
+	MessagesOut    int           `json:"messagesOut"`
+	MessagesIn     int           `json:"messagesIn"`
+	BytesOut       int           `json:"bytesOut"`
+	BytesIn        int           `json:"bytesIn"`
+	CloseCode      int           `json:"closeCode"`
+	CloseText      string        `json:"closeText"`
+	ClosedBy       string        `json:"closedBy"`
+	HandshakeNanos time.Duration `json:"handshakeNanos"`
+	DurationNanos  time.Duration `json:"durationNanos"`
+	Frames         []*WSFrame    `json:"frames,omitempty"`
+	lock           sync.Mutex
+}
+
+const (
+	WSText   = "text"
+	WSBinary = "binary"
+
+	defaultWSIdleTimeout = time.Second
+)
+
+func (ws *WSSpec) validate() error {
+	var err error
+	if ws.IdleTimeout != "" {
+		if ws.idleTimeoutD, err = time.ParseDuration(ws.IdleTimeout); err != nil || ws.idleTimeoutD <= 0 {
+			return fmt.Errorf("invalid ws idleTimeout")
+		}
+	} else {
+		ws.idleTimeoutD = defaultWSIdleTimeout
+		ws.IdleTimeout = defaultWSIdleTimeout.String()
+	}
+	if ws.ReceiveCount < 0 {
+		return fmt.Errorf("invalid ws receiveCount")
+	}
+	if ws.CloseCode == 0 {
+		ws.CloseCode = websocket.CloseNormalClosure
+	} else if ws.CloseCode < 1000 || ws.CloseCode > 4999 {
+		return fmt.Errorf("invalid ws closeCode [%d]", ws.CloseCode)
+	}
+	for i, m := range ws.Messages {
+		if m == nil {
+			return fmt.Errorf("ws message [%d] is empty", i+1)
+		}
+		m.Type = strings.ToLower(m.Type)
+		switch m.Type {
+		case "", WSText:
+			m.Type = WSText
+		case WSBinary:
+			m.binary = true
+		default:
+			return fmt.Errorf("invalid ws message type [%s]", m.Type)
+		}
+		if m.Delay != "" {
+			if m.delayD, err = time.ParseDuration(m.Delay); err != nil {
+				return fmt.Errorf("invalid delay for ws message [%d]", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+func (ws *WSSpec) payloads() (payloads [][]byte) {
+	for _, m := range ws.Messages {
+		if m.binary {
+			if b, err := base64.StdEncoding.DecodeString(m.Data); err == nil {
+				payloads = append(payloads, b)
+				continue
+			}
+		}
+		payloads = append(payloads, []byte(m.Data))
+	}
+	return
+}
+
+// invokeWS upgrades the connection and sends the request payloads as frames, then keeps reading
+// frames until the expected count is received, the connection stays idle for the idle timeout,
+// or the server closes the connection. The connection is closed with the configured close code.
+func (ir *InvocationRequest) invokeWS() {
+	if ir.httpRequest == nil || ir.tracker == nil || ir.tracker.Target == nil {
+		return
+	}
+	target := ir.tracker.Target
+	sni := ir.httpRequest.Host
+	if target.NoSNI {
+		sni = ""
+	}
+	ir.client.UpdateTLSConfig(sni, target.TLSVersion, target.VerifyTLS, target.ALPN)
+	dialer := &websocket.Dialer{HandshakeTimeout: target.connTimeoutD}
+	if tr := ir.client.Transport(); tr != nil {
+		if tlsConfig := tr.GetTLSConfig(); tlsConfig != nil {
+			dialer.TLSClientConfig = tlsConfig.Clone()
+			dialer.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		}
+		if ht, ok := tr.(*transport.HTTPTransportIntercept); ok {
+			dialer.NetDialContext = ht.DialContext
+		}
+	}
+	header := http.Header{}
+	for h, values := range ir.httpRequest.Header {
+		header[h] = values
+	}
+	if ir.httpRequest.Host != "" && ir.httpRequest.Host != ir.httpRequest.URL.Host {
+		header.Set("Host", ir.httpRequest.Host)
+	}
+	start := time.Now()
+	conn, resp, err := dialer.Dial(ir.url, header)
+	if err != nil {
+		end := time.Now()
+		ir.result.trackRequest(start, end)
+		ir.tracker.Status.trackRequest(end)
+		ir.result.processHTTPResponse(ir, resp, err)
+		return
+	}
+	ir.result.WS = &WSResult{HandshakeNanos: time.Since(start)}
+	ir.result.processWSResponse(ir, resp, conn, start)
+}
+
+func (result *InvocationResult) processWSResponse(ir *InvocationRequest, resp *http.Response, conn *websocket.Conn, start time.Time) {
+	target := ir.tracker.Target
+	wsResult := result.WS
+	received := make(chan bool, 10)
+	done := make(chan bool)
+	firstFrameAt := time.Time{}
+	var readErr error
+	go func() {
+		defer close(done)
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				readErr = err
+				return
+			}
+			at := time.Now()
+			wsResult.lock.Lock()
+			if firstFrameAt.IsZero() {
+				firstFrameAt = at
+			}
+			wsResult.MessagesIn++
+			wsResult.BytesIn += len(data)
+			if target.CollectResponse {
+				frame := &WSFrame{Type: WSText, Size: len(data), At: at}
+				if messageType == websocket.BinaryMessage {
+					frame.Type = WSBinary
+					frame.Data = base64.StdEncoding.EncodeToString(data)
+				} else {
+					frame.Data = string(data)
+				}
+				wsResult.Frames = append(wsResult.Frames, frame)
+				result.Response.Payload = append(result.Response.Payload, data...)
+			}
+			wsResult.lock.Unlock()
+			select {
+			case received <- true:
+			default:
+			}
+		}
+	}()
+	serverClosed := false
+	var writeErr error
+	for i, payload := range ir.payloads {
+		if payload == nil {
+			continue
+		}
+		messageType := websocket.TextMessage
+		delay := time.Duration(0)
+		if i < len(target.WS.Messages) {
+			delay = target.WS.Messages[i].delayD
+			if target.WS.Messages[i].binary {
+				messageType = websocket.BinaryMessage
+			}
+		} else if i > 0 {
+			delay = target.streamDelayD
+		}
+		if target.Binary {
+			messageType = websocket.BinaryMessage
+		}
+		if delay > 0 {
+			select {
+			case <-done:
+				serverClosed = true
+			case <-time.After(delay):
+			}
+		}
+		if serverClosed {
+			break
+		}
+		if writeErr = conn.WriteMessage(messageType, payload); writeErr != nil {
+			break
+		}
+		wsResult.lock.Lock()
+		wsResult.MessagesOut++
+		wsResult.BytesOut += len(payload)
+		wsResult.lock.Unlock()
+	}
+	if !serverClosed && writeErr == nil {
+		serverClosed = result.awaitWSFrames(target, received, done, start)
+	}
+	closeCode := target.WS.CloseCode
+	if !serverClosed {
+		wsResult.ClosedBy = "client"
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, ""), time.Now().Add(target.WS.idleTimeoutD))
+		select {
+		case <-done:
+		case <-time.After(target.WS.idleTimeoutD):
+		}
+	} else {
+		wsResult.ClosedBy = "server"
+	}
+	conn.Close()
+	<-done
+	end := time.Now()
+	result.trackRequest(start, end)
+	ir.tracker.Status.trackRequest(end)
+	wsResult.DurationNanos = end.Sub(start)
+	if !firstFrameAt.IsZero() {
+		result.FirstByteNanos = firstFrameAt.Sub(start)
+	}
+	var closeErr *websocket.CloseError
+	if errors.As(readErr, &closeErr) && (wsResult.ClosedBy == "server" || closeErr.Code != websocket.CloseAbnormalClosure) {
+		wsResult.CloseCode = closeErr.Code
+		wsResult.CloseText = closeErr.Text
+	} else if wsResult.ClosedBy == "client" {
+		wsResult.CloseCode = closeCode
+	} else {
+		wsResult.CloseCode = websocket.CloseAbnormalClosure
+		if readErr != nil {
+			wsResult.CloseText = readErr.Error()
+		}
+	}
+	result.httpResponse = resp
+	result.Response.PeerCertInfo = ir.client.GetPeerCertInfo()
+	result.Response.PayloadSize = wsResult.BytesIn
+	if writeErr != nil {
+		result.err = writeErr
+	} else if wsResult.CloseCode == websocket.CloseAbnormalClosure {
+		result.err = fmt.Errorf("websocket closed abnormally: %s", wsResult.CloseText)
+	}
+	if result.err != nil {
+		result.Response.Status = result.err.Error()
+		result.Response.StatusCode = resp.StatusCode
+	} else {
+		result.updateResult(ir.url, ir.uri, resp.Status, resp.StatusCode, resp.Header)
+	}
+	ir.tracker.logWSClosed(result)
+}
+
+// awaitWSFrames waits for the expected number of frames, or until no frame arrives within the idle timeout.
+// It returns true if the server closed the connection while waiting.
+func (result *InvocationResult) awaitWSFrames(target *InvocationSpec, received, done chan bool, start time.Time) bool {
+	deadline := time.NewTimer(target.requestTimeoutD - time.Since(start))
+	defer deadline.Stop()
+	for {
+		result.WS.lock.Lock()
+		count := result.WS.MessagesIn
+		result.WS.lock.Unlock()
+		if target.WS.ReceiveCount > 0 && count >= target.WS.ReceiveCount {
+			return false
+		}
+		select {
+		case <-done:
+			return true
+		case <-received:
+		case <-time.After(target.WS.idleTimeoutD):
+			return false
+		case <-deadline.C:
+			return false
+		}
+	}
+}
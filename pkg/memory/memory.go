@@ -225,6 +225,12 @@ func (m *MemoryManager) GetOrAddContext(ctx string) *Memory {
 	return memory
 }
 
+func (m *MemoryManager) RemoveContext(ctx string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.ContextMemory, ctx)
+}
+
 func (m *MemoryManager) GetOrAddContextExtractor(ctxKey string) *MemoryExtractor {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -304,6 +310,31 @@ func (m *Memory) Get(key string) string {
 	return m.Items[key]
 }
 
+func (m *Memory) Lookup(key string) (string, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	value, found := m.Items[key]
+	return value, found
+}
+
+func (m *Memory) Remove(key string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	_, found := m.Items[key]
+	delete(m.Items, key)
+	return found
+}
+
+func (m *Memory) Snapshot() map[string]string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	items := make(map[string]string, len(m.Items))
+	for k, v := range m.Items {
+		items[k] = v
+	}
+	return items
+}
+
 func (m *Memory) Clear() {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -0,0 +1,311 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"goto/pkg/events"
+
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "halfOpen"
+
+	RejectOpen     = "open"
+	RejectOverflow = "overflow"
+	RejectTimeout  = "timeout"
+
+	defaultEjectionDuration = 30 * time.Second
+	defaultPendingTimeout   = 10 * time.Second
+)
+
+// CircuitBreaker is the circuit breaker config of a proxy upstream. The fast-fail status is an HTTP status for
+// HTTP upstreams and a gRPC status code for gRPC upstreams.
+type CircuitBreaker struct {
+	MaxConcurrent    int    `yaml:"maxConcurrent" json:"maxConcurrent"`
+	MaxPending       int    `yaml:"maxPending" json:"maxPending"`
+	PendingTimeout   string `yaml:"pendingTimeout" json:"pendingTimeout"`
+	Consecutive5xx   int    `yaml:"consecutive5xx" json:"consecutive5xx"`
+	EjectionDuration string `yaml:"ejectionDuration" json:"ejectionDuration"`
+	HalfOpenRequests int    `yaml:"halfOpenRequests" json:"halfOpenRequests"`
+	FailStatus       int    `yaml:"failStatus" json:"failStatus"`
+	ejectionDuration time.Duration
+	pendingTimeout   time.Duration
+}
+
+type BreakerCounts struct {
+	Allowed    int `json:"allowed"`
+	Rejected   int `json:"rejected"`
+	Overflowed int `json:"overflowed"`
+	TimedOut   int `json:"timedOut"`
+	Failures   int `json:"failures"`
+	Successes  int `json:"successes"`
+	Opened     int `json:"opened"`
+}
+
+// Breaker tracks the state of one upstream's circuit.
+type Breaker struct {
+	Port                int            `json:"port"`
+	Name                string         `json:"name"`
+	State               string         `json:"state"`
+	ConsecutiveFailures int            `json:"consecutiveFailures"`
+	Active              int            `json:"active"`
+	Pending             int            `json:"pending"`
+	OpenedAt            time.Time      `json:"openedAt"`
+	Counts              *BreakerCounts `json:"counts"`
+	config              *CircuitBreaker
+	probes              int
+	probeSuccesses      int
+	onTransition        func(from, to string)
+	lock                sync.Mutex
+	released            chan struct{}
+}
+
+// Permit is an admission through a breaker, which must be reported Done with the call's outcome.
+type Permit struct {
+	b     *Breaker
+	probe bool
+}
+
+type transition struct {
+	from, to, reason string
+}
+
+// Validate checks the config and applies its defaults. The failStatus is checked as a gRPC code for gRPC upstreams
+// and as an HTTP status otherwise.
+func (cb *CircuitBreaker) Validate(defaultStatus int, grpc bool) error {
+	if cb.MaxConcurrent < 0 || cb.MaxPending < 0 || cb.Consecutive5xx < 0 || cb.HalfOpenRequests < 0 {
+		return errors.New("circuit breaker limits can't be negative")
+	}
+	if cb.MaxPending > 0 && cb.MaxConcurrent == 0 {
+		return errors.New("circuit breaker maxPending needs maxConcurrent")
+	}
+	cb.ejectionDuration = defaultEjectionDuration
+	if cb.EjectionDuration != "" {
+		d, err := time.ParseDuration(cb.EjectionDuration)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid circuit breaker ejectionDuration [%s]", cb.EjectionDuration)
+		}
+		cb.ejectionDuration = d
+	}
+	cb.EjectionDuration = cb.ejectionDuration.String()
+	cb.pendingTimeout = defaultPendingTimeout
+	if cb.PendingTimeout != "" {
+		d, err := time.ParseDuration(cb.PendingTimeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid circuit breaker pendingTimeout [%s]", cb.PendingTimeout)
+		}
+		cb.pendingTimeout = d
+	}
+	cb.PendingTimeout = cb.pendingTimeout.String()
+	if cb.HalfOpenRequests == 0 {
+		cb.HalfOpenRequests = 1
+	}
+	if cb.FailStatus == 0 {
+		cb.FailStatus = defaultStatus
+	} else if grpc && (cb.FailStatus < 0 || cb.FailStatus > int(codes.Unauthenticated)) {
+		return fmt.Errorf("invalid circuit breaker failStatus [%d], must be a gRPC code", cb.FailStatus)
+	} else if !grpc && (cb.FailStatus < http.StatusContinue || cb.FailStatus > 599) {
+		return fmt.Errorf("invalid circuit breaker failStatus [%d], must be an HTTP status", cb.FailStatus)
+	}
+	return nil
+}
+
+// New creates a breaker for a validated config. The transition callback is invoked outside the breaker's lock.
+func New(port int, name string, cb *CircuitBreaker, onTransition func(from, to string)) *Breaker {
+	return &Breaker{Port: port, Name: name, State: StateClosed, Counts: &BreakerCounts{}, config: cb, onTransition: onTransition,
+		released: make(chan struct{})}
+}
+
+func (b *Breaker) FailStatus() int {
+	return b.config.FailStatus
+}
+
+func (b *Breaker) setState(to, reason string, transitions *[]transition) {
+	*transitions = append(*transitions, transition{from: b.State, to: to, reason: reason})
+	b.State = to
+	switch to {
+	case StateOpen:
+		b.OpenedAt = time.Now()
+		b.Counts.Opened++
+	case StateHalfOpen:
+		b.probes = 0
+		b.probeSuccesses = 0
+	case StateClosed:
+		b.ConsecutiveFailures = 0
+	}
+}
+
+func (b *Breaker) notify(transitions []transition) {
+	for _, t := range transitions {
+		title := events.Proxy_CircuitBreakerClosed
+		switch t.to {
+		case StateOpen:
+			title = events.Proxy_CircuitBreakerOpened
+		case StateHalfOpen:
+			title = events.Proxy_CircuitBreakerHalfOpen
+		}
+		events.SendEventJSONForPort(b.Port, title, fmt.Sprintf("%s: %s -> %s", b.Name, t.from, t.to),
+			map[string]any{"upstream": b.Name, "from": t.from, "to": t.to, "reason": t.reason})
+		if b.onTransition != nil {
+			b.onTransition(t.from, t.to)
+		}
+	}
+}
+
+// Acquire admits a call through the breaker, waiting for a slot if the upstream is at max concurrency and the pending
+// queue has room. The wait is bounded by the pending timeout and the context. It returns the reason instead of a permit
+// when the call should fail fast.
+func (b *Breaker) Acquire(ctx context.Context) (*Permit, string) {
+	transitions := []transition{}
+	defer func() { b.notify(transitions) }()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.State == StateOpen {
+		if time.Since(b.OpenedAt) < b.config.ejectionDuration {
+			b.Counts.Rejected++
+			return nil, RejectOpen
+		}
+		b.setState(StateHalfOpen, "ejection duration elapsed", &transitions)
+	}
+	probe := false
+	if b.State == StateHalfOpen {
+		if b.probes >= b.config.HalfOpenRequests {
+			b.Counts.Rejected++
+			return nil, RejectOpen
+		}
+		b.probes++
+		probe = true
+	}
+	if b.config.MaxConcurrent > 0 && b.Active >= b.config.MaxConcurrent {
+		if b.Pending >= b.config.MaxPending {
+			if probe {
+				b.probes--
+			}
+			b.Counts.Overflowed++
+			return nil, RejectOverflow
+		}
+		b.Pending++
+		admitted := b.waitForSlot(ctx)
+		b.Pending--
+		if !admitted {
+			if probe {
+				b.probes--
+			}
+			b.Counts.TimedOut++
+			return nil, RejectTimeout
+		}
+		if b.State == StateOpen {
+			if probe {
+				b.probes--
+			}
+			b.Counts.Rejected++
+			return nil, RejectOpen
+		}
+	}
+	b.Active++
+	b.Counts.Allowed++
+	return &Permit{b: b, probe: probe}, ""
+}
+
+// waitForSlot waits until the upstream is below max concurrency, giving up when the pending timeout elapses or the
+// context is done. It's called with the lock held, and releases the lock while waiting.
+func (b *Breaker) waitForSlot(ctx context.Context) bool {
+	timer := time.NewTimer(b.config.pendingTimeout)
+	defer timer.Stop()
+	for b.Active >= b.config.MaxConcurrent {
+		released := b.released
+		b.lock.Unlock()
+		select {
+		case <-released:
+		case <-timer.C:
+			b.lock.Lock()
+			return false
+		case <-ctx.Done():
+			b.lock.Lock()
+			return false
+		}
+		b.lock.Lock()
+	}
+	return true
+}
+
+// release frees a slot and wakes up the pending calls to compete for it.
+func (b *Breaker) release() {
+	b.Active--
+	if b.Pending > 0 {
+		close(b.released)
+		b.released = make(chan struct{})
+	}
+}
+
+// Done releases the permit's slot and records the call's outcome, where failed means a 5xx (or equivalent) result.
+func (p *Permit) Done(failed bool) {
+	b := p.b
+	transitions := []transition{}
+	defer func() { b.notify(transitions) }()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.release()
+	if failed {
+		b.Counts.Failures++
+		b.ConsecutiveFailures++
+		if p.probe && b.State == StateHalfOpen {
+			b.setState(StateOpen, "half-open probe failed", &transitions)
+		} else if b.State == StateClosed && b.config.Consecutive5xx > 0 && b.ConsecutiveFailures >= b.config.Consecutive5xx {
+			b.setState(StateOpen, fmt.Sprintf("%d consecutive failures", b.ConsecutiveFailures), &transitions)
+		}
+		return
+	}
+	b.Counts.Successes++
+	b.ConsecutiveFailures = 0
+	if p.probe && b.State == StateHalfOpen {
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.config.HalfOpenRequests {
+			b.setState(StateClosed, "half-open probes succeeded", &transitions)
+		}
+	}
+}
+
+// Release frees the permit's slot without recording an outcome, for calls that never reached the upstream.
+func (p *Permit) Release() {
+	b := p.b
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.release()
+	b.Counts.Allowed--
+	if p.probe && b.State == StateHalfOpen {
+		b.probes--
+	}
+}
+
+func (b *Breaker) Snapshot() *Breaker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	counts := *b.Counts
+	return &Breaker{Port: b.Port, Name: b.Name, State: b.State, ConsecutiveFailures: b.ConsecutiveFailures,
+		Active: b.Active, Pending: b.Pending, OpenedAt: b.OpenedAt, Counts: &counts}
+}
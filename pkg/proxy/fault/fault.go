@@ -0,0 +1,201 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	serverfault "goto/pkg/server/response/fault"
+
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	Abort     = "abort"
+	Delay     = "delay"
+	Corrupt   = "corrupt"
+	Truncate  = "truncate"
+	Drop      = "drop"
+	Duplicate = "duplicate"
+	Reorder   = "reorder"
+
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+	ProtocolUDP  = "udp"
+)
+
+type FaultPercent struct {
+	Percent float64 `yaml:"percent" json:"percent"`
+}
+
+// Fault is the fault injection config of a proxy target or upstream, sharing the abort/delay faults of the
+// server's fault rules. The abort status is an HTTP status for HTTP targets and a gRPC status code for gRPC upstreams.
+// Drop, duplicate and reorder only apply to UDP datagrams.
+type Fault struct {
+	Abort     *serverfault.FaultAbort `yaml:"abort,omitempty" json:"abort,omitempty"`
+	Delay     *serverfault.FaultDelay `yaml:"delay,omitempty" json:"delay,omitempty"`
+	Corrupt   *FaultPercent           `yaml:"corrupt,omitempty" json:"corrupt,omitempty"`
+	Truncate  *FaultPercent           `yaml:"truncate,omitempty" json:"truncate,omitempty"`
+	Drop      *FaultPercent           `yaml:"drop,omitempty" json:"drop,omitempty"`
+	Duplicate *FaultPercent           `yaml:"duplicate,omitempty" json:"duplicate,omitempty"`
+	Reorder   *FaultPercent           `yaml:"reorder,omitempty" json:"reorder,omitempty"`
+}
+
+// Injection is the set of faults rolled for one call.
+type Injection struct {
+	Abort     bool
+	Status    int
+	Delay     time.Duration
+	Corrupt   bool
+	Truncate  bool
+	Drop      bool
+	Duplicate bool
+	Reorder   bool
+}
+
+func (fp *FaultPercent) rolled() bool {
+	return fp != nil && serverfault.Rolled(fp.Percent)
+}
+
+// Validate checks the fault config for the given protocol, where an abort without a status gets the given default status.
+func (f *Fault) Validate(defaultStatus int, protocol string) error {
+	datagrams := protocol == ProtocolUDP
+	if f.Abort == nil && f.Delay == nil && f.Corrupt == nil && f.Truncate == nil && f.Drop == nil && f.Duplicate == nil && f.Reorder == nil {
+		return errors.New("fault needs at least one of abort, delay, corrupt, truncate, drop, duplicate or reorder")
+	}
+	if !datagrams && (f.Drop != nil || f.Duplicate != nil || f.Reorder != nil) {
+		return errors.New("drop, duplicate and reorder faults only apply to UDP")
+	}
+	if datagrams && f.Abort != nil {
+		return errors.New("abort faults don't apply to UDP")
+	}
+	if f.Abort != nil {
+		if f.Abort.Status == 0 {
+			f.Abort.Status = defaultStatus
+		}
+		if !serverfault.ValidPercent(f.Abort.Percent) {
+			return errors.New("invalid abort fault")
+		}
+		if protocol == ProtocolGRPC && (f.Abort.Status < int(codes.Canceled) || f.Abort.Status > int(codes.Unauthenticated)) {
+			return fmt.Errorf("invalid abort fault status [%d], must be a gRPC error code from 1 to 16", f.Abort.Status)
+		}
+		if protocol == ProtocolHTTP && (f.Abort.Status < http.StatusContinue || f.Abort.Status > 599) {
+			return fmt.Errorf("invalid abort fault status [%d], must be an HTTP status", f.Abort.Status)
+		}
+	}
+	if f.Delay != nil {
+		if err := f.Delay.Prepare(); err != nil {
+			return err
+		}
+	}
+	for name, fp := range map[string]*FaultPercent{Corrupt: f.Corrupt, Truncate: f.Truncate, Drop: f.Drop, Duplicate: f.Duplicate, Reorder: f.Reorder} {
+		if fp != nil && !serverfault.ValidPercent(fp.Percent) {
+			return fmt.Errorf("invalid %s fault", name)
+		}
+	}
+	return nil
+}
+
+// Roll rolls each fault independently for one call, giving nil when none of them hit.
+func (f *Fault) Roll() *Injection {
+	if f == nil {
+		return nil
+	}
+	i := &Injection{
+		Corrupt:   f.Corrupt.rolled(),
+		Truncate:  f.Truncate.rolled(),
+		Drop:      f.Drop.rolled(),
+		Duplicate: f.Duplicate.rolled(),
+		Reorder:   f.Reorder.rolled(),
+	}
+	if f.Abort != nil && serverfault.Rolled(f.Abort.Percent) {
+		i.Abort = true
+		i.Status = f.Abort.Status
+	}
+	i.Delay = f.Delay.Roll()
+	if len(i.Kinds()) == 0 {
+		return nil
+	}
+	return i
+}
+
+// Kinds gives the names of the injected faults, for tracking.
+func (i *Injection) Kinds() (kinds []string) {
+	if i == nil {
+		return nil
+	}
+	for _, k := range []struct {
+		name string
+		on   bool
+	}{{Abort, i.Abort}, {Delay, i.Delay > 0}, {Corrupt, i.Corrupt}, {Truncate, i.Truncate},
+		{Drop, i.Drop}, {Duplicate, i.Duplicate}, {Reorder, i.Reorder}} {
+		if k.on {
+			kinds = append(kinds, k.name)
+		}
+	}
+	return
+}
+
+func (i *Injection) String() string {
+	kinds := i.Kinds()
+	for n, k := range kinds {
+		switch k {
+		case Abort:
+			kinds[n] = fmt.Sprintf("%s=%d", Abort, i.Status)
+		case Delay:
+			kinds[n] = Delay + "=" + i.Delay.String()
+		}
+	}
+	return strings.Join(kinds, ",")
+}
+
+// ApplyDelay sleeps for the injected delay, if any.
+func (i *Injection) ApplyDelay() {
+	if i != nil && i.Delay > 0 {
+		time.Sleep(i.Delay)
+	}
+}
+
+// ApplyPayload gives a copy of the payload with the injected corruption and truncation, where corruption overwrites
+// a sixteenth of the bytes (at least one) at random with printable characters, and truncation cuts the payload in half.
+func (i *Injection) ApplyPayload(b []byte) []byte {
+	if i == nil || (!i.Corrupt && !i.Truncate) || len(b) == 0 {
+		return b
+	}
+	b = append([]byte{}, b...)
+	if i.Corrupt {
+		CorruptBytes(b)
+	}
+	if i.Truncate {
+		b = b[:len(b)/2]
+	}
+	return b
+}
+
+func CorruptBytes(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	for n := max(1, len(b)/16); n > 0; n-- {
+		b[rand.IntN(len(b))] = byte('!' + rand.IntN(94))
+	}
+}
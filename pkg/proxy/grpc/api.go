@@ -18,11 +18,15 @@ package grpcproxy
 
 import (
 	"fmt"
+	"goto/pkg/proxy/breaker"
+	"goto/pkg/proxy/fault"
+	"goto/pkg/proxy/health"
 	"goto/pkg/rpc"
 	"goto/pkg/rpc/grpc"
 	"goto/pkg/server/middleware"
 	"goto/pkg/util"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -36,6 +40,13 @@ func setRoutes(r *mux.Router) {
 	grpcRouter := util.PathPrefix(proxyRouter, "/grpc")
 	util.AddRoute(grpcRouter, "/status", getGRPCProxyDetails, "GET")
 	util.AddRoute(grpcRouter, "/clear", clearGRPCProxies, "POST")
+	util.AddRoute(grpcRouter, "/breaker/{service}/remove", setGRPCCircuitBreaker, "POST")
+	util.AddRoute(grpcRouter, "/breaker/{service}", setGRPCCircuitBreaker, "POST")
+	util.AddRoute(grpcRouter, "/health/{service}/remove", setGRPCHealthCheck, "POST")
+	util.AddRoute(grpcRouter, "/health/{service}", setGRPCHealthCheck, "POST")
+	util.AddRoute(grpcRouter, "/fault/{service}/remove", setGRPCFault, "POST")
+	util.AddRoute(grpcRouter, "/fault/{service}", setGRPCFault, "POST")
+	util.AddRoute(grpcRouter, "/upstreams", getGRPCProxyUpstreams, "GET")
 	util.AddRoute(grpcRouter, "/{service}/{upstream}/tee/{teeport}", proxyGRPCService, "POST")
 	util.AddRoute(grpcRouter, "/{service}/{upstream}/{targetService}/tee/{teeport}", proxyGRPCService, "POST")
 	util.AddRoute(grpcRouter, "/{service}/{upstream}/{targetService}", proxyGRPCService, "POST")
@@ -71,6 +82,119 @@ func proxyGRPCService(w http.ResponseWriter, r *http.Request) {
 	util.AddLogMessage(msg, r)
 }
 
+func setGRPCCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	service := util.GetStringParamValue(r, "service")
+	port := util.GetRequestOrListenerPortNum(r)
+	proxy := GetPortProxy(port)
+	proxy.lock.RLock()
+	sp := proxy.ServiceProxies[service]
+	proxy.lock.RUnlock()
+	msg := ""
+	if sp == nil || sp.Upstream == nil {
+		w.WriteHeader(http.StatusNotFound)
+		msg = fmt.Sprintf("No gRPC proxy for service [%s] on port [%d]", service, port)
+	} else if strings.HasSuffix(r.URL.Path, "/remove") {
+		sp.Upstream.setCircuitBreaker(port, nil, proxy.Tracker)
+		msg = fmt.Sprintf("Circuit breaker removed from upstream [%s] of service [%s] on port [%d]", sp.Upstream.ID, service, port)
+	} else {
+		cb := &breaker.CircuitBreaker{}
+		if err := util.ReadJsonPayload(r, cb); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg = fmt.Sprintf("Failed to parse circuit breaker with error: %s", err.Error())
+		} else if err := sp.Upstream.setCircuitBreaker(port, cb, proxy.Tracker); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg = fmt.Sprintf("Invalid circuit breaker: %s", err.Error())
+		} else {
+			msg = fmt.Sprintf("Circuit breaker set on upstream [%s] of service [%s] on port [%d]: %s", sp.Upstream.ID, service, port, util.ToJSONText(cb))
+		}
+	}
+	fmt.Fprintln(w, msg)
+	util.AddLogMessage(msg, r)
+}
+
+func setGRPCHealthCheck(w http.ResponseWriter, r *http.Request) {
+	service := util.GetStringParamValue(r, "service")
+	port := util.GetRequestOrListenerPortNum(r)
+	proxy := GetPortProxy(port)
+	proxy.lock.RLock()
+	sp := proxy.ServiceProxies[service]
+	proxy.lock.RUnlock()
+	msg := ""
+	if sp == nil || sp.Upstream == nil {
+		w.WriteHeader(http.StatusNotFound)
+		msg = fmt.Sprintf("No gRPC proxy for service [%s] on port [%d]", service, port)
+	} else if strings.HasSuffix(r.URL.Path, "/remove") {
+		sp.Upstream.setHealthCheck(port, nil)
+		msg = fmt.Sprintf("Health check removed from upstream [%s] of service [%s] on port [%d]", sp.Upstream.ID, service, port)
+	} else {
+		hc := &health.HealthCheck{}
+		if err := util.ReadJsonPayload(r, hc); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg = fmt.Sprintf("Failed to parse health check with error: %s", err.Error())
+		} else if err := sp.Upstream.setHealthCheck(port, hc); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg = fmt.Sprintf("Invalid health check: %s", err.Error())
+		} else {
+			msg = fmt.Sprintf("Health check set on upstream [%s] of service [%s] on port [%d]: %s", sp.Upstream.ID, service, port, util.ToJSONText(hc))
+		}
+	}
+	fmt.Fprintln(w, msg)
+	util.AddLogMessage(msg, r)
+}
+
+func setGRPCFault(w http.ResponseWriter, r *http.Request) {
+	service := util.GetStringParamValue(r, "service")
+	port := util.GetRequestOrListenerPortNum(r)
+	proxy := GetPortProxy(port)
+	proxy.lock.RLock()
+	sp := proxy.ServiceProxies[service]
+	proxy.lock.RUnlock()
+	msg := ""
+	if sp == nil || sp.Upstream == nil {
+		w.WriteHeader(http.StatusNotFound)
+		msg = fmt.Sprintf("No gRPC proxy for service [%s] on port [%d]", service, port)
+	} else if strings.HasSuffix(r.URL.Path, "/remove") {
+		sp.Upstream.setFault(nil)
+		msg = fmt.Sprintf("Fault removed from upstream [%s] of service [%s] on port [%d]", sp.Upstream.ID, service, port)
+	} else {
+		f := &fault.Fault{}
+		if err := util.ReadJsonPayload(r, f); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg = fmt.Sprintf("Failed to parse fault with error: %s", err.Error())
+		} else if err := sp.Upstream.setFault(f); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg = fmt.Sprintf("Invalid fault: %s", err.Error())
+		} else {
+			msg = fmt.Sprintf("Fault set on upstream [%s] of service [%s] on port [%d]: %s", sp.Upstream.ID, service, port, util.ToJSONText(f))
+		}
+	}
+	fmt.Fprintln(w, msg)
+	util.AddLogMessage(msg, r)
+}
+
+func getGRPCProxyUpstreams(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	proxy := GetPortProxy(port)
+	upstreams := map[string]any{}
+	proxy.lock.RLock()
+	for service, sp := range proxy.ServiceProxies {
+		if up := sp.Upstream; up != nil {
+			up.lock.RLock()
+			upstreams[service] = map[string]any{
+				"id":          up.ID,
+				"endpoint":    up.Endpoint,
+				"healthCheck": up.HealthCheck,
+				"fault":       up.Fault,
+				"health":      up.Health,
+			}
+			up.lock.RUnlock()
+		}
+	}
+	proxy.lock.RUnlock()
+	util.WriteJsonPayload(w, map[string]any{"port": port, "grpc": upstreams})
+	util.AddLogMessage("Reported gRPC proxy upstreams", r)
+}
+
 func getGRPCProxyDetails(w http.ResponseWriter, r *http.Request) {
 	port := util.GetRequestOrListenerPortNum(r)
 	proxy := GetPortProxy(port)
@@ -0,0 +1,146 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcproxy
+
+import (
+	"goto/pkg/constants"
+	"goto/pkg/proxy/fault"
+	gotogrpc "goto/pkg/rpc/grpc"
+	"goto/pkg/types"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func (up *GRPCUpstream) setFault(f *fault.Fault) error {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	if f != nil {
+		if err := f.Validate(int(codes.Unavailable), fault.ProtocolGRPC); err != nil {
+			return err
+		}
+	}
+	up.Fault = f
+	return nil
+}
+
+// injectFault rolls the upstream's faults for a call and applies the delay, failing the call with the abort code
+// when an abort is rolled. The remaining payload faults are returned to be applied to the response messages.
+func (up *GRPCUpstream) injectFault(tracker *GRPCProxyTracker) (*fault.Injection, error) {
+	up.lock.RLock()
+	f := up.Fault
+	up.lock.RUnlock()
+	injection := f.Roll()
+	if injection == nil {
+		return nil, nil
+	}
+	tracker.IncrementFaultCounts(up.ID, injection.Kinds())
+	injection.ApplyDelay()
+	if injection.Abort {
+		return nil, status.Errorf(codes.Code(injection.Status), "Fault injected: abort for upstream [%s]", up.ID)
+	}
+	return injection, nil
+}
+
+// faultMessages applies the payload faults to the response messages. Corruption garbles the message's string
+// and bytes fields, and truncation cuts the message's encoding at the last field that ends within its first half.
+func faultMessages(msgs []proto.Message, injection *fault.Injection) []proto.Message {
+	if injection == nil || (!injection.Corrupt && !injection.Truncate) {
+		return msgs
+	}
+	faulted := make([]proto.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg == nil {
+			faulted = append(faulted, msg)
+			continue
+		}
+		msg = proto.Clone(msg)
+		if injection.Corrupt {
+			corruptMessage(msg.ProtoReflect())
+		}
+		if injection.Truncate {
+			msg = truncateMessage(msg)
+		}
+		faulted = append(faulted, msg)
+	}
+	return faulted
+}
+
+func corruptMessage(m protoreflect.Message) {
+	fields := []protoreflect.FieldDescriptor{}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if !fd.IsList() && !fd.IsMap() {
+			fields = append(fields, fd)
+		}
+		return true
+	})
+	for _, fd := range fields {
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			b := []byte(m.Get(fd).String())
+			fault.CorruptBytes(b)
+			m.Set(fd, protoreflect.ValueOfString(strings.ToValidUTF8(string(b), "?")))
+		case protoreflect.BytesKind:
+			b := append([]byte{}, m.Get(fd).Bytes()...)
+			fault.CorruptBytes(b)
+			m.Set(fd, protoreflect.ValueOfBytes(b))
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+			corruptMessage(m.Mutable(fd).Message())
+		}
+	}
+}
+
+func truncateMessage(msg proto.Message) proto.Message {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return msg
+	}
+	end := 0
+	for end < len(b) {
+		_, _, n := protowire.ConsumeField(b[end:])
+		if n < 0 || end+n > len(b)/2 {
+			break
+		}
+		end += n
+	}
+	truncated := msg.ProtoReflect().New().Interface()
+	if err := proto.Unmarshal(b[:end], truncated); err != nil {
+		return msg
+	}
+	return truncated
+}
+
+// faultHooks wraps a session's server-side hooks so that the streamed response messages get the payload faults,
+// and the response headers report the injected faults.
+func (s *GRPCSession) faultHooks(hook gotogrpc.HookFunc, headersHook gotogrpc.HeadersHookFunc) (gotogrpc.HookFunc, gotogrpc.HeadersHookFunc) {
+	injection := s.fault
+	return func(msg proto.Message) (metadata.MD, []proto.Message, *types.Delay, error) {
+			md, msgs, delay, err := hook(msg)
+			return md, faultMessages(msgs, injection), delay, err
+		}, func(md metadata.MD) (metadata.MD, error) {
+			md, err := headersHook(md)
+			if md != nil {
+				md.Append(constants.HeaderGotoFault, injection.String())
+			}
+			return md, err
+		}
+}
@@ -22,6 +22,9 @@ import (
 	"fmt"
 	"goto/pkg/constants"
 	"goto/pkg/global"
+	"goto/pkg/proxy/breaker"
+	"goto/pkg/proxy/fault"
+	"goto/pkg/proxy/health"
 	gotogrpc "goto/pkg/rpc/grpc"
 	grpcclient "goto/pkg/rpc/grpc/client"
 	"goto/pkg/types"
@@ -34,7 +37,9 @@ import (
 	"sync/atomic"
 	"time"
 
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/dynamicpb"
@@ -62,15 +67,22 @@ type GRPCSession struct {
 	streamUp       gotogrpc.GRPCStream
 	teeport        int
 	tracker        *GRPCProxyTracker
+	permit         *breaker.Permit
+	fault          *fault.Injection
 }
 
 type GRPCUpstream struct {
 	ID             string                  `json:"id"`
 	Endpoint       string                  `json:"endpoint"`
 	Authority      string                  `json:"authority"`
+	CircuitBreaker *breaker.CircuitBreaker `json:"circuitBreaker"`
+	HealthCheck    *health.HealthCheck     `json:"healthCheck"`
+	Fault          *fault.Fault            `json:"fault"`
+	Health         *health.Checker         `json:"health,omitempty"`
 	ActiveSessions map[string]*GRPCSession `json:"activeSessions"`
 	PastSessions   map[string]*GRPCSession `json:"pastSessions"`
 	client         *grpcclient.GRPCClient
+	circuit        *breaker.Breaker
 	lock           sync.RWMutex
 }
 
@@ -137,7 +149,9 @@ func WillProxyGRPC(port int, method *gotogrpc.GRPCServiceMethod) bool {
 	}
 	if len(sp.Methods) > 0 {
 		if _, present := sp.Methods[method.URI]; !present {
-			return false
+			if _, all := sp.Methods["*"]; !all {
+				return false
+			}
 		}
 	}
 	return true
@@ -174,6 +188,14 @@ func ProxyGRPCUnary(ctx context.Context, port int, method *gotogrpc.GRPCServiceM
 			sessionLog.ClientMessageLog[int(sessionLog.logCounter.Add(1))] = util.JSONFromBytes(b)
 		}
 	}
+	injection, err := up.injectFault(proxy.Tracker)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	permit, err := up.acquire(ctx, proxy.Tracker)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	delay := sp.applyDelay()
 	if delay != "" {
 		log.Printf("[DEBUG] GRPCProxy.ProxyGRPCMethod: Service [%s] Method [%s] Delayed Upstream [%s] by [%s]\n",
@@ -185,6 +207,9 @@ func ProxyGRPCUnary(ctx context.Context, port int, method *gotogrpc.GRPCServiceM
 	}
 	start := time.Now()
 	output, respHeaders, respTrailers, err = up.client.InvokeRaw(toMethod, md, inputs)
+	if permit != nil {
+		permit.Done(isUpstreamFailure(err))
+	}
 	end := time.Now()
 	tookNanos := end.Sub(start)
 	if err == nil {
@@ -196,6 +221,10 @@ func ProxyGRPCUnary(ctx context.Context, port int, method *gotogrpc.GRPCServiceM
 		if delay != "" {
 			respHeaders.Append(constants.HeaderGotoProxyDelay, delay)
 		}
+		if injection != nil {
+			output = faultMessages(output, injection)
+			respHeaders.Append(constants.HeaderGotoFault, injection.String())
+		}
 		sessionLog.ServerHeaders[int(sessionLog.logCounter.Add(1))] = respHeaders
 		for _, output := range output {
 			if b, err := protojson.Marshal(output); err == nil {
@@ -232,9 +261,91 @@ func ProxyGRPCStream(ctx context.Context, port int, method *gotogrpc.GRPCService
 	}
 	receiveCount, sendCount, err = session.Stream()
 	session.Close()
+	if session.permit != nil {
+		session.permit.Done(isUpstreamFailure(err))
+	}
 	return
 }
 
+// acquire admits a call through the upstream's circuit breaker if it has one, failing fast with the breaker's gRPC code otherwise.
+// A call to an upstream that's failing its health checks fails fast as Unavailable.
+func (up *GRPCUpstream) acquire(ctx context.Context, tracker *GRPCProxyTracker) (*breaker.Permit, error) {
+	up.lock.RLock()
+	circuit := up.circuit
+	checker := up.Health
+	up.lock.RUnlock()
+	if !checker.IsHealthy() {
+		return nil, status.Errorf(codes.Unavailable, "Upstream [%s] is unhealthy", up.ID)
+	}
+	if circuit == nil {
+		return nil, nil
+	}
+	permit, reason := circuit.Acquire(ctx)
+	if permit == nil {
+		tracker.IncrementBreakerRejects(up.ID)
+		return nil, status.Errorf(codes.Code(circuit.FailStatus()), "Circuit breaker [%s] for upstream [%s]", reason, up.ID)
+	}
+	return permit, nil
+}
+
+func (up *GRPCUpstream) setCircuitBreaker(port int, cb *breaker.CircuitBreaker, tracker *GRPCProxyTracker) error {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	up.CircuitBreaker = cb
+	up.circuit = nil
+	if cb == nil {
+		return nil
+	}
+	if err := cb.Validate(int(codes.Unavailable), true); err != nil {
+		return err
+	}
+	up.circuit = breaker.New(port, up.ID, cb, func(from, to string) {
+		tracker.AddBreakerTransition(up.ID, from, to)
+	})
+	return nil
+}
+
+func (up *GRPCUpstream) setHealthCheck(port int, hc *health.HealthCheck) error {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	if hc != nil {
+		if err := hc.Validate(health.CheckGRPC); err != nil {
+			return err
+		}
+		if hc.Authority == "" {
+			hc.Authority = up.Authority
+		}
+	}
+	up.Health.Stop()
+	up.Health = nil
+	up.HealthCheck = hc
+	if hc != nil {
+		up.Health = health.New(port, up.ID, up.Endpoint, hc)
+		up.Health.Start()
+	}
+	return nil
+}
+
+func (sp *GRPCServiceProxy) stopHealthCheck() {
+	if sp.Upstream != nil {
+		sp.Upstream.lock.RLock()
+		sp.Upstream.Health.Stop()
+		sp.Upstream.lock.RUnlock()
+	}
+}
+
+// isUpstreamFailure tells whether a call's error is the gRPC equivalent of a 5xx, which counts against the circuit breaker.
+func isUpstreamFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
 func (p *GRPCProxy) createResponse(method *gotogrpc.GRPCServiceMethod, json any) (msg proto.Message, err error) {
 	msg = dynamicpb.NewMessage(method.OutputType())
 	err = protojson.Unmarshal(util.ToJSONBytes(json), msg)
@@ -390,8 +501,12 @@ func AddPortProxy(p *GRPCProxy) {
 
 func RemovePortProxy(port int) {
 	proxyLock.Lock()
+	p := portProxy[port]
 	delete(portProxy, port)
 	proxyLock.Unlock()
+	if p != nil {
+		p.Clear()
+	}
 }
 
 func newGRPCProxy(port int) *GRPCProxy {
@@ -426,6 +541,7 @@ func newGRPCServiceProxy(port int, from, to *gotogrpc.GRPCService, endpoint, aut
 			Endpoint:  endpoint,
 			Authority: authority,
 		},
+		Config:  &GRPCProxyConfig{},
 		tracker: tracker,
 	}
 	if err := sp.init(tracker); err != nil {
@@ -436,6 +552,9 @@ func newGRPCServiceProxy(port int, from, to *gotogrpc.GRPCService, endpoint, aut
 
 func (p *GRPCProxy) Clear() {
 	p.lock.Lock()
+	for _, sp := range p.ServiceProxies {
+		sp.stopHealthCheck()
+	}
 	p.ServiceProxies = map[string]*GRPCServiceProxy{}
 	p.TeeServices = map[string]map[string]*GRPCSessionLog{}
 	p.lock.Unlock()
@@ -450,10 +569,16 @@ func (sp *GRPCServiceProxy) init(tracker *GRPCProxyTracker) error {
 	sp.Upstream.ActiveSessions = map[string]*GRPCSession{}
 	sp.Upstream.PastSessions = map[string]*GRPCSession{}
 	sp.tracker = tracker
+	if err := sp.Upstream.setCircuitBreaker(sp.Port, sp.Upstream.CircuitBreaker, tracker); err != nil {
+		return err
+	}
+	if err := sp.Upstream.setFault(sp.Upstream.Fault); err != nil {
+		return err
+	}
 	if host, port := util.ParseAddress(sp.Upstream.Endpoint); host != "" && port > 0 {
 		if client, err := grpcclient.NewGRPCClient(sp.Label, sp.Port, sp.targetService, sp.Upstream.Endpoint, sp.Upstream.Authority, host, &grpcclient.GRPCOptions{IsTLS: false, VerifyTLS: false}); err == nil {
 			sp.Upstream.client = client
-			return nil
+			return sp.Upstream.setHealthCheck(sp.Port, sp.Upstream.HealthCheck)
 		} else {
 			return err
 		}
@@ -465,6 +590,9 @@ func (sp *GRPCServiceProxy) init(tracker *GRPCProxyTracker) error {
 func (p *GRPCProxy) RemoveServiceProxy(service string) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
+	if sp := p.ServiceProxies[service]; sp != nil {
+		sp.stopHealthCheck()
+	}
 	delete(p.ServiceProxies, service)
 }
 
@@ -502,6 +630,9 @@ func (p *GRPCProxy) SetupGRPCServiceProxy(from, to string, methods map[string]st
 			return err
 		}
 	}
+	if old := p.ServiceProxies[from]; old != nil && old != sp {
+		old.stopHealthCheck()
+	}
 	p.ServiceProxies[from] = sp
 	sp.Methods = map[string]string{}
 	if methods != nil {
@@ -566,11 +697,24 @@ func (p *GRPCProxy) OpenGRPCSession(ctx context.Context, method *gotogrpc.GRPCSe
 	if err != nil {
 		return nil, err
 	}
+	injection, err := sp.Upstream.injectFault(p.Tracker)
+	if err != nil {
+		return nil, err
+	}
+	permit, err := sp.Upstream.acquire(ctx, p.Tracker)
+	if err != nil {
+		return nil, err
+	}
 	upstream, err := sp.Upstream.client.OpenStream(p.Port, toMethod, md, clientMsg)
 	if err != nil {
+		if permit != nil {
+			permit.Done(isUpstreamFailure(err))
+		}
 		return nil, err
 	}
 	session := sp.newGRPCSession(downstreamAddr, sp.Upstream, toMethod, downstream, upstream, teeport)
+	session.permit = permit
+	session.fault = injection
 	if session.Log != nil {
 		session.Log.clientTeeStream <- clientMsg
 	}
@@ -673,6 +817,9 @@ func (s *GRPCSession) Stream() (receiveCount, sendCount int, err error) {
 	} else if s.serviceProxy.hasDelay() {
 		hook1 = gotogrpc.IdentityHookWithDelay(s.serviceProxy.applyDelay)
 	}
+	if s.fault != nil {
+		hook2, headersHook2 = s.faultHooks(hook2, headersHook2)
+	}
 	receiveCount, sendCount, err = s.streamDown.CrossHook(s.streamUp, hook1, hook2, headersHook1, headersHook2)
 	s.streamDown.Close()
 	s.streamUp.Close()
@@ -19,29 +19,37 @@ package grpcproxy
 import "sync"
 
 type GRPCProxyTracker struct {
-	ConnCount                int                       `json:"connCount"`
-	ConnCountByUpstream      map[string]int            `json:"connCountByUpstream"`
-	RequestCountByUpstream   map[string]int            `json:"requestCountByUpstream"`
-	RequestCountByService    map[string]int            `json:"requestCountByService"`
-	RequestCountBySvcMethod  map[string]map[string]int `json:"requestCountByServiceMethod"`
-	ResponseCountByUpstream  map[string]int            `json:"responseCountByUpstream"`
-	ResponseCountByService   map[string]int            `json:"responseCountByService"`
-	ResponseCountBySvcMethod map[string]map[string]int `json:"responseCountByServiceMethod"`
-	MessageCountByType       map[string]int            `json:"messageCountByType"`
-	lock                     sync.RWMutex
+	ConnCount                 int                       `json:"connCount"`
+	ConnCountByUpstream       map[string]int            `json:"connCountByUpstream"`
+	RequestCountByUpstream    map[string]int            `json:"requestCountByUpstream"`
+	RequestCountByService     map[string]int            `json:"requestCountByService"`
+	RequestCountBySvcMethod   map[string]map[string]int `json:"requestCountByServiceMethod"`
+	ResponseCountByUpstream   map[string]int            `json:"responseCountByUpstream"`
+	ResponseCountByService    map[string]int            `json:"responseCountByService"`
+	ResponseCountBySvcMethod  map[string]map[string]int `json:"responseCountByServiceMethod"`
+	MessageCountByType        map[string]int            `json:"messageCountByType"`
+	CircuitBreakerStates      map[string]string         `json:"circuitBreakerStates"`
+	CircuitBreakerTransitions map[string]map[string]int `json:"circuitBreakerTransitions"`
+	CircuitBreakerRejects     map[string]int            `json:"circuitBreakerRejects"`
+	FaultCountsByUpstream     map[string]map[string]int `json:"faultCountsByUpstream"`
+	lock                      sync.RWMutex
 }
 
 func NewGRPCProxyTracker() *GRPCProxyTracker {
 	return &GRPCProxyTracker{
-		ConnCount:                0,
-		ConnCountByUpstream:      map[string]int{},
-		RequestCountByUpstream:   map[string]int{},
-		RequestCountByService:    map[string]int{},
-		RequestCountBySvcMethod:  map[string]map[string]int{},
-		ResponseCountByUpstream:  map[string]int{},
-		ResponseCountByService:   map[string]int{},
-		ResponseCountBySvcMethod: map[string]map[string]int{},
-		MessageCountByType:       map[string]int{},
+		ConnCount:                 0,
+		ConnCountByUpstream:       map[string]int{},
+		RequestCountByUpstream:    map[string]int{},
+		RequestCountByService:     map[string]int{},
+		RequestCountBySvcMethod:   map[string]map[string]int{},
+		ResponseCountByUpstream:   map[string]int{},
+		ResponseCountByService:    map[string]int{},
+		ResponseCountBySvcMethod:  map[string]map[string]int{},
+		MessageCountByType:        map[string]int{},
+		CircuitBreakerStates:      map[string]string{},
+		CircuitBreakerTransitions: map[string]map[string]int{},
+		CircuitBreakerRejects:     map[string]int{},
+		FaultCountsByUpstream:     map[string]map[string]int{},
 	}
 }
 
@@ -82,3 +90,30 @@ func (pt *GRPCProxyTracker) AddMatchCounts(upstream, service, method, requestMes
 		pt.MessageCountByType[responseMessageType] += responseCount
 	}
 }
+
+func (pt *GRPCProxyTracker) AddBreakerTransition(upstream, from, to string) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	pt.CircuitBreakerStates[upstream] = to
+	if pt.CircuitBreakerTransitions[upstream] == nil {
+		pt.CircuitBreakerTransitions[upstream] = map[string]int{}
+	}
+	pt.CircuitBreakerTransitions[upstream][from+"->"+to]++
+}
+
+func (pt *GRPCProxyTracker) IncrementBreakerRejects(upstream string) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	pt.CircuitBreakerRejects[upstream]++
+}
+
+func (pt *GRPCProxyTracker) IncrementFaultCounts(upstream string, kinds []string) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	if pt.FaultCountsByUpstream[upstream] == nil {
+		pt.FaultCountsByUpstream[upstream] = map[string]int{}
+	}
+	for _, kind := range kinds {
+		pt.FaultCountsByUpstream[upstream][kind]++
+	}
+}
@@ -0,0 +1,344 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"goto/pkg/events"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	CheckHTTP = "http"
+	CheckTCP  = "tcp"
+	CheckGRPC = "grpc"
+
+	StateUnknown   = "unknown"
+	StateHealthy   = "healthy"
+	StateUnhealthy = "unhealthy"
+
+	defaultInterval           = 10 * time.Second
+	defaultTimeout            = 2 * time.Second
+	defaultHTTPPath           = "/health"
+	defaultHealthyThreshold   = 2
+	defaultUnhealthyThreshold = 3
+)
+
+// HealthCheck is the active health check config of a proxy upstream. An HTTP check expects the status from the path,
+// a TCP check expects a connection, and a gRPC check expects SERVING from grpc.health.v1 for the service.
+type HealthCheck struct {
+	Type               string `yaml:"type" json:"type"`
+	Path               string `yaml:"path,omitempty" json:"path,omitempty"`
+	Status             int    `yaml:"status,omitempty" json:"status,omitempty"`
+	Service            string `yaml:"service,omitempty" json:"service,omitempty"`
+	Authority          string `yaml:"authority,omitempty" json:"authority,omitempty"`
+	TLS                bool   `yaml:"tls,omitempty" json:"tls,omitempty"`
+	Interval           string `yaml:"interval" json:"interval"`
+	Timeout            string `yaml:"timeout" json:"timeout"`
+	HealthyThreshold   int    `yaml:"healthyThreshold" json:"healthyThreshold"`
+	UnhealthyThreshold int    `yaml:"unhealthyThreshold" json:"unhealthyThreshold"`
+	interval           time.Duration
+	timeout            time.Duration
+}
+
+type HealthStatus struct {
+	Name                 string    `json:"name"`
+	Address              string    `json:"address"`
+	Type                 string    `json:"type"`
+	State                string    `json:"state"`
+	ConsecutiveSuccesses int       `json:"consecutiveSuccesses"`
+	ConsecutiveFailures  int       `json:"consecutiveFailures"`
+	Checks               int       `json:"checks"`
+	Failures             int       `json:"failures"`
+	LastCheckAt          time.Time `json:"lastCheckAt"`
+	LastError            string    `json:"lastError,omitempty"`
+}
+
+// Checker runs the health checks of one upstream address. Until it has seen enough checks to decide,
+// the upstream's state is unknown and it stays selectable.
+type Checker struct {
+	status   HealthStatus
+	port     int
+	config   *HealthCheck
+	client   *http.Client
+	conn     *grpc.ClientConn
+	connErr  error
+	stopChan chan bool
+	stopOnce sync.Once
+	lock     sync.RWMutex
+}
+
+func parseDuration(name, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid health check %s [%s]", name, value)
+	}
+	return d, nil
+}
+
+func (hc *HealthCheck) Validate(defaultType string) error {
+	if hc.Type == "" {
+		hc.Type = defaultType
+	}
+	switch hc.Type {
+	case CheckHTTP:
+		if hc.Path == "" {
+			hc.Path = defaultHTTPPath
+		} else if !strings.HasPrefix(hc.Path, "/") {
+			return fmt.Errorf("invalid health check path [%s]", hc.Path)
+		}
+		if hc.Status == 0 {
+			hc.Status = http.StatusOK
+		} else if hc.Status < 100 || hc.Status > 599 {
+			return fmt.Errorf("invalid health check status [%d]", hc.Status)
+		}
+	case CheckTCP, CheckGRPC:
+	default:
+		return fmt.Errorf("invalid health check type [%s]", hc.Type)
+	}
+	if hc.HealthyThreshold < 0 || hc.UnhealthyThreshold < 0 {
+		return errors.New("health check thresholds can't be negative")
+	}
+	if hc.HealthyThreshold == 0 {
+		hc.HealthyThreshold = defaultHealthyThreshold
+	}
+	if hc.UnhealthyThreshold == 0 {
+		hc.UnhealthyThreshold = defaultUnhealthyThreshold
+	}
+	var err error
+	if hc.interval, err = parseDuration("interval", hc.Interval, defaultInterval); err != nil {
+		return err
+	}
+	if hc.timeout, err = parseDuration("timeout", hc.Timeout, defaultTimeout); err != nil {
+		return err
+	}
+	if hc.timeout > hc.interval {
+		hc.timeout = hc.interval
+	}
+	hc.Interval = hc.interval.String()
+	hc.Timeout = hc.timeout.String()
+	return nil
+}
+
+// New creates a checker for a validated config. For HTTP checks the address can be a base URL,
+// otherwise it's a host:port.
+func New(port int, name, address string, hc *HealthCheck) *Checker {
+	c := &Checker{
+		status:   HealthStatus{Name: name, Address: address, Type: hc.Type, State: StateUnknown},
+		port:     port,
+		config:   hc,
+		stopChan: make(chan bool),
+	}
+	switch hc.Type {
+	case CheckHTTP:
+		c.client = &http.Client{
+			Timeout:   hc.timeout,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	case CheckGRPC:
+		creds := insecure.NewCredentials()
+		if hc.TLS {
+			creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+		}
+		opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+		if hc.Authority != "" {
+			opts = append(opts, grpc.WithAuthority(hc.Authority))
+		}
+		c.conn, c.connErr = grpc.NewClient(address, opts...)
+	}
+	return c
+}
+
+func (c *Checker) Start() {
+	go c.run()
+}
+
+func (c *Checker) Stop() {
+	if c == nil || c.stopChan == nil {
+		return
+	}
+	c.stopOnce.Do(func() {
+		close(c.stopChan)
+		if c.client != nil {
+			c.client.CloseIdleConnections()
+		}
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
+}
+
+// IsHealthy tells whether the upstream can be selected, which is the case unless the checks found it unhealthy.
+func (c *Checker) IsHealthy() bool {
+	if c == nil {
+		return true
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.status.State != StateUnhealthy
+}
+
+func (c *Checker) Status() *HealthStatus {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	status := c.status
+	return &status
+}
+
+func (c *Checker) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Status())
+}
+
+func (c *Checker) run() {
+	ticker := time.NewTicker(c.config.interval)
+	defer ticker.Stop()
+	for {
+		c.check()
+		select {
+		case <-c.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.timeout)
+	defer cancel()
+	var err error
+	switch c.config.Type {
+	case CheckHTTP:
+		err = c.checkHTTP(ctx)
+	case CheckTCP:
+		err = c.checkTCP(ctx)
+	case CheckGRPC:
+		err = c.checkGRPC(ctx)
+	}
+	select {
+	case <-c.stopChan:
+		return
+	default:
+	}
+	c.record(err)
+}
+
+func (c *Checker) checkHTTP(ctx context.Context) error {
+	url := c.status.Address
+	if !strings.Contains(url, "://") {
+		if c.config.TLS {
+			url = "https://" + url
+		} else {
+			url = "http://" + url
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(url, "/")+c.config.Path, nil)
+	if err != nil {
+		return err
+	}
+	if c.config.Authority != "" {
+		req.Host = c.config.Authority
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != c.config.Status {
+		return fmt.Errorf("unexpected status [%d]", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *Checker) checkTCP(ctx context.Context) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", c.status.Address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (c *Checker) checkGRPC(ctx context.Context) error {
+	if c.connErr != nil {
+		return c.connErr
+	}
+	resp, err := healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{Service: c.config.Service})
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service status [%s]", resp.Status.String())
+	}
+	return nil
+}
+
+func (c *Checker) record(err error) {
+	c.lock.Lock()
+	from := c.status.State
+	c.status.Checks++
+	c.status.LastCheckAt = time.Now()
+	if err == nil {
+		c.status.ConsecutiveSuccesses++
+		c.status.ConsecutiveFailures = 0
+		c.status.LastError = ""
+		if c.status.State != StateHealthy && c.status.ConsecutiveSuccesses >= c.config.HealthyThreshold {
+			c.status.State = StateHealthy
+		}
+	} else {
+		c.status.Failures++
+		c.status.ConsecutiveFailures++
+		c.status.ConsecutiveSuccesses = 0
+		c.status.LastError = err.Error()
+		if c.status.State != StateUnhealthy && c.status.ConsecutiveFailures >= c.config.UnhealthyThreshold {
+			c.status.State = StateUnhealthy
+		}
+	}
+	to := c.status.State
+	name := c.status.Name
+	lastError := c.status.LastError
+	c.lock.Unlock()
+	if from == to {
+		return
+	}
+	title := events.Proxy_UpstreamHealthy
+	if to == StateUnhealthy {
+		title = events.Proxy_UpstreamUnhealthy
+	}
+	events.SendEventJSONForPort(c.port, title, fmt.Sprintf("%s: %s -> %s", name, from, to),
+		map[string]any{"upstream": name, "address": c.status.Address, "from": from, "to": to, "error": lastError})
+}
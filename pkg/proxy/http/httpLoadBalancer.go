@@ -0,0 +1,228 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+)
+
+const (
+	LBRoundRobin   = "roundRobin"
+	LBWeighted     = "weighted"
+	LBRandom       = "random"
+	LBLeastRequest = "leastRequest"
+	LBHash         = "hash"
+)
+
+// LoadBalance makes a trigger pick one of its endpoints per request instead of invoking all of them.
+type LoadBalance struct {
+	Policy     string `yaml:"policy" json:"policy"`
+	HashHeader string `yaml:"hashHeader,omitempty" json:"hashHeader,omitempty"`
+	HashCookie string `yaml:"hashCookie,omitempty" json:"hashCookie,omitempty"`
+}
+
+// balancer keeps a trigger's selection state, so each trigger balances across its own endpoints.
+type balancer struct {
+	*LoadBalance
+	next    int
+	current map[string]int
+	lock    sync.Mutex
+}
+
+func (lb *LoadBalance) validate() error {
+	switch lb.Policy {
+	case LBRoundRobin, LBWeighted, LBRandom, LBLeastRequest:
+	case LBHash:
+		if lb.HashHeader == "" && lb.HashCookie == "" {
+			return fmt.Errorf("load balance policy [%s] needs a hashHeader or hashCookie", lb.Policy)
+		}
+	default:
+		return fmt.Errorf("invalid load balance policy [%s]", lb.Policy)
+	}
+	return nil
+}
+
+func newBalancer(lb *LoadBalance) *balancer {
+	return &balancer{LoadBalance: lb, current: map[string]int{}}
+}
+
+func (b *balancer) hashKey(r *http.Request) string {
+	if b.HashHeader != "" {
+		if v := r.Header.Get(b.HashHeader); v != "" {
+			return v
+		}
+	}
+	if b.HashCookie != "" {
+		if c, err := r.Cookie(b.HashCookie); err == nil && c.Value != "" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// pick selects one of the candidate endpoints for the request. A hash request without the hash key falls back to round-robin.
+// The weighted and hash policies leave out endpoints with weight 0. If all the candidates have weight 0, one of them is
+// picked in turn and reported as unweighted, so that the call can be rejected the same way as a call to an unhealthy endpoint.
+func (b *balancer) pick(candidates []*EndpointInvocation, r *http.Request) (ep *EndpointInvocation, unweighted bool) {
+	if len(candidates) == 0 {
+		return nil, false
+	}
+	if b.Policy == LBWeighted || b.Policy == LBHash {
+		if weighted := weighted(candidates); len(weighted) > 0 {
+			candidates = weighted
+		} else {
+			return b.pickRoundRobin(candidates), true
+		}
+	}
+	switch b.Policy {
+	case LBWeighted:
+		return b.pickWeighted(candidates), false
+	case LBRandom:
+		return candidates[rand.IntN(len(candidates))], false
+	case LBLeastRequest:
+		return b.pickLeastRequest(candidates), false
+	case LBHash:
+		if key := b.hashKey(r); key != "" {
+			return pickHash(candidates, key), false
+		}
+	}
+	return b.pickRoundRobin(candidates), false
+}
+
+func (b *balancer) pickRoundRobin(candidates []*EndpointInvocation) *EndpointInvocation {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	ep := candidates[b.next%len(candidates)]
+	b.next++
+	return ep
+}
+
+// pickWeighted does a smooth weighted round-robin, spreading each endpoint's share evenly over the rotation.
+func (b *balancer) pickWeighted(candidates []*EndpointInvocation) *EndpointInvocation {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var best *EndpointInvocation
+	total := 0
+	for _, ep := range candidates {
+		w := ep.ep.weight()
+		total += w
+		b.current[ep.ep.name] += w
+		if best == nil || b.current[ep.ep.name] > b.current[best.ep.name] {
+			best = ep
+		}
+	}
+	b.current[best.ep.name] -= total
+	return best
+}
+
+// weighted gives the candidates that have a non-zero weight.
+func weighted(candidates []*EndpointInvocation) []*EndpointInvocation {
+	var eps []*EndpointInvocation
+	for _, ep := range candidates {
+		if ep.ep.weight() > 0 {
+			eps = append(eps, ep)
+		}
+	}
+	return eps
+}
+
+// pickLeastRequest picks the endpoint with the fewest in-flight requests, rotating the starting point to spread ties.
+func (b *balancer) pickLeastRequest(candidates []*EndpointInvocation) *EndpointInvocation {
+	b.lock.Lock()
+	start := b.next
+	b.next++
+	b.lock.Unlock()
+	var best *EndpointInvocation
+	least := 0
+	for i := range candidates {
+		ep := candidates[(start+i)%len(candidates)]
+		inFlight := ep.ep.getInFlight()
+		if best == nil || inFlight < least {
+			best = ep
+			least = inFlight
+		}
+	}
+	return best
+}
+
+// pickHash does a weighted rendezvous hash of the key, so that a key keeps going to the same endpoint
+// and only the keys of an endpoint that goes away get moved.
+func pickHash(candidates []*EndpointInvocation, key string) *EndpointInvocation {
+	var best *EndpointInvocation
+	bestScore := 0.0
+	for _, ep := range candidates {
+		h := fnv.New64a()
+		h.Write([]byte(ep.ep.name))
+		h.Write([]byte(key))
+		u := (float64(mix64(h.Sum64())>>11) + 0.5) / (1 << 53)
+		score := -float64(ep.ep.weight()) / math.Log(u)
+		if best == nil || score > bestScore {
+			best = ep
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// mix64 spreads the bits of a hash, since FNV leaves the high bits of similar inputs close together.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// healthyEndpoints gives the trigger's endpoints that aren't failing their health checks. When none of them are healthy,
+// it gives all of them and reports so.
+func (t *TargetTrigger) healthyEndpoints() (candidates []*EndpointInvocation, noneHealthy bool) {
+	for _, ep := range t.epList {
+		if ep.ep.Health.IsHealthy() {
+			candidates = append(candidates, ep)
+		}
+	}
+	if len(candidates) == 0 {
+		return t.epList, len(t.epList) > 0
+	}
+	return candidates, false
+}
+
+// weight is the endpoint's configured weight, defaulting to 1 when not set.
+func (ep *TargetEndpoint) weight() int {
+	if ep.Weight == nil {
+		return 1
+	}
+	return *ep.Weight
+}
+
+func (ep *TargetEndpoint) getInFlight() int {
+	ep.lock.RLock()
+	defer ep.lock.RUnlock()
+	return ep.InFlight
+}
+
+func (ep *TargetEndpoint) addInFlight(delta int) {
+	ep.lock.Lock()
+	defer ep.lock.Unlock()
+	ep.InFlight += delta
+}
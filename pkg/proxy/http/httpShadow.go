@@ -0,0 +1,248 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"goto/pkg/constants"
+	"goto/pkg/global"
+	"goto/pkg/invocation"
+)
+
+const (
+	ShadowDiffStatus  = "status"
+	ShadowDiffHeaders = "headers"
+	ShadowDiffBody    = "body"
+)
+
+var defaultShadowIgnoreHeaders = []string{"Date", "Content-Length", "Goto-*", "Via-Goto", "Request-Goto-*"}
+
+// Shadow mirrors a sample of a trigger's requests to a shadow endpoint, whose responses are only compared
+// against the primary response and never sent to the client.
+type Shadow struct {
+	Endpoint      string   `yaml:"endpoint" json:"endpoint"`
+	SamplePct     int      `yaml:"samplePct" json:"samplePct"`
+	IgnoreHeaders []string `yaml:"ignoreHeaders" json:"ignoreHeaders"`
+}
+
+type shadowSpec struct {
+	*Shadow
+	ep *EndpointInvocation
+}
+
+// shadowCall is one mirrored request, whose response gets diffed once the primary endpoint's response is in.
+type shadowCall struct {
+	spec    *shadowSpec
+	target  string
+	primary string
+	result  chan *invocation.InvocationResultResponse
+}
+
+type shadowDiff struct {
+	kinds         []string
+	statusChange  string
+	headerChanges []string
+}
+
+func (s *Shadow) validate() error {
+	if s.Endpoint == "" {
+		return fmt.Errorf("shadow endpoint missing")
+	}
+	if s.SamplePct < 0 || s.SamplePct > 100 {
+		return fmt.Errorf("invalid shadow samplePct [%d]", s.SamplePct)
+	}
+	if s.SamplePct == 0 {
+		s.SamplePct = 100
+	}
+	if len(s.IgnoreHeaders) == 0 {
+		s.IgnoreHeaders = defaultShadowIgnoreHeaders
+	}
+	return nil
+}
+
+// prepareShadow resolves the trigger's shadow, where the trigger's own shadow config takes precedence over the target's.
+func (trigger *TargetTrigger) prepareShadow(port int, t *Target) error {
+	trigger.shadow = nil
+	shadow := trigger.Shadow
+	if shadow == nil {
+		shadow = t.Shadow
+	}
+	if shadow == nil {
+		return nil
+	}
+	if err := shadow.validate(); err != nil {
+		return fmt.Errorf("Target [%s] Trigger [%s]: %s", t.Name, trigger.name, err.Error())
+	}
+	ep := t.Endpoints[shadow.Endpoint]
+	if ep == nil {
+		return fmt.Errorf("Target [%s] Trigger [%s] refers to shadow Endpoint [%s] but endpoint not defined under target", t.Name, trigger.name, shadow.Endpoint)
+	}
+	if trigger.epSpecs[shadow.Endpoint] != nil {
+		return fmt.Errorf("Target [%s] Trigger [%s] uses Endpoint [%s] as both primary and shadow", t.Name, trigger.name, shadow.Endpoint)
+	}
+	tc := trigger.TrafficConfig
+	if tc == nil {
+		tc = t.TrafficConfig
+	}
+	is, err := ep.prepareInvocationSpec(tc)
+	if err != nil {
+		return err
+	}
+	is.RequestCount = 1
+	is.Replicas = 1
+	is.CollectResponse = true
+	trigger.shadow = &shadowSpec{Shadow: shadow, ep: &EndpointInvocation{proxyPort: port, ep: ep, is: is, target: t}}
+	return nil
+}
+
+func (s *shadowSpec) sample() bool {
+	return s.SamplePct >= 100 || rand.IntN(100) < s.SamplePct
+}
+
+func (s *shadowSpec) ignoreHeader(header string) bool {
+	for _, h := range s.IgnoreHeaders {
+		if prefix, found := strings.CutSuffix(h, "*"); found {
+			if len(header) >= len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+				return true
+			}
+		} else if strings.EqualFold(header, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferBody reads the request body once so that the primary and shadow calls each get their own copy of it.
+func (rc *RequestContext) bufferBody() {
+	if rc.bodyBytes != nil || rc.body == nil {
+		return
+	}
+	rc.bodyBytes, _ = io.ReadAll(rc.body)
+	if rc.bodyBytes == nil {
+		rc.bodyBytes = []byte{}
+	}
+	rc.body = bytes.NewReader(rc.bodyBytes)
+}
+
+// invokeShadow sends a copy of the request to the shadow endpoint without waiting for it.
+func (t *MatchedTarget) invokeShadow(primary string, rc *RequestContext, pt *HTTPProxyTracker) {
+	s := t.shadow
+	if !s.ep.ep.Health.IsHealthy() {
+		return
+	}
+	is := s.ep.toInvocationSpec(t.matchedURI, t.transform, rc, pt)
+	is.ResponseWriter = nil
+	if is.BodyReader != nil {
+		is.BodyReader = bytes.NewReader(rc.bodyBytes)
+	}
+	is.Headers[constants.HeaderGotoShadow] = primary
+	tracker, err := invocation.RegisterInvocation(s.ep.proxyPort, is)
+	if err != nil {
+		log.Printf("Proxy[%d]: Failed to invoke shadow endpoint [%s] of target [%s]: %s\n", s.ep.proxyPort, s.Endpoint, t.target.Name, err.Error())
+		return
+	}
+	call := &shadowCall{spec: s, target: t.target.Name, primary: primary, result: make(chan *invocation.InvocationResultResponse, 1)}
+	rc.lock.Lock()
+	rc.shadows = append(rc.shadows, call)
+	rc.lock.Unlock()
+	go func() {
+		var result *invocation.InvocationResultResponse
+		if responses := invocation.StartInvocation(tracker, true); len(responses) > 0 {
+			result = responses[0].Response
+		}
+		call.result <- result
+	}()
+	pt.IncrementTargetShadowRequests(t.target.Name, s.Endpoint)
+	if global.Flags.EnableProxyDebugLogs {
+		log.Printf("[DEBUG] Proxy[%d]: Mirrored request [%s] of target [%s] to shadow endpoint [%s]\n", s.ep.proxyPort, rc.path, t.target.Name, s.Endpoint)
+	}
+}
+
+// diffShadows compares the shadow responses against the primary responses of the request as they come in.
+func diffShadows(calls []*shadowCall, responses UpstreamResults, pt *HTTPProxyTracker) {
+	for _, call := range calls {
+		shadow := <-call.result
+		var primary *invocation.InvocationResultResponse
+		if epResponses := responses[call.target][call.primary]; len(epResponses) > 0 {
+			primary = epResponses[0]
+		}
+		if primary == nil {
+			continue
+		}
+		pt.IncrementTargetShadowDiffs(call.target, call.spec.Endpoint, call.spec.diff(primary, shadow))
+	}
+}
+
+func (s *shadowSpec) diff(primary, shadow *invocation.InvocationResultResponse) *shadowDiff {
+	diff := &shadowDiff{}
+	if shadow == nil {
+		shadow = &invocation.InvocationResultResponse{}
+	}
+	if primary.StatusCode != shadow.StatusCode {
+		diff.kinds = append(diff.kinds, ShadowDiffStatus)
+		diff.statusChange = fmt.Sprintf("%d->%d", primary.StatusCode, shadow.StatusCode)
+	}
+	headers := map[string]bool{}
+	for h := range primary.Headers {
+		headers[http.CanonicalHeaderKey(h)] = true
+	}
+	for h := range shadow.Headers {
+		headers[http.CanonicalHeaderKey(h)] = true
+	}
+	for h := range headers {
+		if s.ignoreHeader(h) {
+			continue
+		}
+		if strings.Join(primary.Headers.Values(h), ",") != strings.Join(shadow.Headers.Values(h), ",") {
+			diff.headerChanges = append(diff.headerChanges, h)
+		}
+	}
+	if len(diff.headerChanges) > 0 {
+		sort.Strings(diff.headerChanges)
+		diff.kinds = append(diff.kinds, ShadowDiffHeaders)
+	}
+	if !sameBody(primary, shadow) {
+		diff.kinds = append(diff.kinds, ShadowDiffBody)
+	}
+	return diff
+}
+
+// sameBody compares the bodies byte for byte, or as JSON values when both are JSON. A primary body that wasn't
+// collected (the target isn't in a payload mode) is compared by size.
+func sameBody(primary, shadow *invocation.InvocationResultResponse) bool {
+	if primary.Payload == nil {
+		return primary.PayloadSize == shadow.PayloadSize
+	}
+	if bytes.Equal(primary.Payload, shadow.Payload) {
+		return true
+	}
+	var p, s any
+	if json.Unmarshal(primary.Payload, &p) != nil || json.Unmarshal(shadow.Payload, &s) != nil {
+		return false
+	}
+	return reflect.DeepEqual(p, s)
+}
@@ -32,6 +32,18 @@ type HTTPCounts struct {
 	UpstreamRequestCountByStatus          map[string]int            `json:"upstreamRequestCountByStatus"`
 	UpstreamRequestCountsByURIStatus      map[string]map[string]int `json:"upstreamRequestCountsByURIStatus"`
 	UpstreamRequestCountsByEndpointStatus map[string]map[string]int `json:"upstreamRequestCountsByEndpointStatus"`
+	LBSelectionCountsByEndpoint           map[string]int            `json:"lbSelectionCountsByEndpoint"`
+	LBRejects                             map[string]int            `json:"lbRejects"`
+	CircuitBreakerStates                  map[string]string         `json:"circuitBreakerStates"`
+	CircuitBreakerTransitions             map[string]map[string]int `json:"circuitBreakerTransitions"`
+	CircuitBreakerRejects                 map[string]int            `json:"circuitBreakerRejects"`
+	ShadowRequestCountsByEndpoint         map[string]int            `json:"shadowRequestCountsByEndpoint"`
+	ShadowMatchCountsByEndpoint           map[string]int            `json:"shadowMatchCountsByEndpoint"`
+	ShadowMismatchCountsByEndpoint        map[string]int            `json:"shadowMismatchCountsByEndpoint"`
+	ShadowDiffCounts                      map[string]map[string]int `json:"shadowDiffCounts"`
+	ShadowStatusDiffs                     map[string]map[string]int `json:"shadowStatusDiffs"`
+	ShadowHeaderDiffs                     map[string]map[string]int `json:"shadowHeaderDiffs"`
+	FaultCountsByEndpoint                 map[string]map[string]int `json:"faultCountsByEndpoint"`
 	RequestDropCountsByURI                map[string]int            `json:"requestDropCountsByURI"`
 	ResponseDropCountsByURI               map[string]int            `json:"responseDropCountsByURI"`
 	URIMatchCounts                        map[string]int            `json:"uriMatchCounts"`
@@ -59,6 +71,18 @@ func NewHTTPCounts() *HTTPCounts {
 		UpstreamRequestCountByStatus:          map[string]int{},
 		UpstreamRequestCountsByURIStatus:      map[string]map[string]int{},
 		UpstreamRequestCountsByEndpointStatus: map[string]map[string]int{},
+		LBSelectionCountsByEndpoint:           map[string]int{},
+		LBRejects:                             map[string]int{},
+		CircuitBreakerStates:                  map[string]string{},
+		CircuitBreakerTransitions:             map[string]map[string]int{},
+		CircuitBreakerRejects:                 map[string]int{},
+		ShadowRequestCountsByEndpoint:         map[string]int{},
+		ShadowMatchCountsByEndpoint:           map[string]int{},
+		ShadowMismatchCountsByEndpoint:        map[string]int{},
+		ShadowDiffCounts:                      map[string]map[string]int{},
+		ShadowStatusDiffs:                     map[string]map[string]int{},
+		ShadowHeaderDiffs:                     map[string]map[string]int{},
+		FaultCountsByEndpoint:                 map[string]map[string]int{},
 		RequestDropCountsByURI:                map[string]int{},
 		ResponseDropCountsByURI:               map[string]int{},
 		URIMatchCounts:                        map[string]int{},
@@ -159,6 +183,7 @@ func (pt *HTTPProxyTracker) IncrementTargetUpstreamCounts(targetName, endpoint,
 	if pt.TargetTrackers[targetName] == nil {
 		pt.TargetTrackers[targetName] = NewHTTPTargetTracker()
 	}
+	pt.UpstreamRequestCountsByEndpoint[endpoint]++
 	pt.TargetTrackers[targetName].lock.Lock()
 	pt.TargetTrackers[targetName].UpstreamRequestCount++
 	pt.TargetTrackers[targetName].UpstreamRequestCountsByURI[requestURI]++
@@ -166,6 +191,135 @@ func (pt *HTTPProxyTracker) IncrementTargetUpstreamCounts(targetName, endpoint,
 	pt.TargetTrackers[targetName].lock.Unlock()
 }
 
+func (pt *HTTPProxyTracker) IncrementTargetLBCounts(targetName, endpoint string) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	if pt.TargetTrackers[targetName] == nil {
+		pt.TargetTrackers[targetName] = NewHTTPTargetTracker()
+	}
+	pt.LBSelectionCountsByEndpoint[endpoint]++
+	pt.TargetTrackers[targetName].lock.Lock()
+	pt.TargetTrackers[targetName].LBSelectionCountsByEndpoint[endpoint]++
+	pt.TargetTrackers[targetName].lock.Unlock()
+}
+
+func (pt *HTTPProxyTracker) IncrementTargetLBRejects(targetName, endpoint string) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	if pt.TargetTrackers[targetName] == nil {
+		pt.TargetTrackers[targetName] = NewHTTPTargetTracker()
+	}
+	pt.LBRejects[endpoint]++
+	pt.TargetTrackers[targetName].lock.Lock()
+	pt.TargetTrackers[targetName].LBRejects[endpoint]++
+	pt.TargetTrackers[targetName].lock.Unlock()
+}
+
+func (hc *HTTPCounts) addBreakerTransition(endpoint, from, to string) {
+	hc.CircuitBreakerStates[endpoint] = to
+	if hc.CircuitBreakerTransitions[endpoint] == nil {
+		hc.CircuitBreakerTransitions[endpoint] = map[string]int{}
+	}
+	hc.CircuitBreakerTransitions[endpoint][from+"->"+to]++
+}
+
+func (pt *HTTPProxyTracker) IncrementTargetBreakerTransition(targetName, endpoint, from, to string) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	if pt.TargetTrackers[targetName] == nil {
+		pt.TargetTrackers[targetName] = NewHTTPTargetTracker()
+	}
+	pt.addBreakerTransition(endpoint, from, to)
+	pt.TargetTrackers[targetName].lock.Lock()
+	pt.TargetTrackers[targetName].addBreakerTransition(endpoint, from, to)
+	pt.TargetTrackers[targetName].lock.Unlock()
+}
+
+func (pt *HTTPProxyTracker) IncrementTargetBreakerRejects(targetName, endpoint string) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	if pt.TargetTrackers[targetName] == nil {
+		pt.TargetTrackers[targetName] = NewHTTPTargetTracker()
+	}
+	pt.CircuitBreakerRejects[endpoint]++
+	pt.TargetTrackers[targetName].lock.Lock()
+	pt.TargetTrackers[targetName].CircuitBreakerRejects[endpoint]++
+	pt.TargetTrackers[targetName].lock.Unlock()
+}
+
+func (hc *HTTPCounts) addShadowDiff(endpoint string, diff *shadowDiff) {
+	if len(diff.kinds) == 0 {
+		hc.ShadowMatchCountsByEndpoint[endpoint]++
+		return
+	}
+	hc.ShadowMismatchCountsByEndpoint[endpoint]++
+	if hc.ShadowDiffCounts[endpoint] == nil {
+		hc.ShadowDiffCounts[endpoint] = map[string]int{}
+	}
+	for _, kind := range diff.kinds {
+		hc.ShadowDiffCounts[endpoint][kind]++
+	}
+	if diff.statusChange != "" {
+		if hc.ShadowStatusDiffs[endpoint] == nil {
+			hc.ShadowStatusDiffs[endpoint] = map[string]int{}
+		}
+		hc.ShadowStatusDiffs[endpoint][diff.statusChange]++
+	}
+	if len(diff.headerChanges) > 0 {
+		if hc.ShadowHeaderDiffs[endpoint] == nil {
+			hc.ShadowHeaderDiffs[endpoint] = map[string]int{}
+		}
+		for _, h := range diff.headerChanges {
+			hc.ShadowHeaderDiffs[endpoint][h]++
+		}
+	}
+}
+
+func (pt *HTTPProxyTracker) IncrementTargetShadowRequests(targetName, endpoint string) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	if pt.TargetTrackers[targetName] == nil {
+		pt.TargetTrackers[targetName] = NewHTTPTargetTracker()
+	}
+	pt.ShadowRequestCountsByEndpoint[endpoint]++
+	pt.TargetTrackers[targetName].lock.Lock()
+	pt.TargetTrackers[targetName].ShadowRequestCountsByEndpoint[endpoint]++
+	pt.TargetTrackers[targetName].lock.Unlock()
+}
+
+func (pt *HTTPProxyTracker) IncrementTargetShadowDiffs(targetName, endpoint string, diff *shadowDiff) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	if pt.TargetTrackers[targetName] == nil {
+		pt.TargetTrackers[targetName] = NewHTTPTargetTracker()
+	}
+	pt.addShadowDiff(endpoint, diff)
+	pt.TargetTrackers[targetName].lock.Lock()
+	pt.TargetTrackers[targetName].addShadowDiff(endpoint, diff)
+	pt.TargetTrackers[targetName].lock.Unlock()
+}
+
+func (hc *HTTPCounts) addFaults(endpoint string, kinds []string) {
+	if hc.FaultCountsByEndpoint[endpoint] == nil {
+		hc.FaultCountsByEndpoint[endpoint] = map[string]int{}
+	}
+	for _, kind := range kinds {
+		hc.FaultCountsByEndpoint[endpoint][kind]++
+	}
+}
+
+func (pt *HTTPProxyTracker) IncrementTargetFaultCounts(targetName, endpoint string, kinds []string) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+	if pt.TargetTrackers[targetName] == nil {
+		pt.TargetTrackers[targetName] = NewHTTPTargetTracker()
+	}
+	pt.addFaults(endpoint, kinds)
+	pt.TargetTrackers[targetName].lock.Lock()
+	pt.TargetTrackers[targetName].addFaults(endpoint, kinds)
+	pt.TargetTrackers[targetName].lock.Unlock()
+}
+
 func (pt *HTTPProxyTracker) IncrementTargetUpstreamStatusCounts(targetName, endpoint, requestURI string, statusCode int) {
 	pt.lock.Lock()
 	defer pt.lock.Unlock()
@@ -21,6 +21,9 @@ import (
 	"goto/pkg/constants"
 	"goto/pkg/invocation"
 	"goto/pkg/metrics"
+	"goto/pkg/proxy/breaker"
+	"goto/pkg/proxy/fault"
+	"goto/pkg/proxy/health"
 	"goto/pkg/server/catchall"
 	"goto/pkg/server/intercept"
 	"goto/pkg/server/middleware"
@@ -62,12 +65,18 @@ func GetPortProxy(port int) *Proxy {
 func ClearAllProxies() {
 	proxyLock.Lock()
 	defer proxyLock.Unlock()
+	for _, p := range portProxy {
+		p.stopHealthChecks()
+	}
 	portProxy = map[int]*Proxy{}
 }
 
 func ClearPortProxy(port int) {
 	proxyLock.Lock()
 	defer proxyLock.Unlock()
+	if p := portProxy[port]; p != nil {
+		p.stopHealthChecks()
+	}
 	portProxy[port] = newProxy(port)
 }
 
@@ -133,6 +142,9 @@ func (p *Proxy) invokeTargets(targetsMatches map[string]*MatchedTarget, rc *Requ
 	}
 	wg.Wait()
 	close(rc.c)
+	if len(rc.shadows) > 0 {
+		go diffShadows(rc.shadows, responses, p.HTTPTracker)
+	}
 	p.processResponses(rc, responses, responseStatuses)
 	rc.rs.StatusCode = proxyResponseStatus
 	rc.w.WriteHeader(proxyResponseStatus)
@@ -241,7 +253,36 @@ func (p *Proxy) processPayload(rc *RequestContext, responses UpstreamResults) {
 }
 
 func (t *MatchedTarget) invoke(rc *RequestContext, out chan *TargetEndpointResponse, wg *sync.WaitGroup, pt *HTTPProxyTracker) {
-	for _, ep := range t.endpoints {
+	endpoints := t.endpoints
+	candidates, noneHealthy := t.trigger.healthyEndpoints()
+	unweighted := false
+	if t.balancer != nil {
+		var ep *EndpointInvocation
+		if ep, unweighted = t.balancer.pick(candidates, rc.r); ep == nil {
+			return
+		}
+		endpoints = map[string]*EndpointInvocation{ep.ep.name: ep}
+		if !unweighted {
+			pt.IncrementTargetLBCounts(t.target.Name, ep.ep.name)
+			util.AddLogMessage(fmt.Sprintf("Load balancer [%s] picked endpoint [%s] of target [%s]", t.balancer.Policy, ep.ep.name, t.target.Name), rc.r)
+		}
+	} else if len(candidates) < len(endpoints) {
+		endpoints = map[string]*EndpointInvocation{}
+		for _, ep := range candidates {
+			endpoints[ep.ep.name] = ep
+		}
+	}
+	primary := ""
+	if t.shadow != nil && !noneHealthy && !unweighted && t.shadow.sample() {
+		for _, ep := range t.trigger.epList {
+			if endpoints[ep.ep.name] != nil {
+				primary = ep.ep.name
+				break
+			}
+		}
+		rc.bufferBody()
+	}
+	for _, ep := range endpoints {
 		t.target.lock.Lock()
 		t.target.CallCount++
 		targetCounter := t.target.CallCount
@@ -254,29 +295,125 @@ func (t *MatchedTarget) invoke(rc *RequestContext, out chan *TargetEndpointRespo
 		epCounter := ep.ep.CallCount
 		ep.ep.lock.Unlock()
 		metrics.UpdateProxiedRequestCount(ep.ep.name)
-		err := ep.invoke(targetCounter, epCounter, t.target.Name, t.matchedURI, t.transform, rc, out, pt)
+		if noneHealthy {
+			util.AddLogMessage(fmt.Sprintf("No healthy endpoint for target [%s], rejected call to endpoint [%s]", t.target.Name, ep.ep.name), rc.r)
+			wg.Add(1)
+			out <- ep.failFastResponse(t.target.Name, http.StatusServiceUnavailable, constants.HeaderGotoUpstreamHealth,
+				ep.ep.name+":"+health.StateUnhealthy, fmt.Sprintf("Endpoint [%s] is unhealthy", ep.ep.name), rc)
+			continue
+		}
+		if unweighted {
+			util.AddLogMessage(fmt.Sprintf("No endpoint with weight for target [%s], rejected call to endpoint [%s]", t.target.Name, ep.ep.name), rc.r)
+			pt.IncrementTargetLBRejects(t.target.Name, ep.ep.name)
+			wg.Add(1)
+			out <- ep.failFastResponse(t.target.Name, http.StatusServiceUnavailable, constants.HeaderGotoLoadBalancer,
+				ep.ep.name+":weight=0", fmt.Sprintf("Endpoint [%s] has weight 0", ep.ep.name), rc)
+			continue
+		}
+		injection := t.fault.Roll()
+		if injection != nil {
+			pt.IncrementTargetFaultCounts(t.target.Name, ep.ep.name, injection.Kinds())
+			util.AddLogMessage(fmt.Sprintf("Injected faults [%s] into call to endpoint [%s] of target [%s]", injection, ep.ep.name, t.target.Name), rc.r)
+		}
+		if injection != nil && injection.Abort {
+			if ep.ep.name == primary {
+				primary = ""
+			}
+			wg.Add(1)
+			go func(ep *EndpointInvocation) {
+				injection.ApplyDelay()
+				out <- ep.failFastResponse(t.target.Name, injection.Status, constants.HeaderGotoFault,
+					ep.ep.name+":"+injection.String(), fmt.Sprintf("Fault injected into call to endpoint [%s]", ep.ep.name), rc)
+			}(ep)
+			continue
+		}
+		if ep.ep.circuit != nil {
+			shadowed := ep.ep.name == primary
+			if shadowed {
+				primary = ""
+			}
+			wg.Add(1)
+			go t.invokeThroughBreaker(ep, targetCounter, epCounter, shadowed, rc, out, wg, pt, injection)
+			continue
+		}
+		err := ep.invoke(targetCounter, epCounter, t.target.Name, t.matchedURI, t.transform, rc, out, pt, nil, injection)
 		if err != nil {
+			if ep.ep.name == primary {
+				primary = ""
+			}
 			log.Println(err.Error())
 		}
 		wg.Add(ep.ep.RequestCount * ep.ep.Concurrent)
 	}
+	if primary != "" {
+		t.invokeShadow(primary, rc, pt)
+	}
+}
+
+// invokeThroughBreaker acquires the endpoint's circuit breaker before invoking it, off the dispatch loop so that a call
+// waiting for a slot doesn't hold up the other endpoints. It's started with one count added to the wait group, which the
+// fail-fast response takes over when the breaker rejects the call, and which is otherwise held until the invocation's
+// calls are counted.
+func (t *MatchedTarget) invokeThroughBreaker(ep *EndpointInvocation, targetCounter, epCounter int, shadowed bool, rc *RequestContext,
+	out chan *TargetEndpointResponse, wg *sync.WaitGroup, pt *HTTPProxyTracker, injection *fault.Injection) {
+	permit, reason := ep.ep.circuit.Acquire(rc.r.Context())
+	if permit == nil {
+		pt.IncrementTargetBreakerRejects(t.target.Name, ep.ep.name)
+		util.AddLogMessage(fmt.Sprintf("Circuit breaker [%s] rejected call to endpoint [%s] of target [%s]", reason, ep.ep.name, t.target.Name), rc.r)
+		out <- ep.failFastResponse(t.target.Name, ep.ep.circuit.FailStatus(), constants.HeaderGotoCircuitBreaker,
+			ep.ep.name+":"+reason, fmt.Sprintf("Circuit breaker [%s] for endpoint [%s]", reason, ep.ep.name), rc)
+		return
+	}
+	defer wg.Done()
+	calls := ep.ep.RequestCount * ep.ep.Concurrent
+	wg.Add(calls)
+	if err := ep.invoke(targetCounter, epCounter, t.target.Name, t.matchedURI, t.transform, rc, out, pt, permit, injection); err != nil {
+		wg.Add(-calls)
+		log.Println(err.Error())
+		return
+	}
+	if shadowed {
+		t.invokeShadow(ep.ep.name, rc, pt)
+	}
 }
 
-func (ep *EndpointInvocation) invoke(targetCounter, epCounter int, target string, matchedURI string, tt *TrafficTransform, rc *RequestContext, out chan *TargetEndpointResponse, pt *HTTPProxyTracker) error {
+func (ep *EndpointInvocation) invoke(targetCounter, epCounter int, target string, matchedURI string, tt *TrafficTransform, rc *RequestContext, out chan *TargetEndpointResponse, pt *HTTPProxyTracker, permit *breaker.Permit, injection *fault.Injection) error {
 	is := ep.toInvocationSpec(matchedURI, tt, rc, pt)
 	tracker, err := invocation.RegisterInvocation(ep.proxyPort, is)
 	if err != nil {
+		if permit != nil {
+			permit.Release()
+		}
 		return err
 	}
 	tracker.CustomID = fmt.Sprintf("%d.%d", targetCounter, epCounter)
 	tracker.OnHeaders = ep.onHeaders(rc)
-	go ep.asyncInvoke(target, tracker, out)
+	ep.ep.addInFlight(1)
+	go ep.asyncInvoke(target, tracker, out, permit, injection)
 	return nil
 }
 
-func (ep *EndpointInvocation) asyncInvoke(target string, tracker *invocation.InvocationTracker, out chan *TargetEndpointResponse) {
+func (ep *EndpointInvocation) asyncInvoke(target string, tracker *invocation.InvocationTracker, out chan *TargetEndpointResponse, permit *breaker.Permit, injection *fault.Injection) {
+	injection.ApplyDelay()
 	responses := invocation.StartInvocation(tracker, true)
+	ep.ep.addInFlight(-1)
+	if permit != nil {
+		failed := len(responses) == 0
+		for _, resp := range responses {
+			if resp.Response.StatusCode == 0 || resp.Response.StatusCode >= 500 {
+				failed = true
+			}
+		}
+		permit.Done(failed)
+	}
 	for _, resp := range responses {
+		if injection != nil {
+			resp.Response.Payload = injection.ApplyPayload(resp.Response.Payload)
+			if resp.Response.Headers == nil {
+				resp.Response.Headers = http.Header{}
+			}
+			resp.Response.Headers.Add(constants.HeaderGotoFault, ep.ep.name+":"+injection.String())
+		}
 		if !util.IsBinaryContentHeader(resp.Response.Headers) {
 			resp.Response.PayloadText = string(resp.Response.Payload)
 		}
@@ -290,6 +427,22 @@ func (ep *EndpointInvocation) asyncInvoke(target string, tracker *invocation.Inv
 	}
 }
 
+// failFastResponse is the response for a call that the proxy rejected without reaching the upstream.
+func (ep *EndpointInvocation) failFastResponse(target string, status int, header, value, payload string, rc *RequestContext) *TargetEndpointResponse {
+	return &TargetEndpointResponse{
+		target:     target,
+		endpoint:   ep.ep.name,
+		requestURI: rc.path,
+		url:        ep.ep.URL,
+		response: &invocation.InvocationResultResponse{
+			Status:      fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:  status,
+			Headers:     http.Header{header: []string{value}},
+			PayloadText: payload,
+		},
+	}
+}
+
 func (ep *EndpointInvocation) onHeaders(rc *RequestContext) func(http.Header, int, *gototls.PeerCertInfo) {
 	return func(headers http.Header, status int, peerCertInfo *gototls.PeerCertInfo) {
 		upstreamViaGoto := []string{}
@@ -565,6 +718,9 @@ func (p *Proxy) getMatchingProxyTargets(r *http.Request) (ProxyTargets, bool) {
 		}
 		if trigger.match(matchedTarget, r) {
 			matchedTarget.endpoints = trigger.epSpecs
+			matchedTarget.balancer = trigger.balancer
+			matchedTarget.shadow = trigger.shadow
+			matchedTarget.fault = trigger.fault
 			if trigger.Transform != nil {
 				matchedTarget.transform = trigger.Transform
 			} else {
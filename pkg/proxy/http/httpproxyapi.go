@@ -52,6 +52,8 @@ func setRoutes(r *mux.Router) {
 	util.AddRoute(httpTargetsRouter, "", getProxyTargets, "GET")
 	util.AddRoute(httpTargetsRouter, "/all", getProxyTargets, "GET")
 	util.AddRoute(httpTargetsRouter, "/{target}/tracker", getProxyTargetTracker, "GET")
+	util.AddRoute(httpProxyRouter, "/upstreams", getProxyUpstreams, "GET")
+	util.AddRoute(httpProxyRouter, "/upstreams/all", getProxyUpstreams, "GET")
 	util.AddRoute(httpProxyRouter, "/trackers/{all}?", getProxyTrackers, "GET")
 	util.AddRoute(proxyRouter, "/trackers/{all}?/clear", clearProxyTrackers, "POST")
 }
@@ -221,6 +223,23 @@ func getProxyTargets(w http.ResponseWriter, r *http.Request) {
 	util.AddLogMessage("Reported proxy targets", r)
 }
 
+func getProxyUpstreams(w http.ResponseWriter, r *http.Request) {
+	all := strings.Contains(r.RequestURI, "all")
+	result := map[string]any{}
+	if all {
+		for port, proxy := range portProxy {
+			result[strconv.Itoa(port)] = proxy.getUpstreams()
+		}
+	} else {
+		port := util.GetRequestOrListenerPortNum(r)
+		proxy := GetPortProxy(port)
+		result["port"] = port
+		result["http"] = proxy.getUpstreams()
+	}
+	util.WriteJsonPayload(w, result)
+	util.AddLogMessage("Reported proxy upstreams", r)
+}
+
 func checkAndGetTarget(proxy *Proxy, w http.ResponseWriter, r *http.Request) *Target {
 	name := util.GetStringParamValue(r, "target")
 	target := proxy.getTarget(name)
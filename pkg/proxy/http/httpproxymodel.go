@@ -20,12 +20,16 @@ import (
 	"errors"
 	"fmt"
 	"goto/pkg/invocation"
+	"goto/pkg/proxy/breaker"
+	"goto/pkg/proxy/fault"
+	"goto/pkg/proxy/health"
 	"goto/pkg/server/intercept"
 	gototls "goto/pkg/tls"
 	"goto/pkg/types"
 	"goto/pkg/util"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"slices"
 	"sync"
@@ -89,24 +93,30 @@ type TrafficTransform struct {
 }
 
 type TargetEndpoint struct {
-	URL          string        `yaml:"url" json:"url"`
-	Method       string        `yaml:"method" json:"method"`
-	Protocol     string        `yaml:"protocol" json:"protocol"`
-	Authority    string        `yaml:"authority" json:"authority"`
-	IsTLS        bool          `yaml:"tls" json:"tls"`
-	ALPN         *gototls.ALPN `yaml:"alpn" json:"alpn"`
-	ClientCert   string        `yaml:"clientCert" json:"clientCert"`
-	RequestCount int           `yaml:"requestCount" json:"requestCount"`
-	Concurrent   int           `yaml:"concurrent" json:"concurrent"`
-	Payload      bool          `yaml:"payload" json:"payload"`
-	JsonPayload  bool          `yaml:"jsonPayload" json:"jsonPayload"`
-	YamlPayload  bool          `yaml:"yamlPayload" json:"yamlPayload"`
-	Transparent  bool          `yaml:"transparent" json:"transparent"`
-	Stream       bool          `yaml:"stream" json:"stream"`
-	CallCount    int           `yaml:"-" json:"callCount"`
-	name         string
-	target       *Target
-	lock         sync.RWMutex
+	URL            string                  `yaml:"url" json:"url"`
+	Method         string                  `yaml:"method" json:"method"`
+	Protocol       string                  `yaml:"protocol" json:"protocol"`
+	Authority      string                  `yaml:"authority" json:"authority"`
+	IsTLS          bool                    `yaml:"tls" json:"tls"`
+	ALPN           *gototls.ALPN           `yaml:"alpn" json:"alpn"`
+	ClientCert     string                  `yaml:"clientCert" json:"clientCert"`
+	RequestCount   int                     `yaml:"requestCount" json:"requestCount"`
+	Concurrent     int                     `yaml:"concurrent" json:"concurrent"`
+	Payload        bool                    `yaml:"payload" json:"payload"`
+	JsonPayload    bool                    `yaml:"jsonPayload" json:"jsonPayload"`
+	YamlPayload    bool                    `yaml:"yamlPayload" json:"yamlPayload"`
+	Transparent    bool                    `yaml:"transparent" json:"transparent"`
+	Stream         bool                    `yaml:"stream" json:"stream"`
+	Weight         *int                    `yaml:"weight,omitempty" json:"weight,omitempty"`
+	CircuitBreaker *breaker.CircuitBreaker `yaml:"circuitBreaker" json:"circuitBreaker"`
+	HealthCheck    *health.HealthCheck     `yaml:"healthCheck" json:"healthCheck"`
+	Health         *health.Checker         `yaml:"-" json:"health,omitempty"`
+	CallCount      int                     `yaml:"-" json:"callCount"`
+	InFlight       int                     `yaml:"-" json:"inFlight"`
+	name           string
+	target         *Target
+	circuit        *breaker.Breaker
+	lock           sync.RWMutex
 }
 
 type EndpointInvocation struct {
@@ -121,9 +131,16 @@ type TargetTrigger struct {
 	Endpoints     []string          `yaml:"endpoints" json:"endpoints"`
 	Transform     *TrafficTransform `yaml:"transform" json:"transform"`
 	TrafficConfig *TrafficConfig    `yaml:"trafficConfig" json:"trafficConfig"`
+	LoadBalance   *LoadBalance      `yaml:"loadBalance" json:"loadBalance"`
+	Shadow        *Shadow           `yaml:"shadow" json:"shadow"`
+	Fault         *fault.Fault      `yaml:"fault" json:"fault"`
 	CallCount     int               `yaml:"-" json:"callCount"`
 	name          string
 	epSpecs       map[string]*EndpointInvocation
+	epList        []*EndpointInvocation
+	balancer      *balancer
+	shadow        *shadowSpec
+	fault         *fault.Fault
 	exactMatches  []*TargetMatch
 	prefixMatches []*TargetMatch
 	lock          sync.RWMutex
@@ -137,6 +154,9 @@ type Target struct {
 	Triggers      map[string]*TargetTrigger  `yaml:"triggers" json:"triggers"`
 	Transform     *TrafficTransform          `yaml:"transform" json:"transform"`
 	TrafficConfig *TrafficConfig             `yaml:"trafficConfig" json:"trafficConfig"`
+	LoadBalance   *LoadBalance               `yaml:"loadBalance" json:"loadBalance"`
+	Shadow        *Shadow                    `yaml:"shadow" json:"shadow"`
+	Fault         *fault.Fault               `yaml:"fault" json:"fault"`
 	CallCount     int                        `yaml:"-" json:"callCount"`
 	streaming     bool
 	lock          sync.RWMutex
@@ -146,6 +166,9 @@ type MatchedTarget struct {
 	target         *Target
 	trigger        *TargetTrigger
 	endpoints      map[string]*EndpointInvocation
+	balancer       *balancer
+	shadow         *shadowSpec
+	fault          *fault.Fault
 	transform      *TrafficTransform
 	trafficConfig  *TrafficConfig
 	matchedURI     string
@@ -198,6 +221,9 @@ type RequestContext struct {
 	yaml        bool
 	parseJson   bool
 	parseYaml   bool
+	bodyBytes   []byte
+	shadows     []*shadowCall
+	lock        sync.Mutex
 }
 
 func newProxy(port int) *Proxy {
@@ -231,6 +257,19 @@ func parseTarget(r io.Reader) (*Target, error) {
 		if ep.URL == "" {
 			return nil, fmt.Errorf("target endpoint [%s] missing url", name)
 		}
+		if ep.Weight != nil && *ep.Weight < 0 {
+			return nil, fmt.Errorf("target endpoint [%s] has negative weight", name)
+		}
+	}
+	if target.LoadBalance != nil {
+		if err := target.LoadBalance.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if target.Shadow != nil {
+		if err := target.Shadow.validate(); err != nil {
+			return nil, err
+		}
 	}
 	if len(target.Triggers) == 0 {
 		return nil, fmt.Errorf("At least one trigger is required")
@@ -242,6 +281,16 @@ func parseTarget(r io.Reader) (*Target, error) {
 		if len(t.Endpoints) == 0 {
 			return nil, fmt.Errorf("target trigger [%s] must specify one endpoint", i)
 		}
+		if t.LoadBalance != nil {
+			if err := t.LoadBalance.validate(); err != nil {
+				return nil, fmt.Errorf("target trigger [%s]: %s", i, err.Error())
+			}
+		}
+		if t.Shadow != nil {
+			if err := t.Shadow.validate(); err != nil {
+				return nil, fmt.Errorf("target trigger [%s]: %s", i, err.Error())
+			}
+		}
 	}
 	return target, nil
 }
@@ -317,6 +366,19 @@ func (p *Proxy) AddTarget(t *Target) error {
 		if ep.Stream {
 			t.streaming = true
 		}
+		ep.circuit = nil
+		if ep.CircuitBreaker != nil {
+			if err := ep.CircuitBreaker.Validate(http.StatusServiceUnavailable, false); err != nil {
+				return fmt.Errorf("Target [%s] Endpoint [%s]: %s", t.Name, epName, err.Error())
+			}
+			ep.circuit = breaker.New(p.Port, t.Name+"/"+epName, ep.CircuitBreaker, p.onBreakerTransition(t.Name, epName))
+		}
+		ep.Health = nil
+		if ep.HealthCheck != nil {
+			if err := ep.HealthCheck.Validate(health.CheckHTTP); err != nil {
+				return fmt.Errorf("Target [%s] Endpoint [%s]: %s", t.Name, epName, err.Error())
+			}
+		}
 	}
 	for triggerName, trigger := range t.Triggers {
 		trigger.name = triggerName
@@ -329,6 +391,22 @@ func (p *Proxy) AddTarget(t *Target) error {
 			trigger.Transform.prepare()
 		}
 		trigger.epSpecs = map[string]*EndpointInvocation{}
+		trigger.epList = nil
+		trigger.balancer = nil
+		if lb := trigger.LoadBalance; lb != nil {
+			trigger.balancer = newBalancer(lb)
+		} else if t.LoadBalance != nil {
+			trigger.balancer = newBalancer(t.LoadBalance)
+		}
+		trigger.fault = trigger.Fault
+		if trigger.fault == nil {
+			trigger.fault = t.Fault
+		}
+		if trigger.fault != nil {
+			if err := trigger.fault.Validate(http.StatusServiceUnavailable, fault.ProtocolHTTP); err != nil {
+				return fmt.Errorf("Target [%s] Trigger [%s]: %s", t.Name, triggerName, err.Error())
+			}
+		}
 		for _, epName := range trigger.Endpoints {
 			ep := t.Endpoints[epName]
 			if ep == nil {
@@ -347,19 +425,94 @@ func (p *Proxy) AddTarget(t *Target) error {
 					is:        is,
 					target:    t,
 				}
+				trigger.epList = append(trigger.epList, trigger.epSpecs[epName])
 			}
 			ep.target = t
 		}
+		if err := trigger.prepareShadow(p.Port, t); err != nil {
+			return err
+		}
 	}
 	if t.Transform != nil {
 		t.Transform.prepare()
 	}
+	t.startHealthChecks(p.Port)
 	p.lock.Lock()
 	defer p.lock.Unlock()
+	if old := p.Targets[t.Name]; old != nil && old != t {
+		old.stopHealthChecks()
+	}
 	p.Targets[t.Name] = t
 	return nil
 }
 
+func (t *Target) startHealthChecks(port int) {
+	for epName, ep := range t.Endpoints {
+		if ep.HealthCheck != nil {
+			ep.Health = health.New(port, t.Name+"/"+epName, ep.healthCheckAddress(), ep.HealthCheck)
+			ep.Health.Start()
+		}
+	}
+}
+
+func (t *Target) stopHealthChecks() {
+	for _, ep := range t.Endpoints {
+		ep.Health.Stop()
+	}
+}
+
+func (p *Proxy) stopHealthChecks() {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, t := range p.Targets {
+		t.stopHealthChecks()
+	}
+}
+
+// healthCheckAddress gives the base URL of the endpoint for HTTP checks, and its host:port for TCP and gRPC checks.
+func (ep *TargetEndpoint) healthCheckAddress() string {
+	u, err := url.Parse(ep.URL)
+	if err != nil || u.Host == "" {
+		return ep.URL
+	}
+	scheme := u.Scheme
+	if ep.IsTLS {
+		scheme = "https"
+	} else if scheme == "" {
+		scheme = "http"
+	}
+	if ep.HealthCheck.Type == health.CheckHTTP {
+		if ep.HealthCheck.Authority == "" {
+			ep.HealthCheck.Authority = ep.Authority
+		}
+		return scheme + "://" + u.Host
+	}
+	if u.Port() != "" {
+		return u.Host
+	}
+	if scheme == "https" {
+		return u.Host + ":443"
+	}
+	return u.Host + ":80"
+}
+
+func (p *Proxy) onBreakerTransition(target, endpoint string) func(from, to string) {
+	return func(from, to string) {
+		p.HTTPTracker.IncrementTargetBreakerTransition(target, endpoint, from, to)
+	}
+}
+
+// getUpstreams gives the endpoints of each target, with their health and in-flight requests.
+func (p *Proxy) getUpstreams() map[string]map[string]*TargetEndpoint {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	upstreams := map[string]map[string]*TargetEndpoint{}
+	for name, t := range p.Targets {
+		upstreams[name] = t.Endpoints
+	}
+	return upstreams
+}
+
 func (p *Proxy) getTarget(name string) *Target {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -369,6 +522,9 @@ func (p *Proxy) getTarget(name string) *Target {
 func (p *Proxy) clearTargets() {
 	p.lock.Lock()
 	defer p.lock.Unlock()
+	for _, t := range p.Targets {
+		t.stopHealthChecks()
+	}
 	p.Targets = map[string]*Target{}
 }
 
@@ -376,6 +532,7 @@ func (p *Proxy) removeTarget(target string) bool {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	if p.Targets[target] != nil {
+		p.Targets[target].stopHealthChecks()
 		delete(p.Targets, target)
 		return true
 	}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"goto/pkg/constants"
 	"goto/pkg/global"
+	"goto/pkg/proxy/health"
 	gototls "goto/pkg/tls"
 	"goto/pkg/types"
 	"goto/pkg/util"
@@ -52,8 +53,9 @@ type TCPProxy struct {
 }
 
 type TCPEndpoint struct {
-	Name    string `yaml:"name" json:"name"`
-	Address string `yaml:"address" json:"address"`
+	Name    string          `yaml:"name" json:"name"`
+	Address string          `yaml:"address" json:"address"`
+	Health  *health.Checker `yaml:"-" json:"health,omitempty"`
 }
 
 type TCPUpstream struct {
@@ -64,6 +66,7 @@ type TCPUpstream struct {
 	Retries            int                     `yaml:"retries" json:"retries"`
 	RetryDelay         *types.Delay            `yaml:"retryDelay" json:"retryDelay"`
 	DropPct            int                     `yaml:"dropPct" json:"dropPct"`
+	HealthCheck        *health.HealthCheck     `yaml:"healthCheck" json:"healthCheck"`
 	proxyPort          int
 	writeSinceLastDrop int
 	isRunning          bool
@@ -108,12 +111,18 @@ func GetPortProxy(port int) *TCPProxy {
 func ClearAllProxies() {
 	proxyLock.Lock()
 	defer proxyLock.Unlock()
+	for _, p := range portProxy {
+		p.stopHealthChecks()
+	}
 	portProxy = map[int]*TCPProxy{}
 }
 
 func ClearPortProxy(port int) {
 	proxyLock.Lock()
 	defer proxyLock.Unlock()
+	if p := portProxy[port]; p != nil {
+		p.stopHealthChecks()
+	}
 	portProxy[port] = newTCPProxy(port)
 }
 
@@ -144,6 +153,11 @@ func ValidateUpstreams(upstreams map[string]*TCPUpstream) error {
 				return fmt.Errorf("target endpoint [%s] missing address/port", name)
 			}
 		}
+		if upstream.HealthCheck != nil {
+			if err := upstream.HealthCheck.Validate(health.CheckTCP); err != nil {
+				return fmt.Errorf("upstream [%s]: %s", upstream.Name, err.Error())
+			}
+		}
 	}
 	return nil
 }
@@ -176,14 +190,43 @@ func (p *TCPProxy) AddUpstreams(upstreams map[string]*TCPUpstream) {
 			upstream.Match.sniRegexp = regexp.MustCompile("(" + strings.Join(snis, "|") + ")")
 		}
 		upstream.proxyPort = p.Port
+		upstream.startHealthChecks()
 	}
+	p.stopHealthChecks()
 	p.lock.Lock()
 	p.Upstreams = upstreams
 	p.lock.Unlock()
 }
 
+func (p *TCPProxy) stopHealthChecks() {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, upstream := range p.Upstreams {
+		upstream.stopHealthChecks()
+	}
+}
+
+func (up *TCPUpstream) startHealthChecks() {
+	for name, ep := range up.Endpoints {
+		ep.Health = nil
+		if up.HealthCheck != nil {
+			ep.Health = health.New(up.proxyPort, up.Name+"/"+name, ep.Address, up.HealthCheck)
+			ep.Health.Start()
+		}
+	}
+}
+
+func (up *TCPUpstream) stopHealthChecks() {
+	for _, ep := range up.Endpoints {
+		ep.Health.Stop()
+	}
+}
+
 func (p *TCPProxy) addNewUpstream(name, address string) {
 	p.lock.Lock()
+	if old := p.Upstreams[address]; old != nil {
+		old.stopHealthChecks()
+	}
 	p.Upstreams[address] = &TCPUpstream{
 		Name: address,
 		Endpoints: map[string]*TCPEndpoint{
@@ -249,6 +292,10 @@ func (session *TCPSessionTracker) prepareEndpoints() {
 	session.endpointAddresses = []*net.TCPAddr{}
 	session.endpointNames = []string{}
 	for _, ep := range session.up.Endpoints {
+		if !ep.Health.IsHealthy() {
+			log.Printf("TCP Proxy[%d]: Skipping unhealthy upstream endpoint [%s]\n", session.ProxyPort, ep.Address)
+			continue
+		}
 		addr, err := net.ResolveTCPAddr("tcp", ep.Address)
 		if err != nil {
 			log.Printf("TCP Proxy[%d]: Error while resolving upstream address: %s\n", session.ProxyPort, err.Error())
@@ -346,6 +393,10 @@ func (session *TCPSessionTracker) proxyPipe(pendingData []byte) {
 		return
 	}
 	session.prepareEndpoints()
+	if len(session.endpointAddresses) == 0 {
+		log.Printf("TCP Proxy[%d]: No healthy upstream endpoints for upstream [%s]\n", session.ProxyPort, session.up.Name)
+		return
+	}
 	session.connectEndpoints()
 	inputChans := session.readFromDownstream()
 	done := util.NewChannel[bool]()
@@ -18,10 +18,12 @@ package udpproxy
 
 import (
 	"fmt"
+	"goto/pkg/proxy/fault"
 	"goto/pkg/server/listeners"
 	"goto/pkg/server/middleware"
 	"goto/pkg/util"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -33,6 +35,9 @@ var (
 func setRoutes(r *mux.Router) {
 	proxyRouter := middleware.RootPath("/proxy")
 	udpProxyRouter := util.PathPrefix(proxyRouter, "/udp")
+	util.AddRoute(udpProxyRouter, "/{port}/status", getUDPProxy, "GET")
+	util.AddRoute(udpProxyRouter, "/{port}/{endpoint}/fault/remove", setUDPFault, "POST")
+	util.AddRoute(udpProxyRouter, "/{port}/{endpoint}/fault", setUDPFault, "POST")
 	util.AddRoute(udpProxyRouter, "/{port}/{endpoint}", proxyUDP, "POST")
 	util.AddRoute(udpProxyRouter, "/{port}/{endpoint}/delay/{delay}", proxyUDP, "POST")
 	util.AddRoute(udpProxyRouter, "/{port}/delay/{delay}", setUDPDelay, "POST")
@@ -76,3 +81,39 @@ func setUDPDelay(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, msg)
 	util.AddLogMessage(msg, r)
 }
+
+func setUDPFault(w http.ResponseWriter, r *http.Request) {
+	port := util.GetIntParamValue(r, "port")
+	endpoint := util.GetStringParamValue(r, "endpoint")
+	msg := ""
+	if strings.HasSuffix(r.URL.Path, "/remove") {
+		if err := SetUDPFault(port, endpoint, nil); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			msg = fmt.Sprintf("Failed to remove fault from UDP upstream [%s] on port [%d]: %s", endpoint, port, err.Error())
+		} else {
+			msg = fmt.Sprintf("Fault removed from UDP upstream [%s] on port [%d]", endpoint, port)
+		}
+	} else {
+		f := &fault.Fault{}
+		if err := util.ReadJsonPayload(r, f); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg = fmt.Sprintf("Failed to parse fault with error: %s", err.Error())
+		} else if err := SetUDPFault(port, endpoint, f); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg = fmt.Sprintf("Failed to set fault on UDP upstream [%s] on port [%d]: %s", endpoint, port, err.Error())
+		} else {
+			msg = fmt.Sprintf("Fault set on UDP upstream [%s] on port [%d]: %s", endpoint, port, util.ToJSONText(f))
+		}
+	}
+	fmt.Fprintln(w, msg)
+	util.AddLogMessage(msg, r)
+}
+
+func getUDPProxy(w http.ResponseWriter, r *http.Request) {
+	port := util.GetIntParamValue(r, "port")
+	p := GetUDPProxy(port)
+	p.lock.RLock()
+	util.WriteJsonPayload(w, p)
+	p.lock.RUnlock()
+	util.AddLogMessage(fmt.Sprintf("Reported UDP proxy on port [%d]", port), r)
+}
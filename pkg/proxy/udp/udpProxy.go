@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"goto/pkg/proxy/fault"
 	"goto/pkg/server/listeners"
 	"goto/pkg/types"
 	"log"
@@ -29,6 +30,8 @@ import (
 	"time"
 )
 
+const reorderWait = time.Second
+
 var (
 	udpProxyByPort = map[int]*UDPProxy{}
 	proxyLock      sync.RWMutex
@@ -39,13 +42,20 @@ type UDPUpstream struct {
 	Protocol     string       `json:"protocol"`
 	Endpoint     string       `json:"endpoint"`
 	Delay        *types.Delay `json:"delay"`
+	Fault        *fault.Fault `json:"fault"`
 	upstreamAddr *net.UDPAddr
 	isRunning    bool
 	conn         *net.UDPConn
+	held         *heldDatagram
 	stopChan     chan bool
 	lock         sync.RWMutex
 }
 
+// heldDatagram is a response datagram held back to be sent after the next one.
+type heldDatagram struct {
+	send func()
+}
+
 type UDPProxy struct {
 	Port       int                     `json:"port"`
 	Enabled    bool                    `json:"enabled"`
@@ -100,6 +110,14 @@ func StopUDPUpstream(port int, upstream string) {
 	getUDPProxyForPort(port).stopUpstream(upstream)
 }
 
+func SetUDPFault(port int, upstream string, f *fault.Fault) error {
+	return getUDPProxyForPort(port).setUDPFault(upstream, f)
+}
+
+func GetUDPProxy(port int) *UDPProxy {
+	return getUDPProxyForPort(port)
+}
+
 func (p *UDPProxy) initTracker() {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -115,6 +133,24 @@ func (p *UDPProxy) setUDPDelay(upstream string, delayMin, delayMax time.Duration
 	}
 }
 
+func (p *UDPProxy) setUDPFault(upstream string, f *fault.Fault) error {
+	if f != nil {
+		if err := f.Validate(0, fault.ProtocolUDP); err != nil {
+			return err
+		}
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	up := p.Upstreams[upstream]
+	if up == nil {
+		return fmt.Errorf("upstream [%s] not found", upstream)
+	}
+	up.lock.Lock()
+	up.Fault = f
+	up.lock.Unlock()
+	return nil
+}
+
 func (p *UDPProxy) startUpstream(name, upstream string, delayMin, delayMax time.Duration) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -176,15 +212,17 @@ func (p *UDPProxy) runProxy(up *UDPUpstream) error {
 }
 
 func (up *UDPUpstream) readFromDownstream(conn *net.UDPConn) (packet []byte, clientAddr net.Addr, err error) {
-	if conn == nil || conn.RemoteAddr() == nil {
+	if conn == nil {
 		err = errors.New("connection is nil")
 		return
 	}
 	packet = make([]byte, 4096)
-	_, clientAddr, err = conn.ReadFrom(packet)
+	n := 0
+	n, clientAddr, err = conn.ReadFrom(packet)
 	if err != nil {
-		log.Printf("Error reading packet from downstream [%s], error: %s\n", conn.RemoteAddr().String(), err.Error())
+		log.Printf("Error reading packet from downstream on [%s], error: %s\n", conn.LocalAddr().String(), err.Error())
 	}
+	packet = packet[:n]
 	return
 }
 
@@ -195,10 +233,19 @@ func (up *UDPUpstream) handlePacket(listenerConn net.PacketConn, clientAddr net.
 	domain := extractDomain(packet)
 	up.lock.RLock()
 	address := up.Endpoint
+	injection := up.Fault.Roll()
 	up.lock.RUnlock()
 	if domain != "" {
 		tracker.IncrementPacketCounts(address, domain)
 	}
+	if injection != nil {
+		tracker.IncrementFaultCounts(address, injection.Kinds())
+		if injection.Drop {
+			log.Printf("Dropped UDP packet from downstream [%s] to upstream [%s] for domain [%s] due to fault\n", clientAddr, address, domain)
+			return
+		}
+		injection.ApplyDelay()
+	}
 	_, err := up.conn.Write(packet)
 	if err != nil {
 		log.Printf("Failed to send packet to upstream [%s] for domain [%s]: error [%s]\n", address, domain, err)
@@ -215,12 +262,56 @@ func (up *UDPUpstream) handlePacket(listenerConn net.PacketConn, clientAddr net.
 	if up.Delay != nil {
 		delay = up.Delay.Apply().String()
 	}
-	_, err = listenerConn.WriteTo(resp[:n], clientAddr)
-	if err != nil {
-		log.Printf("Failed to send packet to downstream [%s] for domain [%s]: error [%s]\n", clientAddr, domain, err)
+	payload := injection.ApplyPayload(resp[:n])
+	send := func() {
+		copies := 1
+		if injection != nil && injection.Duplicate {
+			copies = 2
+		}
+		for range copies {
+			if _, err := listenerConn.WriteTo(payload, clientAddr); err != nil {
+				log.Printf("Failed to send packet to downstream [%s] for domain [%s]: error [%s]\n", clientAddr, domain, err)
+				return
+			}
+		}
+		log.Printf("Proxied UDP query from downstream [%s] to upstream [%s] for domain [%s] with delay [%s] and faults [%s]\n", clientAddr, address, domain, delay, injection.String())
+	}
+	if injection != nil && injection.Reorder {
+		up.hold(send)
+		return
+	}
+	send()
+	up.release(nil)
+}
+
+// hold keeps a response datagram back until the next response of the upstream has been sent, so that the two
+// reach the client out of order. If another datagram is already held, this one is sent ahead of it instead.
+// A held datagram is sent anyway after reorderWait.
+func (up *UDPUpstream) hold(send func()) {
+	h := &heldDatagram{send: send}
+	up.lock.Lock()
+	if up.held != nil {
+		up.lock.Unlock()
+		send()
+		up.release(nil)
+		return
+	}
+	up.held = h
+	up.lock.Unlock()
+	time.AfterFunc(reorderWait, func() { up.release(h) })
+}
+
+// release sends the held datagram, if it's still held. A nil datagram releases whichever one is held.
+func (up *UDPUpstream) release(h *heldDatagram) {
+	up.lock.Lock()
+	held := up.held
+	if held == nil || (h != nil && held != h) {
+		up.lock.Unlock()
 		return
 	}
-	log.Printf("Proxied UDP query from downstream [%s] to upstream [%s] for domain [%s] with delay [%s]\n", clientAddr, address, domain, delay)
+	up.held = nil
+	up.lock.Unlock()
+	held.send()
 }
 
 func (up *UDPUpstream) setUDPDelay(delayMin, delayMax time.Duration) {
@@ -24,6 +24,7 @@ type UDPProxyTracker struct {
 	PacketCountByUpstream       map[string]int            `json:"packetCountByUpstream"`
 	PacketCountByDomain         map[string]int            `json:"packetCountByDomain"`
 	PacketCountByUpstreamDomain map[string]map[string]int `json:"packetCountByUpstreamDomain"`
+	FaultCountsByUpstream       map[string]map[string]int `json:"faultCountsByUpstream"`
 	lock                        sync.RWMutex
 }
 
@@ -32,6 +33,7 @@ func NewUDPTracker() *UDPProxyTracker {
 		PacketCountByUpstream:       map[string]int{},
 		PacketCountByDomain:         map[string]int{},
 		PacketCountByUpstreamDomain: map[string]map[string]int{},
+		FaultCountsByUpstream:       map[string]map[string]int{},
 	}
 }
 
@@ -52,3 +54,14 @@ func (ut *UDPProxyTracker) IncrementPacketCounts(upstream, domain string) {
 	}
 	ut.PacketCountByUpstreamDomain[upstream][domain]++
 }
+
+func (ut *UDPProxyTracker) IncrementFaultCounts(upstream string, kinds []string) {
+	ut.lock.Lock()
+	defer ut.lock.Unlock()
+	if ut.FaultCountsByUpstream[upstream] == nil {
+		ut.FaultCountsByUpstream[upstream] = map[string]int{}
+	}
+	for _, kind := range kinds {
+		ut.FaultCountsByUpstream[upstream][kind]++
+	}
+}
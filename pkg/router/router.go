@@ -25,6 +25,7 @@ import (
 	"goto/pkg/server/intercept"
 	"goto/pkg/server/listeners"
 	"goto/pkg/server/request"
+	"goto/pkg/server/request/recorder"
 	"goto/pkg/server/response"
 	"goto/pkg/transport"
 	"goto/pkg/util"
@@ -155,9 +156,10 @@ func newPortRouter(port int) *PortRouter {
 		ModifyResponse: pr.Intercept,
 	}
 
-	pr.requestHandler = intercept.IntereceptMiddleware(request.Middleware.MiddlewareHandler.Middleware(nil), nil)(pr.proxy)
-	pr.responseHandler = intercept.IntereceptMiddleware(nil, response.Middleware.MiddlewareHandler.Middleware(nil))(pr.proxy)
-	pr.requestResponseHandler = intercept.IntereceptMiddleware(request.Middleware.MiddlewareHandler.Middleware(nil), response.Middleware.MiddlewareHandler.Middleware(nil))(pr.proxy)
+	proxy := recorder.Middleware.MiddlewareHandler(pr.proxy)
+	pr.requestHandler = intercept.IntereceptMiddleware(request.Middleware.MiddlewareHandler.Middleware(nil), nil)(proxy)
+	pr.responseHandler = intercept.IntereceptMiddleware(nil, response.Middleware.MiddlewareHandler.Middleware(nil))(proxy)
+	pr.requestResponseHandler = intercept.IntereceptMiddleware(request.Middleware.MiddlewareHandler.Middleware(nil), response.Middleware.MiddlewareHandler.Middleware(nil))(proxy)
 	return pr
 }
 
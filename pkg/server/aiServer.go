@@ -23,6 +23,7 @@ import (
 	"goto/pkg/global"
 	"goto/pkg/server/intercept"
 	"goto/pkg/server/middleware"
+	"goto/pkg/server/request/recorder"
 	"goto/pkg/util"
 	"io"
 	"log"
@@ -67,6 +68,7 @@ func configureAIRouter() *mux.Router {
 	aiRouter.SkipClean(true)
 	middleware.UseCore(aiRouter)
 	aiRouter.Use(intercept.IntereceptMiddleware(nil, postIntercept()))
+	aiRouter.Use(recorder.Middleware.MiddlewareHandler)
 	aiRouter.MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
 		return true
 	}).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,289 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	gototls "goto/pkg/tls"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultIssuer   = "goto"
+	defaultTokenTTL = time.Hour
+)
+
+type OAuthClient struct {
+	Secret   string         `json:"secret,omitempty"`
+	Scopes   []string       `json:"scopes,omitempty"`
+	Audience []string       `json:"audience,omitempty"`
+	Claims   map[string]any `json:"claims,omitempty"`
+	TTL      string         `json:"ttl,omitempty"`
+	ttl      time.Duration
+}
+
+// Issuer signs the port's JWTs with a key from the tls package, either a cert/key uploaded by name or a generated one.
+type Issuer struct {
+	Issuer    string                  `json:"issuer"`
+	Key       string                  `json:"key,omitempty"`
+	Audience  []string                `json:"audience,omitempty"`
+	TTL       string                  `json:"ttl"`
+	Claims    map[string]any          `json:"claims,omitempty"`
+	Clients   map[string]*OAuthClient `json:"clients,omitempty"`
+	KeyID     string                  `json:"kid"`
+	Algorithm string                  `json:"alg"`
+	Issued    int                     `json:"issued"`
+	ttl       time.Duration
+	signer    jose.Signer
+	publicKey jose.JSONWebKey
+	lock      sync.Mutex
+}
+
+var (
+	portIssuers = map[int]*Issuer{}
+	issuersLock sync.RWMutex
+)
+
+// getPortIssuer returns the port's issuer, creating one with a generated key on first use.
+func getPortIssuer(port int) (*Issuer, error) {
+	issuersLock.RLock()
+	issuer := portIssuers[port]
+	issuersLock.RUnlock()
+	if issuer != nil {
+		return issuer, nil
+	}
+	issuersLock.Lock()
+	defer issuersLock.Unlock()
+	if portIssuers[port] == nil {
+		issuer = &Issuer{}
+		if err := issuer.init(); err != nil {
+			return nil, err
+		}
+		portIssuers[port] = issuer
+	}
+	return portIssuers[port], nil
+}
+
+func setPortIssuer(port int, issuer *Issuer) {
+	issuersLock.Lock()
+	defer issuersLock.Unlock()
+	portIssuers[port] = issuer
+}
+
+func signingAlgorithm(key crypto.Signer) (jose.SignatureAlgorithm, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jose.RS256, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jose.ES256, nil
+		case elliptic.P384():
+			return jose.ES384, nil
+		case elliptic.P521():
+			return jose.ES512, nil
+		}
+	case ed25519.PrivateKey:
+		return jose.EdDSA, nil
+	}
+	return "", fmt.Errorf("unsupported signing key type [%T]", key)
+}
+
+// parseTTL parses an optional token lifetime. Negative lifetimes are let through for ad-hoc tokens that should
+// already be expired, and rejected by the issuer for its own and its clients' default lifetimes.
+func parseTTL(ttl string) (time.Duration, error) {
+	if ttl == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttl [%s]", ttl)
+	}
+	return d, nil
+}
+
+func (i *Issuer) init() error {
+	if i.Issuer == "" {
+		i.Issuer = defaultIssuer
+	}
+	var err error
+	if i.ttl, err = parseTTL(i.TTL); err != nil || i.ttl < 0 {
+		return fmt.Errorf("invalid ttl [%s]", i.TTL)
+	}
+	if i.ttl == 0 {
+		i.ttl = defaultTokenTTL
+	}
+	i.TTL = i.ttl.String()
+	for id, client := range i.Clients {
+		if client == nil {
+			return fmt.Errorf("invalid client [%s]", id)
+		}
+		if client.ttl, err = parseTTL(client.TTL); err != nil || client.ttl < 0 {
+			return fmt.Errorf("invalid ttl [%s] for client [%s]", client.TTL, id)
+		}
+	}
+	var key crypto.Signer
+	if i.Key != "" {
+		certs, _ := gototls.GetCerts(i.Key)
+		if len(certs) == 0 {
+			return fmt.Errorf("cert/key [%s] not found", i.Key)
+		}
+		signer, ok := certs[0].PrivateKey.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("key [%s] can't sign", i.Key)
+		}
+		key = signer
+	} else if cert, err := gototls.CreateCertificate([]string{i.Issuer}, "", ""); err != nil {
+		return fmt.Errorf("failed to generate signing key: %s", err.Error())
+	} else {
+		key = cert.PrivateKey.(crypto.Signer)
+	}
+	alg, err := signingAlgorithm(key)
+	if err != nil {
+		return err
+	}
+	i.Algorithm = string(alg)
+	i.publicKey = jose.JSONWebKey{Key: key.Public(), Algorithm: i.Algorithm, Use: "sig"}
+	thumbprint, err := i.publicKey.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	i.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+	i.publicKey.KeyID = i.KeyID
+	i.signer, err = jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: jose.JSONWebKey{Key: key, KeyID: i.KeyID}},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	return err
+}
+
+func (i *Issuer) jwks() *jose.JSONWebKeySet {
+	return &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{i.publicKey}}
+}
+
+// issue signs a token for the subject, with the issuer's claims, then the extra claims, layered over the registered claims.
+func (i *Issuer) issue(subject string, audience []string, ttl time.Duration, extra ...map[string]any) (string, time.Duration, error) {
+	if ttl == 0 {
+		ttl = i.ttl
+	}
+	if len(audience) == 0 {
+		audience = i.Audience
+	}
+	now := time.Now()
+	claims := map[string]any{
+		"iss": i.Issuer,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+		"jti": uuid.NewString(),
+	}
+	if subject != "" {
+		claims["sub"] = subject
+	}
+	if len(audience) == 1 {
+		claims["aud"] = audience[0]
+	} else if len(audience) > 1 {
+		claims["aud"] = audience
+	}
+	for k, v := range i.Claims {
+		claims[k] = v
+	}
+	for _, e := range extra {
+		for k, v := range e {
+			claims[k] = v
+		}
+	}
+	token, err := jwt.Signed(i.signer).Claims(claims).Serialize()
+	if err == nil {
+		i.lock.Lock()
+		i.Issued++
+		i.lock.Unlock()
+	}
+	return token, ttl, err
+}
+
+// grant issues a client credentials token, returning an OAuth2 error code if the client or scope isn't acceptable.
+func (i *Issuer) grant(clientID, secret, scope string, audience []string) (token string, ttl time.Duration, granted string, errCode string, err error) {
+	var client *OAuthClient
+	if len(i.Clients) > 0 {
+		if client = i.Clients[clientID]; client == nil || client.Secret != secret {
+			return "", 0, "", "invalid_client", errors.New("unknown client or wrong secret")
+		}
+	}
+	scopes := strings.Fields(scope)
+	extra := map[string]any{}
+	if client != nil {
+		if len(scopes) == 0 {
+			scopes = client.Scopes
+		} else if len(client.Scopes) > 0 {
+			for _, s := range scopes {
+				if !slices.Contains(client.Scopes, s) {
+					return "", 0, "", "invalid_scope", fmt.Errorf("scope [%s] not allowed for client [%s]", s, clientID)
+				}
+			}
+		}
+		if len(audience) == 0 {
+			audience = client.Audience
+		}
+		ttl = client.ttl
+		for k, v := range client.Claims {
+			extra[k] = v
+		}
+	}
+	granted = strings.Join(scopes, " ")
+	if granted != "" {
+		extra["scope"] = granted
+	}
+	if clientID != "" {
+		extra["client_id"] = clientID
+	}
+	token, ttl, err = i.issue(clientID, audience, ttl, extra)
+	return
+}
+
+func (i *Issuer) verify(token string) (map[string]any, *jwt.Claims, error) {
+	parsed, err := jwt.ParseSigned(token, []jose.SignatureAlgorithm{jose.SignatureAlgorithm(i.Algorithm)})
+	if err != nil {
+		return nil, nil, errors.New("malformed token")
+	}
+	claims := map[string]any{}
+	registered := &jwt.Claims{}
+	if err := parsed.Claims(i.publicKey.Key, &claims, registered); err != nil {
+		return nil, nil, errors.New("invalid signature")
+	}
+	return claims, registered, nil
+}
+
+func (i *Issuer) snapshot() *Issuer {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return &Issuer{Issuer: i.Issuer, Key: i.Key, Audience: i.Audience, TTL: i.TTL, Claims: i.Claims, Clients: i.Clients,
+		KeyID: i.KeyID, Algorithm: i.Algorithm, Issued: i.Issued}
+}
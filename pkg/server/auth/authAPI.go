@@ -0,0 +1,257 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"goto/pkg/constants"
+	"goto/pkg/events"
+	"goto/pkg/server/middleware"
+	"goto/pkg/util"
+
+	"github.com/gorilla/mux"
+)
+
+type IssueRequest struct {
+	Subject  string         `json:"subject"`
+	Audience []string       `json:"audience"`
+	TTL      string         `json:"ttl"`
+	Claims   map[string]any `json:"claims"`
+}
+
+var (
+	Middleware = middleware.NewMiddleware("auth", setRoutes, middlewareFunc)
+)
+
+func setRoutes(r *mux.Router) {
+	authRouter := util.PathRouter(r, "/auth")
+	util.AddRoute(authRouter, "/issuer", setIssuer, "POST", "PUT")
+	util.AddRoute(authRouter, "/issuer", getIssuer, "GET")
+	util.AddRoute(authRouter, "/token", issueOAuthToken, "POST")
+	util.AddRoute(authRouter, "/jwks", getJWKS, "GET")
+	util.AddRoute(authRouter, "/issue", issueToken, "POST")
+	util.AddRoute(authRouter, "/policy/add", addPolicy, "POST", "PUT")
+	util.AddRoute(authRouter, "/policy/remove/{name}", removePolicy, "POST", "PUT")
+	util.AddRoute(authRouter, "/policy/clear", clearPolicies, "POST")
+	util.AddRoute(authRouter, "/counts/clear", clearCounts, "POST")
+	util.AddRoute(authRouter, "", getAuth, "GET")
+}
+
+func setIssuer(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	issuer := &Issuer{}
+	msg := ""
+	if err := util.ReadJsonPayload(r, issuer); err != nil {
+		msg = fmt.Sprintf("Failed to parse issuer with error: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+	} else if err := issuer.init(); err != nil {
+		msg = fmt.Sprintf("Invalid issuer: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		setPortIssuer(port, issuer)
+		msg = fmt.Sprintf("Port [%d] set issuer [%s] signing with [%s] key [%s]", port, issuer.Issuer, issuer.Algorithm, issuer.KeyID)
+		events.SendRequestEventJSON("Auth Issuer Set", issuer.Issuer, issuer.snapshot(), r)
+		w.WriteHeader(http.StatusOK)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
+
+func getIssuer(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	if issuer, err := getPortIssuer(port); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintln(w, err.Error())
+	} else {
+		util.WriteJsonPayload(w, issuer.snapshot())
+	}
+	util.AddLogMessage(fmt.Sprintf("Port [%d] reporting issuer", port), r)
+}
+
+func getJWKS(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	if issuer, err := getPortIssuer(port); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintln(w, err.Error())
+	} else {
+		util.WriteJsonPayload(w, issuer.jwks())
+	}
+	util.AddLogMessage(fmt.Sprintf("Port [%d] serving JWKS", port), r)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSON)
+	w.Header().Set(constants.HeaderCacheControl, "no-store")
+	w.WriteHeader(status)
+	util.WriteJson(w, map[string]string{"error": code, "error_description": description})
+}
+
+// issueOAuthToken serves the OAuth2 token endpoint for the client credentials grant.
+func issueOAuthToken(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	issuer, err := getPortIssuer(port)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if grantType := r.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", fmt.Sprintf("grant type [%s] not supported", grantType))
+		return
+	}
+	clientID, secret, basic := r.BasicAuth()
+	if !basic {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	var audience []string
+	if aud := r.PostForm.Get("audience"); aud != "" {
+		audience = strings.Fields(aud)
+	}
+	token, ttl, scope, errCode, err := issuer.grant(clientID, secret, r.PostForm.Get("scope"), audience)
+	if err != nil {
+		msg := fmt.Sprintf("Port [%d] denied token to client [%s]: %s", port, clientID, err.Error())
+		util.AddLogMessage(msg, r)
+		switch {
+		case errCode == "invalid_client":
+			if basic {
+				w.Header().Set("WWW-Authenticate", "Basic realm=\""+issuer.Issuer+"\"")
+			}
+			writeOAuthError(w, http.StatusUnauthorized, errCode, err.Error())
+		case errCode != "":
+			writeOAuthError(w, http.StatusBadRequest, errCode, err.Error())
+		default:
+			writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		}
+		return
+	}
+	response := map[string]any{"access_token": token, "token_type": "Bearer", "expires_in": int(ttl.Seconds())}
+	if scope != "" {
+		response["scope"] = scope
+	}
+	w.Header().Set(constants.HeaderCacheControl, "no-store")
+	util.WriteJsonPayload(w, response)
+	util.AddLogMessage(fmt.Sprintf("Port [%d] issued token to client [%s] with scope [%s]", port, clientID, scope), r)
+}
+
+func issueToken(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	ir := &IssueRequest{}
+	msg := ""
+	issuer, err := getPortIssuer(port)
+	if err != nil {
+		msg = err.Error()
+		w.WriteHeader(http.StatusInternalServerError)
+	} else if err := util.ReadJsonPayload(r, ir); err != nil {
+		msg = fmt.Sprintf("Failed to parse token request with error: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+	} else if ttl, err := parseTTL(ir.TTL); err != nil {
+		msg = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+	} else if token, _, err := issuer.issue(ir.Subject, ir.Audience, ttl, ir.Claims); err != nil {
+		msg = fmt.Sprintf("Failed to issue token with error: %s", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		util.AddLogMessage(fmt.Sprintf("Port [%d] issued token for subject [%s]", port, ir.Subject), r)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, token)
+		return
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
+
+func addPolicy(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	policy := &AuthPolicy{}
+	msg := ""
+	if err := util.ReadJsonPayload(r, policy); err != nil {
+		msg = fmt.Sprintf("Failed to parse auth policy with error: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+	} else if err := policy.init(); err != nil {
+		msg = fmt.Sprintf("Invalid auth policy: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		getPortPolicies(port, true).addPolicy(policy)
+		msg = fmt.Sprintf("Port [%d] added [%s] auth policy [%s]", port, policy.Type, policy.Name)
+		events.SendRequestEventJSON("Auth Policy Added", policy.Name, policy, r)
+		w.WriteHeader(http.StatusOK)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
+
+func removePolicy(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	name := util.GetStringParamValue(r, "name")
+	msg := ""
+	if pp := getPortPolicies(port, false); pp != nil && pp.removePolicy(name) {
+		msg = fmt.Sprintf("Port [%d] removed auth policy [%s]", port, name)
+		events.SendRequestEvent("Auth Policy Removed", msg, r)
+		w.WriteHeader(http.StatusOK)
+	} else {
+		msg = fmt.Sprintf("Port [%d] has no auth policy [%s]", port, name)
+		w.WriteHeader(http.StatusNotFound)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
+
+func clearPolicies(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	policiesLock.Lock()
+	delete(portPolicies, port)
+	policiesLock.Unlock()
+	msg := fmt.Sprintf("Port [%d] auth policies cleared", port)
+	events.SendRequestEvent("Auth Policies Cleared", msg, r)
+	util.AddLogMessage(msg, r)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, msg)
+}
+
+func clearCounts(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	if pp := getPortPolicies(port, false); pp != nil {
+		pp.clearCounts()
+	}
+	msg := fmt.Sprintf("Port [%d] auth counts cleared", port)
+	util.AddLogMessage(msg, r)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, msg)
+}
+
+func getAuth(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	result := map[string]any{"port": port}
+	issuersLock.RLock()
+	if issuer := portIssuers[port]; issuer != nil {
+		result["issuer"] = issuer.snapshot()
+	}
+	issuersLock.RUnlock()
+	if pp := getPortPolicies(port, false); pp != nil {
+		result["policies"] = pp.snapshot()
+	} else {
+		result["policies"] = []*AuthPolicy{}
+	}
+	util.WriteJsonPayload(w, result)
+	util.AddLogMessage(fmt.Sprintf("Port [%d] reporting auth", port), r)
+}
@@ -0,0 +1,363 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"goto/pkg/constants"
+	"goto/pkg/server/response/status"
+	"goto/pkg/util"
+
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	AuthJWT    = "jwt"
+	AuthBasic  = "basic"
+	AuthAPIKey = "apikey"
+
+	defaultRealm        = "goto"
+	defaultAPIKeyHeader = "X-API-Key"
+)
+
+type AuthCounts struct {
+	Allowed      int `json:"allowed"`
+	Unauthorized int `json:"unauthorized"`
+	Forbidden    int `json:"forbidden"`
+}
+
+// AuthPolicy enforces one kind of credentials on the requests it matches.
+type AuthPolicy struct {
+	Name       string              `json:"name"`
+	Match      *status.StatusMatch `json:"match"`
+	Type       string              `json:"type"`
+	Realm      string              `json:"realm"`
+	IssuerPort int                 `json:"issuerPort,omitempty"`
+	Issuer     string              `json:"issuer,omitempty"`
+	Audience   []string            `json:"audience,omitempty"`
+	Claims     map[string]string   `json:"claims,omitempty"`
+	Users      map[string]string   `json:"users,omitempty"`
+	Header     string              `json:"header,omitempty"`
+	Query      string              `json:"query,omitempty"`
+	Keys       []string            `json:"keys,omitempty"`
+	Counts     *AuthCounts         `json:"counts"`
+	lock       sync.Mutex
+}
+
+type PortPolicies struct {
+	Port     int           `json:"port"`
+	Policies []*AuthPolicy `json:"policies"`
+	lock     sync.RWMutex
+}
+
+// rejection is the outcome of a failed auth check, with the challenge to send back.
+type rejection struct {
+	status      int
+	error       string
+	description string
+}
+
+var (
+	portPolicies = map[int]*PortPolicies{}
+	policiesLock sync.RWMutex
+)
+
+func getPortPolicies(port int, create bool) *PortPolicies {
+	if !create {
+		policiesLock.RLock()
+		defer policiesLock.RUnlock()
+		return portPolicies[port]
+	}
+	policiesLock.Lock()
+	defer policiesLock.Unlock()
+	if portPolicies[port] == nil {
+		portPolicies[port] = &PortPolicies{Port: port, Policies: []*AuthPolicy{}}
+	}
+	return portPolicies[port]
+}
+
+func (p *AuthPolicy) init() error {
+	if p.Name == "" {
+		return errors.New("auth policy needs a name")
+	}
+	switch p.Type {
+	case AuthJWT:
+	case AuthBasic:
+		if len(p.Users) == 0 {
+			return errors.New("basic auth policy needs users")
+		}
+	case AuthAPIKey:
+		if len(p.Keys) == 0 {
+			return errors.New("apikey auth policy needs keys")
+		}
+		if p.Header == "" && p.Query == "" {
+			p.Header = defaultAPIKeyHeader
+		}
+	default:
+		return fmt.Errorf("invalid auth type [%s]", p.Type)
+	}
+	if p.Realm == "" {
+		p.Realm = defaultRealm
+	}
+	if p.Match == nil {
+		p.Match = &status.StatusMatch{}
+	}
+	if err := p.Match.Prepare(); err != nil {
+		return err
+	}
+	p.Counts = &AuthCounts{}
+	return nil
+}
+
+func (p *AuthPolicy) challenge(rej *rejection) string {
+	switch p.Type {
+	case AuthBasic:
+		return fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", p.Realm)
+	case AuthAPIKey:
+		if p.Header != "" {
+			return fmt.Sprintf("APIKey realm=%q, header=%q", p.Realm, p.Header)
+		}
+		return fmt.Sprintf("APIKey realm=%q, query=%q", p.Realm, p.Query)
+	}
+	if rej.error == "" {
+		return fmt.Sprintf("Bearer realm=%q", p.Realm)
+	}
+	return fmt.Sprintf("Bearer realm=%q, error=%q, error_description=%q", p.Realm, rej.error, rej.description)
+}
+
+// claimHas tells whether a claim value holds the expected value, either as the whole value,
+// as one of the space separated values of a string (e.g. scope), or as an element of an array.
+func claimHas(value any, expected string) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v == expected || slices.Contains(strings.Fields(v), expected)
+	case []any:
+		for _, e := range v {
+			if fmt.Sprint(e) == expected {
+				return true
+			}
+		}
+		return false
+	default:
+		return fmt.Sprint(v) == expected
+	}
+}
+
+func (p *AuthPolicy) checkJWT(r *http.Request, port int) (string, *rejection) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || strings.TrimSpace(token) == "" {
+		return "", &rejection{status: http.StatusUnauthorized, description: "missing bearer token"}
+	}
+	if p.IssuerPort > 0 {
+		port = p.IssuerPort
+	}
+	issuer, err := getPortIssuer(port)
+	if err != nil {
+		return "", &rejection{status: http.StatusUnauthorized, error: "invalid_token", description: err.Error()}
+	}
+	claims, registered, err := issuer.verify(strings.TrimSpace(token))
+	if err != nil {
+		return "", &rejection{status: http.StatusUnauthorized, error: "invalid_token", description: err.Error()}
+	}
+	expectedIssuer := p.Issuer
+	if expectedIssuer == "" {
+		expectedIssuer = issuer.Issuer
+	}
+	if err := registered.ValidateWithLeeway(jwt.Expected{Issuer: expectedIssuer, AnyAudience: p.Audience, Time: time.Now()}, 0); err != nil {
+		description := "invalid claims"
+		switch {
+		case errors.Is(err, jwt.ErrExpired):
+			description = "token expired"
+		case errors.Is(err, jwt.ErrNotValidYet), errors.Is(err, jwt.ErrIssuedInTheFuture):
+			description = "token not valid yet"
+		case errors.Is(err, jwt.ErrInvalidIssuer):
+			description = "wrong issuer"
+		case errors.Is(err, jwt.ErrInvalidAudience):
+			description = "wrong audience"
+		}
+		return registered.Subject, &rejection{status: http.StatusUnauthorized, error: "invalid_token", description: description}
+	}
+	for claim, expected := range p.Claims {
+		if !claimHas(claims[claim], expected) {
+			return registered.Subject, &rejection{status: http.StatusForbidden, error: "insufficient_scope",
+				description: fmt.Sprintf("claim [%s] doesn't have [%s]", claim, expected)}
+		}
+	}
+	return registered.Subject, nil
+}
+
+func (p *AuthPolicy) checkBasic(r *http.Request) (string, *rejection) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return "", &rejection{status: http.StatusUnauthorized, description: "missing basic credentials"}
+	}
+	if expected, found := p.Users[user]; !found || expected != password {
+		return user, &rejection{status: http.StatusUnauthorized, error: "invalid_credentials", description: "wrong user or password"}
+	}
+	return user, nil
+}
+
+func (p *AuthPolicy) checkAPIKey(r *http.Request) (string, *rejection) {
+	key := ""
+	if p.Header != "" {
+		key = r.Header.Get(p.Header)
+	}
+	if key == "" && p.Query != "" {
+		key = r.URL.Query().Get(p.Query)
+	}
+	if key == "" {
+		return "", &rejection{status: http.StatusUnauthorized, description: "missing api key"}
+	}
+	if !slices.Contains(p.Keys, key) {
+		return "", &rejection{status: http.StatusForbidden, error: "invalid_key", description: "unknown api key"}
+	}
+	return "", nil
+}
+
+func (p *AuthPolicy) check(r *http.Request, port int) (subject string, rej *rejection) {
+	switch p.Type {
+	case AuthJWT:
+		subject, rej = p.checkJWT(r, port)
+	case AuthBasic:
+		subject, rej = p.checkBasic(r)
+	case AuthAPIKey:
+		subject, rej = p.checkAPIKey(r)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	switch {
+	case rej == nil:
+		p.Counts.Allowed++
+	case rej.status == http.StatusForbidden:
+		p.Counts.Forbidden++
+	default:
+		p.Counts.Unauthorized++
+	}
+	return
+}
+
+func (pp *PortPolicies) addPolicy(policy *AuthPolicy) {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+	for i, p := range pp.Policies {
+		if p.Name == policy.Name {
+			pp.Policies[i] = policy
+			return
+		}
+	}
+	pp.Policies = append(pp.Policies, policy)
+}
+
+func (pp *PortPolicies) removePolicy(name string) bool {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+	for i, p := range pp.Policies {
+		if p.Name == name {
+			pp.Policies = append(pp.Policies[:i], pp.Policies[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (pp *PortPolicies) clearCounts() {
+	pp.lock.RLock()
+	defer pp.lock.RUnlock()
+	for _, p := range pp.Policies {
+		p.lock.Lock()
+		p.Counts = &AuthCounts{}
+		p.lock.Unlock()
+	}
+}
+
+func (pp *PortPolicies) snapshot() []*AuthPolicy {
+	pp.lock.RLock()
+	defer pp.lock.RUnlock()
+	policies := []*AuthPolicy{}
+	for _, p := range pp.Policies {
+		p.lock.Lock()
+		counts := *p.Counts
+		policies = append(policies, &AuthPolicy{Name: p.Name, Match: p.Match, Type: p.Type, Realm: p.Realm, IssuerPort: p.IssuerPort,
+			Issuer: p.Issuer, Audience: p.Audience, Claims: p.Claims, Users: p.Users, Header: p.Header, Query: p.Query, Keys: p.Keys, Counts: &counts})
+		p.lock.Unlock()
+	}
+	return policies
+}
+
+func (pp *PortPolicies) match(r *http.Request) *AuthPolicy {
+	pp.lock.RLock()
+	defer pp.lock.RUnlock()
+	for _, p := range pp.Policies {
+		if p.Match.Matches(r.RequestURI, r.Header, r.Method) {
+			return p
+		}
+	}
+	return nil
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		port := util.GetRequestOrListenerPortNum(r)
+		var policy *AuthPolicy
+		if !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest {
+			if pp := getPortPolicies(port, false); pp != nil {
+				policy = pp.match(r)
+			}
+		}
+		if policy == nil {
+			if next != nil {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		subject, rej := policy.check(r, port)
+		if rej == nil {
+			w.Header().Set(constants.HeaderGotoAuth, policy.Name+":allowed")
+			util.AddLogMessage(fmt.Sprintf("Auth policy [%s] allowed [%s] for URI [%s]", policy.Name, subject, r.RequestURI), r)
+			if next != nil {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		msg := fmt.Sprintf("Auth policy [%s] rejected [%s] with [%d] for URI [%s]: %s", policy.Name, subject, rej.status, r.RequestURI, rej.description)
+		util.AddLogMessage(msg, r)
+		util.UpdateTrafficEventDetails(r, msg)
+		result := "unauthorized"
+		if rej.status == http.StatusForbidden {
+			result = "forbidden"
+		}
+		w.Header().Set(constants.HeaderGotoAuth, policy.Name+":"+result)
+		w.Header().Set("WWW-Authenticate", policy.challenge(rej))
+		w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSON)
+		w.WriteHeader(rej.status)
+		errorCode := rej.error
+		if errorCode == "" {
+			errorCode = result
+		}
+		util.WriteJson(w, map[string]string{"error": errorCode, "description": rej.description, "policy": policy.Name})
+	})
+}
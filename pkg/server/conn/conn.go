@@ -39,10 +39,6 @@ var (
 )
 
 func captureTLSInfo(r *http.Request) {
-	var conn net.Conn
-	if conn = util.GetConn(r); conn == nil {
-		return
-	}
 	rs := util.GetRequestStore(r)
 	if rs == nil {
 		return
@@ -51,16 +47,23 @@ func captureTLSInfo(r *http.Request) {
 	if l == nil || !l.TLS {
 		return
 	}
-	tlsConn, ok := conn.(*tls.Conn)
-	if !ok {
-		if wc, ok2 := conn.(*tcp.WrappedConn); ok2 {
-			tlsConn, ok = wc.Conn.(*tls.Conn)
+	var tlsState tls.ConnectionState
+	if conn := util.GetConn(r); conn != nil {
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok {
+			if wc, ok2 := conn.(*tcp.WrappedConn); ok2 {
+				tlsConn, ok = wc.Conn.(*tls.Conn)
+			}
 		}
-	}
-	if !ok {
+		if !ok {
+			return
+		}
+		tlsState = tlsConn.ConnectionState()
+	} else if l.IsHTTP3 && r.TLS != nil {
+		tlsState = *r.TLS
+	} else {
 		return
 	}
-	tlsState := tlsConn.ConnectionState()
 	if !tlsState.HandshakeComplete {
 		return
 	}
@@ -79,6 +82,11 @@ func middlewareFunc(next http.Handler) http.Handler {
 		if conn := util.GetConn(r); conn != nil {
 			captureTLSInfo(r)
 			localAddr = conn.LocalAddr().String()
+		} else if l.IsHTTP3 {
+			captureTLSInfo(r)
+			if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+				localAddr = addr.String()
+			}
 		} else {
 			localAddr = l.Listener.Addr().String()
 		}
@@ -91,7 +99,11 @@ func middlewareFunc(next http.Handler) http.Handler {
 		if p > 0 {
 			port = strconv.Itoa(p)
 		}
-		rs.GotoProtocol = util.GotoProtocol(r.ProtoMajor == 2, rs.IsTLS, rs.IsGRPC)
+		if r.ProtoMajor == 3 {
+			rs.GotoProtocol = "HTTP/3"
+		} else {
+			rs.GotoProtocol = util.GotoProtocol(r.ProtoMajor == 2, rs.IsTLS, rs.IsGRPC)
+		}
 		rs.HostLabel = l.HostLabel
 		rs.ListenerLabel = l.Label
 		if util.IsTunnelRequest(r) {
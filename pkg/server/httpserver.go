@@ -28,10 +28,12 @@ import (
 	"goto/pkg/registry/peer"
 	"goto/pkg/router"
 	grpcserver "goto/pkg/rpc/grpc/server"
+	"goto/pkg/server/auth"
 	"goto/pkg/server/conn"
 	"goto/pkg/server/intercept"
 	"goto/pkg/server/listeners"
 	"goto/pkg/server/middleware"
+	"goto/pkg/server/request/recorder"
 	"goto/pkg/server/startup"
 	"goto/pkg/tunnel"
 	"goto/pkg/util"
@@ -92,7 +94,9 @@ func configureHTTPRouter() {
 
 	adminRouter := coreRouter.PathPrefix("").Subrouter()
 	adminRouter.Use(intercept.IntereceptMiddleware(preIntercept(), postIntercept()))
+	adminRouter.Use(recorder.Middleware.MiddlewareHandler)
 	middleware.SetRoutesOnly(adminRouter)
+	middleware.AddRoutes(middleware.RootPath("/server"), auth.Middleware)
 
 	RootRouter = util.CreateRouters(coreRouter)
 	middleware.LinkCore(RootRouter)
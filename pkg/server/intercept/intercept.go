@@ -49,6 +49,7 @@ type InterceptResponseWriter struct {
 	IsH2C       bool
 	Proceeded   bool
 	BodyLength  int
+	Tee         io.Writer
 }
 
 type HeaderInterceptResponseWriter struct {
@@ -161,6 +162,9 @@ func (rw *InterceptResponseWriter) Write(b []byte) (int, error) {
 	// util.PrintCallers(3, "InterceptResponseWriter.Write")
 	l := len(b)
 	rw.BodyLength += l
+	if rw.Tee != nil {
+		rw.Tee.Write(b)
+	}
 	if !rw.Hijacked {
 		if !rw.Hold || rw.Chunked && !rw.HoldChunked {
 			if len(rw.Data) > 0 {
@@ -234,6 +238,9 @@ func (rw *InterceptResponseWriter) Proceed() {
 			}
 		}
 	}
+	if c, ok := rw.ResponseWriter.(io.Closer); ok {
+		c.Close()
+	}
 }
 
 func NewInterceptResponseWriter(r *http.Request, w http.ResponseWriter, hold bool) *InterceptResponseWriter {
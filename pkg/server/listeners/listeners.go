@@ -35,6 +35,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
 	"golang.org/x/net/http2"
 	"google.golang.org/grpc"
 )
@@ -46,6 +48,7 @@ const (
 	PROTOL_STRICT_HTTPS = "httpss"
 	PROTOL_H2           = "h2"
 	PROTOL_H2C          = "h2c"
+	PROTOL_H3           = "h3"
 	PROTO_GRPC          = "GRPC"
 	PROTOL_GRPC         = "grpc"
 	PROTOL_GRPC_SECURE  = "grpcs"
@@ -85,6 +88,7 @@ type Listener struct {
 	TCP              *tcp.TCPConfig                   `json:"tcp,omitempty"`
 	IsHTTP           bool                             `json:"isHTTP"`
 	IsHTTP2          bool                             `json:"isH2"`
+	IsHTTP3          bool                             `json:"isH3"`
 	IsGRPC           bool                             `json:"isGRPC"`
 	IsJSONRPC        bool                             `json:"isJSONRPC"`
 	IsTCP            bool                             `json:"isTCP"`
@@ -102,6 +106,7 @@ type Listener struct {
 	ForwardListener  *Listener                        `json:"-"`
 	Listener         net.Listener                     `json:"-"`
 	UDPConn          *net.UDPConn                     `json:"-"`
+	H3Server         *http3.Server                    `json:"-"`
 	Restarted        bool                             `json:"-"`
 	peerCertInfos    map[string]*gototls.PeerCertInfo `json:"-"`
 	lock             sync.RWMutex                     `json:"-"`
@@ -289,6 +294,7 @@ func (l *Listener) assignProtocol() {
 	isJSONRPCS := strings.EqualFold(l.Protocol, "jsonrpcs") || strings.EqualFold(l.Protocol, "rpcs")
 	isUDP := strings.EqualFold(l.Protocol, PROTOL_UDP)
 	isTCPS := strings.EqualFold(l.Protocol, PROTOL_TLS)
+	isH3 := strings.EqualFold(l.Protocol, PROTOL_H3) || strings.EqualFold(l.Protocol, "http3")
 
 	if isHTTP1 || isHTTPS1 {
 		if isHTTPS1 {
@@ -311,6 +317,12 @@ func (l *Listener) assignProtocol() {
 		} else {
 			l.L8Proto = PROTOL_H2C
 		}
+	} else if isH3 {
+		l.L8Proto = PROTOL_H3
+		l.Protocol = PROTOL_H3
+		l.TLS = true
+		l.IsHTTP = true
+		l.IsHTTP3 = true
 	} else if isGRPC || isGRPCS {
 		if isGRPCS {
 			l.TLS = true
@@ -499,6 +511,26 @@ func (l *Listener) serveHTTP() {
 	}()
 }
 
+func (l *Listener) serveHTTP3() {
+	l.H3Server = &http3.Server{
+		Handler:     httpServer.Handler,
+		TLSConfig:   l.TLSConfig,
+		IdleTimeout: httpServer.IdleTimeout,
+		ConnContext: func(ctx context.Context, c *quic.Conn) context.Context {
+			ctx = context.WithValue(ctx, util.RemoteAddrKey, c.RemoteAddr().String())
+			return util.WithPort(ctx, l.Port)
+		},
+	}
+	server := l.H3Server
+	udpConn := l.UDPConn
+	go func() {
+		log.Printf("Starting HTTP/3 Listener [%s] With TLS [CN: %s]\n", l.ListenerID, l.CommonName)
+		if err := server.Serve(udpConn); err != nil {
+			log.Printf("HTTP/3 Listener [%d]: %s", l.Port, err.Error())
+		}
+	}()
+}
+
 func (l *Listener) serveGRPC() {
 	if grpcStarted {
 		go func() {
@@ -651,9 +683,17 @@ func (l *Listener) InitListener() bool {
 		}
 	}
 	address := fmt.Sprintf("0.0.0.0:%d", l.Port)
-	if l.IsUDP {
+	if l.IsUDP || l.IsHTTP3 {
 		if udpAddr, err := net.ResolveUDPAddr("udp4", address); err == nil {
 			if udpConn, err := net.ListenUDP("udp", udpAddr); err == nil {
+				if l.IsHTTP3 {
+					if tlsConfig := l.prepareTLS(); tlsConfig == nil {
+						udpConn.Close()
+						return false
+					} else {
+						l.TLSConfig = http3.ConfigureTLSConfig(tlsConfig)
+					}
+				}
 				l.UDPConn = udpConn
 				return true
 			} else {
@@ -694,6 +734,8 @@ func (l *Listener) openListener(serve bool) bool {
 		if serve {
 			if l.IsJSONRPC {
 				l.serveJSONRPC()
+			} else if l.IsHTTP3 {
+				l.serveHTTP3()
 			} else if l.IsHTTP {
 				l.serveHTTP()
 			} else if l.IsGRPC {
@@ -718,6 +760,10 @@ func (l *Listener) Close() {
 		global.Funcs.CloseConnectionsForPort(l.Port)
 		l.Listener = nil
 	}
+	if l.H3Server != nil {
+		l.H3Server.Close()
+		l.H3Server = nil
+	}
 	if l.UDPConn != nil {
 		l.UDPConn.Close()
 		l.UDPConn = nil
@@ -258,7 +258,7 @@ func getListeners(w http.ResponseWriter, r *http.Request) {
 func openListener(w http.ResponseWriter, r *http.Request) {
 	if l := validateListener(w, r); l != nil {
 		msg := ""
-		if l.Listener == nil {
+		if l.Listener == nil && l.UDPConn == nil {
 			if l.openListener(true) {
 				if l.TLS {
 					msg = fmt.Sprintf("TLS Listener opened on port %d\n", l.Port)
@@ -289,7 +289,7 @@ func openListener(w http.ResponseWriter, r *http.Request) {
 func closeListener(w http.ResponseWriter, r *http.Request) {
 	if l := validateListener(w, r); l != nil {
 		msg := ""
-		if l.Listener == nil {
+		if l.Listener == nil && l.UDPConn == nil {
 			w.WriteHeader(http.StatusBadRequest)
 			msg = fmt.Sprintf("Port %d not open\n", l.Port)
 		} else {
@@ -309,6 +309,14 @@ func removeListener(w http.ResponseWriter, r *http.Request) {
 			l.Listener.Close()
 			l.Listener = nil
 		}
+		if l.H3Server != nil {
+			l.H3Server.Close()
+			l.H3Server = nil
+		}
+		if l.UDPConn != nil {
+			l.UDPConn.Close()
+			l.UDPConn = nil
+		}
 		l.lock.Unlock()
 		RemoveListener(l)
 		LinkForwardListeners()
@@ -0,0 +1,231 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recorder
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"goto/pkg/server/intercept"
+	"goto/pkg/util"
+)
+
+type RecordedExchange struct {
+	At              time.Time           `json:"at"`
+	Method          string              `json:"method"`
+	URL             string              `json:"url"`
+	Host            string              `json:"host"`
+	URI             string              `json:"uri"`
+	Protocol        string              `json:"protocol"`
+	RequestHeaders  map[string][]string `json:"requestHeaders"`
+	RequestBody     string              `json:"requestBody"`
+	StatusCode      int                 `json:"statusCode"`
+	ResponseHeaders map[string][]string `json:"responseHeaders"`
+	ResponseBody    string              `json:"responseBody"`
+	Truncated       bool                `json:"truncated,omitempty"`
+	TookNanos       time.Duration       `json:"tookNanos"`
+}
+
+type Recording struct {
+	Port        int                 `json:"port"`
+	Active      bool                `json:"active"`
+	StartedAt   time.Time           `json:"startedAt"`
+	StoppedAt   time.Time           `json:"stoppedAt"`
+	MaxEntries  int                 `json:"maxEntries"`
+	MaxBodySize int                 `json:"maxBodySize"`
+	Dropped     int                 `json:"dropped"`
+	Exchanges   []*RecordedExchange `json:"exchanges"`
+	lock        sync.RWMutex
+}
+
+type boundedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+const (
+	DefaultMaxEntries  = 1000
+	DefaultMaxBodySize = 64 * 1024
+)
+
+var (
+	recordings = map[int]*Recording{}
+	lock       sync.RWMutex
+)
+
+func getRecording(port int, create bool) *Recording {
+	lock.Lock()
+	defer lock.Unlock()
+	if recordings[port] == nil && create {
+		recordings[port] = &Recording{Port: port, MaxEntries: DefaultMaxEntries, MaxBodySize: DefaultMaxBodySize}
+	}
+	return recordings[port]
+}
+
+func activeRecording(port int) *Recording {
+	lock.RLock()
+	rec := recordings[port]
+	lock.RUnlock()
+	if rec == nil {
+		return nil
+	}
+	rec.lock.RLock()
+	defer rec.lock.RUnlock()
+	if !rec.Active {
+		return nil
+	}
+	return rec
+}
+
+func (rec *Recording) start(maxEntries int) {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	if maxEntries > 0 {
+		rec.MaxEntries = maxEntries
+	}
+	if !rec.Active {
+		rec.Active = true
+		rec.StartedAt = time.Now()
+		rec.StoppedAt = time.Time{}
+	}
+}
+
+func (rec *Recording) stop() {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	if rec.Active {
+		rec.Active = false
+		rec.StoppedAt = time.Now()
+	}
+}
+
+func (rec *Recording) clear() {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	rec.Exchanges = nil
+	rec.Dropped = 0
+}
+
+func (rec *Recording) add(exchange *RecordedExchange) {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	if len(rec.Exchanges) >= rec.MaxEntries {
+		rec.Dropped++
+		return
+	}
+	rec.Exchanges = append(rec.Exchanges, exchange)
+}
+
+func (rec *Recording) snapshot() *Recording {
+	rec.lock.RLock()
+	defer rec.lock.RUnlock()
+	return &Recording{
+		Port:        rec.Port,
+		Active:      rec.Active,
+		StartedAt:   rec.StartedAt,
+		StoppedAt:   rec.StoppedAt,
+		MaxEntries:  rec.MaxEntries,
+		MaxBodySize: rec.MaxBodySize,
+		Dropped:     rec.Dropped,
+		Exchanges:   append([]*RecordedExchange{}, rec.Exchanges...),
+	}
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.max - b.Len(); remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.Buffer.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// readRequestBody captures up to max bytes of the request body. The captured prefix is stitched back in front of
+// the unread remainder so that the handlers still stream the full body.
+func readRequestBody(r *http.Request, max int) (string, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return "", false
+	}
+	body, _ := io.ReadAll(io.LimitReader(r.Body, int64(max)+1))
+	if rr, ok := r.Body.(util.IReReader); ok {
+		rr.Rewind()
+	} else {
+		r.Body = &prefixedBody{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+	}
+	if len(body) > max {
+		return string(body[:max]), true
+	}
+	return string(body), false
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		var rec *Recording
+		if !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest {
+			rec = activeRecording(util.GetRequestOrListenerPortNum(r))
+		}
+		irw := intercept.GetInterceptWriter(r)
+		if rec == nil || irw == nil {
+			if next != nil {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		start := time.Now()
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		exchange := &RecordedExchange{
+			At:             start,
+			Method:         r.Method,
+			URL:            scheme + "://" + r.Host + r.RequestURI,
+			Host:           r.Host,
+			URI:            r.RequestURI,
+			Protocol:       r.Proto,
+			RequestHeaders: r.Header.Clone(),
+		}
+		exchange.RequestBody, exchange.Truncated = readRequestBody(r, rec.MaxBodySize)
+		responseBody := &boundedBuffer{max: rec.MaxBodySize}
+		irw.Tee = responseBody
+		if next != nil {
+			next.ServeHTTP(w, r)
+		}
+		irw.Tee = nil
+		exchange.TookNanos = time.Since(start)
+		exchange.StatusCode = irw.StatusCode
+		if exchange.StatusCode == 0 {
+			exchange.StatusCode = http.StatusOK
+		}
+		exchange.ResponseHeaders = w.Header().Clone()
+		exchange.ResponseBody = responseBody.String()
+		exchange.Truncated = exchange.Truncated || responseBody.truncated
+		rec.add(exchange)
+	})
+}
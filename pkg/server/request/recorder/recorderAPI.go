@@ -0,0 +1,238 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recorder
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"goto/pkg/events"
+	"goto/pkg/global"
+	"goto/pkg/server/middleware"
+	"goto/pkg/util"
+
+	"github.com/gorilla/mux"
+)
+
+type HAR struct {
+	Log *HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string            `json:"version"`
+	Creator map[string]string `json:"creator"`
+	Entries []*HAREntry       `json:"entries"`
+}
+
+type HAREntry struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	Time            float64        `json:"time"`
+	Request         *HARRequest    `json:"request"`
+	Response        *HARResponse   `json:"response"`
+	Cache           map[string]any `json:"cache"`
+	Timings         map[string]any `json:"timings"`
+}
+
+type HARRequest struct {
+	Method      string        `json:"method"`
+	URL         string        `json:"url"`
+	HTTPVersion string        `json:"httpVersion"`
+	Cookies     []*HARNameVal `json:"cookies"`
+	Headers     []*HARNameVal `json:"headers"`
+	QueryString []*HARNameVal `json:"queryString"`
+	PostData    *HARPostData  `json:"postData,omitempty"`
+	HeadersSize int           `json:"headersSize"`
+	BodySize    int           `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int           `json:"status"`
+	StatusText  string        `json:"statusText"`
+	HTTPVersion string        `json:"httpVersion"`
+	Cookies     []*HARNameVal `json:"cookies"`
+	Headers     []*HARNameVal `json:"headers"`
+	Content     *HARContent   `json:"content"`
+	RedirectURL string        `json:"redirectURL"`
+	HeadersSize int           `json:"headersSize"`
+	BodySize    int           `json:"bodySize"`
+}
+
+type HARNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+var (
+	Middleware = middleware.NewMiddleware("record", setRoutes, middlewareFunc)
+)
+
+func setRoutes(r *mux.Router) {
+	recordRouter := util.PathRouter(r, "/record")
+	util.AddRoute(recordRouter, "/start", startRecording, "POST", "PUT")
+	util.AddRoute(recordRouter, "/start/max={max}", startRecording, "POST", "PUT")
+	util.AddRoute(recordRouter, "/stop", stopRecording, "POST", "PUT")
+	util.AddRoute(recordRouter, "/clear", clearRecording, "POST")
+	util.AddRoute(recordRouter, "/har", getHAR, "GET")
+	util.AddRoute(recordRouter, "", getRecordingAPI, "GET")
+}
+
+func startRecording(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	max := util.GetIntParamValue(r, "max")
+	rec := getRecording(port, true)
+	rec.start(max)
+	msg := fmt.Sprintf("Port [%d] recording started with max [%d] entries", port, rec.MaxEntries)
+	events.SendRequestEvent("Recording Started", msg, r)
+	util.AddLogMessage(msg, r)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, msg)
+}
+
+func stopRecording(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	msg := ""
+	if rec := getRecording(port, false); rec != nil {
+		rec.stop()
+		msg = fmt.Sprintf("Port [%d] recording stopped with [%d] entries", port, len(rec.snapshot().Exchanges))
+		events.SendRequestEvent("Recording Stopped", msg, r)
+		w.WriteHeader(http.StatusOK)
+	} else {
+		msg = fmt.Sprintf("Port [%d] has no recording", port)
+		w.WriteHeader(http.StatusNotFound)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
+
+func clearRecording(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	if rec := getRecording(port, false); rec != nil {
+		rec.clear()
+	}
+	msg := fmt.Sprintf("Port [%d] recording cleared", port)
+	events.SendRequestEvent("Recording Cleared", msg, r)
+	util.AddLogMessage(msg, r)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, msg)
+}
+
+func getRecordingAPI(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	rec := getRecording(port, false)
+	if rec == nil {
+		rec = &Recording{Port: port, Exchanges: []*RecordedExchange{}}
+	}
+	util.AddLogMessage(fmt.Sprintf("Port [%d] reporting recording", port), r)
+	util.WriteJsonPayload(w, rec.snapshot())
+}
+
+func getHAR(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	rec := getRecording(port, false)
+	if rec == nil {
+		rec = &Recording{Port: port}
+	}
+	util.AddLogMessage(fmt.Sprintf("Port [%d] reporting recording as HAR", port), r)
+	util.WriteJsonPayload(w, rec.snapshot().ToHAR())
+}
+
+// ToHAR converts the recording to HAR 1.2 format. Timings only report the server-side time taken
+// to produce the response, reported as `wait`.
+func (rec *Recording) ToHAR() *HAR {
+	har := &HAR{Log: &HARLog{
+		Version: "1.2",
+		Creator: map[string]string{"name": "goto", "version": global.Version},
+		Entries: []*HAREntry{},
+	}}
+	for _, e := range rec.Exchanges {
+		took := float64(e.TookNanos.Microseconds()) / 1000
+		entry := &HAREntry{
+			StartedDateTime: e.At.Format("2006-01-02T15:04:05.000Z07:00"),
+			Time:            took,
+			Request: &HARRequest{
+				Method:      e.Method,
+				URL:         e.URL,
+				HTTPVersion: e.Protocol,
+				Cookies:     []*HARNameVal{},
+				Headers:     toHARHeaders(e.RequestHeaders),
+				QueryString: []*HARNameVal{},
+				HeadersSize: -1,
+				BodySize:    len(e.RequestBody),
+			},
+			Response: &HARResponse{
+				Status:      e.StatusCode,
+				StatusText:  http.StatusText(e.StatusCode),
+				HTTPVersion: e.Protocol,
+				Cookies:     []*HARNameVal{},
+				Headers:     toHARHeaders(e.ResponseHeaders),
+				Content: &HARContent{
+					Size:     len(e.ResponseBody),
+					MimeType: headerValue(e.ResponseHeaders, "Content-Type"),
+					Text:     e.ResponseBody,
+				},
+				HeadersSize: -1,
+				BodySize:    len(e.ResponseBody),
+			},
+			Cache:   map[string]any{},
+			Timings: map[string]any{"send": 0, "wait": took, "receive": 0},
+		}
+		if u, err := url.Parse(e.URL); err == nil {
+			for k, values := range u.Query() {
+				for _, v := range values {
+					entry.Request.QueryString = append(entry.Request.QueryString, &HARNameVal{Name: k, Value: v})
+				}
+			}
+		}
+		if e.RequestBody != "" {
+			entry.Request.PostData = &HARPostData{MimeType: headerValue(e.RequestHeaders, "Content-Type"), Text: e.RequestBody}
+		}
+		har.Log.Entries = append(har.Log.Entries, entry)
+	}
+	return har
+}
+
+func toHARHeaders(headers map[string][]string) []*HARNameVal {
+	harHeaders := []*HARNameVal{}
+	for h, values := range headers {
+		for _, v := range values {
+			harHeaders = append(harHeaders, &HARNameVal{Name: h, Value: v})
+		}
+	}
+	return harHeaders
+}
+
+func headerValue(headers map[string][]string, header string) string {
+	for h, values := range headers {
+		if strings.EqualFold(h, header) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
@@ -21,9 +21,11 @@ import (
 
 	"goto/pkg/server/middleware"
 	"goto/pkg/server/request/filter"
+	"goto/pkg/server/request/recorder"
 	"goto/pkg/server/request/timeout"
 	"goto/pkg/server/request/tracking"
 	"goto/pkg/server/request/uri"
+	"goto/pkg/server/request/validation"
 	"goto/pkg/util"
 
 	"github.com/gorilla/mux"
@@ -31,8 +33,8 @@ import (
 
 var (
 	Middleware         = middleware.NewMiddleware("request", setRoutes, middlewareFunc)
-	requestMiddlewares = []*middleware.Middleware{timeout.Middleware, filter.Middleware}
-	CoreMiddlewares    = []*middleware.Middleware{tracking.Middleware, uri.Middleware}
+	requestMiddlewares = []*middleware.Middleware{timeout.Middleware, filter.Middleware, validation.Middleware}
+	CoreMiddlewares    = []*middleware.Middleware{recorder.Middleware, tracking.Middleware, uri.Middleware}
 )
 
 func setRoutes(r *mux.Router) {
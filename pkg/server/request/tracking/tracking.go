@@ -32,11 +32,17 @@ type HeaderData struct {
 	lock                       sync.RWMutex
 }
 
+type BodyValidationCounts struct {
+	Valid   int `json:"valid"`
+	Invalid int `json:"invalid"`
+}
+
 type TrackingData struct {
-	ByHeader       map[string]*HeaderData            `json:"byHeader"`
-	ByURI          map[string]int                    `json:"byURI"`
-	ByURIAndHeader map[string]map[string]*HeaderData `json:"byURIAndHeader"`
-	lock           sync.RWMutex
+	ByHeader         map[string]*HeaderData            `json:"byHeader"`
+	ByURI            map[string]int                    `json:"byURI"`
+	ByURIAndHeader   map[string]map[string]*HeaderData `json:"byURIAndHeader"`
+	ByBodyValidation map[string]*BodyValidationCounts  `json:"byBodyValidation"`
+	lock             sync.RWMutex
 }
 
 type RequestTracking struct {
@@ -141,6 +147,7 @@ func (rtd *TrackingData) init() {
 	rtd.ByHeader = map[string]*HeaderData{}
 	rtd.ByURI = map[string]int{}
 	rtd.ByURIAndHeader = map[string]map[string]*HeaderData{}
+	rtd.ByBodyValidation = map[string]*BodyValidationCounts{}
 }
 
 func (rtd *TrackingData) initURI(uri string) {
@@ -189,6 +196,7 @@ func (rtd *TrackingData) clearCounts() {
 	for _, hd := range rtd.ByHeader {
 		hd.init()
 	}
+	rtd.ByBodyValidation = map[string]*BodyValidationCounts{}
 }
 
 func (rtd *TrackingData) getHeaderData(header string) *HeaderData {
@@ -312,3 +320,20 @@ func middlewareFunc(next http.Handler) http.Handler {
 		}
 	})
 }
+
+// TrackBodyValidation counts a request body that the named validation rule found valid or invalid.
+func TrackBodyValidation(port int, rule string, valid bool) {
+	rtd := Tracker.getPortRequestTrackingData(port, "")
+	rtd.lock.Lock()
+	defer rtd.lock.Unlock()
+	counts := rtd.ByBodyValidation[rule]
+	if counts == nil {
+		counts = &BodyValidationCounts{}
+		rtd.ByBodyValidation[rule] = counts
+	}
+	if valid {
+		counts.Valid++
+	} else {
+		counts.Invalid++
+	}
+}
@@ -0,0 +1,229 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"goto/pkg/constants"
+	"goto/pkg/server/request/tracking"
+	"goto/pkg/server/response/status"
+	"goto/pkg/util"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const defaultError = "request body validation failed"
+
+// ValidationRule validates the bodies of the requests it matches against a JSON Schema or a protobuf message (as protobuf-JSON),
+// and fails the invalid ones with the configured status and error.
+type ValidationRule struct {
+	Name         string              `json:"name"`
+	Match        *status.StatusMatch `json:"match"`
+	Schema       any                 `json:"schema,omitempty"`
+	ProtoMessage string              `json:"protoMessage,omitempty"`
+	AllowEmpty   bool                `json:"allowEmpty,omitempty"`
+	Status       int                 `json:"status"`
+	Error        string              `json:"error"`
+	Headers      map[string]string   `json:"headers,omitempty"`
+	validator    *jsonschema.Resolved
+	message      protoreflect.MessageDescriptor
+}
+
+type PortValidations struct {
+	Port  int               `json:"port"`
+	Rules []*ValidationRule `json:"rules"`
+	lock  sync.RWMutex
+}
+
+var (
+	portValidations = map[int]*PortValidations{}
+	validationsLock sync.RWMutex
+)
+
+func getPortValidations(port int, create bool) *PortValidations {
+	if !create {
+		validationsLock.RLock()
+		defer validationsLock.RUnlock()
+		return portValidations[port]
+	}
+	validationsLock.Lock()
+	defer validationsLock.Unlock()
+	if portValidations[port] == nil {
+		portValidations[port] = &PortValidations{Port: port, Rules: []*ValidationRule{}}
+	}
+	return portValidations[port]
+}
+
+func (v *ValidationRule) init() error {
+	if v.Name == "" {
+		return errors.New("validation rule needs a name")
+	}
+	if (v.Schema == nil) == (v.ProtoMessage == "") {
+		return errors.New("validation rule needs either a schema or a protoMessage")
+	}
+	if v.Schema != nil {
+		b, err := json.Marshal(v.Schema)
+		if err != nil {
+			return err
+		}
+		schema := &jsonschema.Schema{}
+		if err := json.Unmarshal(b, schema); err != nil {
+			return fmt.Errorf("invalid schema: %s", err.Error())
+		}
+		if v.validator, err = schema.Resolve(nil); err != nil {
+			return fmt.Errorf("invalid schema: %s", err.Error())
+		}
+	} else {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(v.ProtoMessage))
+		if err != nil {
+			return fmt.Errorf("proto message [%s] not found", v.ProtoMessage)
+		}
+		md, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			return fmt.Errorf("[%s] is not a proto message", v.ProtoMessage)
+		}
+		v.message = md
+	}
+	if v.Status == 0 {
+		v.Status = http.StatusBadRequest
+	} else if v.Status < 100 || v.Status > 599 {
+		return fmt.Errorf("invalid status [%d]", v.Status)
+	}
+	if v.Error == "" {
+		v.Error = defaultError
+	}
+	if v.Match == nil {
+		v.Match = &status.StatusMatch{}
+	}
+	return v.Match.Prepare()
+}
+
+// validate returns the violations found in the body.
+func (v *ValidationRule) validate(body []byte) (violations []string) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if v.AllowEmpty {
+			return nil
+		}
+		return []string{"request body is required"}
+	}
+	if v.message != nil {
+		if err := protojson.Unmarshal(body, dynamicpb.NewMessage(v.message)); err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	}
+	var instance any
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return []string{fmt.Sprintf("invalid JSON: %s", err.Error())}
+	}
+	if err := v.validator.Validate(instance); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func (pv *PortValidations) addRule(rule *ValidationRule) {
+	pv.lock.Lock()
+	defer pv.lock.Unlock()
+	for i, v := range pv.Rules {
+		if v.Name == rule.Name {
+			pv.Rules[i] = rule
+			return
+		}
+	}
+	pv.Rules = append(pv.Rules, rule)
+}
+
+func (pv *PortValidations) removeRule(name string) bool {
+	pv.lock.Lock()
+	defer pv.lock.Unlock()
+	for i, v := range pv.Rules {
+		if v.Name == name {
+			pv.Rules = append(pv.Rules[:i], pv.Rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (pv *PortValidations) snapshot() *PortValidations {
+	pv.lock.RLock()
+	defer pv.lock.RUnlock()
+	return &PortValidations{Port: pv.Port, Rules: append([]*ValidationRule{}, pv.Rules...)}
+}
+
+func (pv *PortValidations) match(r *http.Request) *ValidationRule {
+	pv.lock.RLock()
+	defer pv.lock.RUnlock()
+	for _, v := range pv.Rules {
+		if v.Match.Matches(r.RequestURI, r.Header, r.Method) {
+			return v
+		}
+	}
+	return nil
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		port := util.GetRequestOrListenerPortNum(r)
+		var rule *ValidationRule
+		if !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest {
+			if pv := getPortValidations(port, false); pv != nil {
+				rule = pv.match(r)
+			}
+		}
+		if rule == nil {
+			if next != nil {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		violations := rule.validate(body)
+		tracking.TrackBodyValidation(port, rule.Name, len(violations) == 0)
+		if len(violations) == 0 {
+			w.Header().Set(constants.HeaderGotoBodyValidation, rule.Name+":valid")
+			if next != nil {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		msg := fmt.Sprintf("Validation rule [%s] rejected body of URI [%s] with [%d]: %v", rule.Name, r.RequestURI, rule.Status, violations)
+		util.AddLogMessage(msg, r)
+		util.UpdateTrafficEventDetails(r, msg)
+		w.Header().Set(constants.HeaderGotoBodyValidation, rule.Name+":invalid")
+		for k, v := range rule.Headers {
+			w.Header().Set(k, v)
+		}
+		w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSON)
+		w.WriteHeader(rule.Status)
+		util.WriteJson(w, map[string]any{"error": rule.Error, "rule": rule.Name, "violations": violations})
+	})
+}
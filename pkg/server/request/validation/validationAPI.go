@@ -0,0 +1,98 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package validation
+
+import (
+	"fmt"
+	"net/http"
+
+	"goto/pkg/events"
+	"goto/pkg/server/middleware"
+	"goto/pkg/util"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	Middleware = middleware.NewMiddleware("validation", setRoutes, middlewareFunc)
+)
+
+func setRoutes(r *mux.Router) {
+	validationRouter := util.PathRouter(r, "/validation")
+	util.AddRoute(validationRouter, "/add", addRule, "POST", "PUT")
+	util.AddRoute(validationRouter, "/remove/{name}", removeRule, "POST", "PUT")
+	util.AddRoute(validationRouter, "/clear", clearRules, "POST")
+	util.AddRoute(validationRouter, "", getRules, "GET")
+}
+
+func addRule(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	rule := &ValidationRule{}
+	msg := ""
+	if err := util.ReadJsonPayload(r, rule); err != nil {
+		msg = fmt.Sprintf("Failed to parse validation rule with error: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+	} else if err := rule.init(); err != nil {
+		msg = fmt.Sprintf("Invalid validation rule: %s", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		getPortValidations(port, true).addRule(rule)
+		msg = fmt.Sprintf("Port [%d] added validation rule [%s]", port, rule.Name)
+		events.SendRequestEventJSON("Validation Rule Added", rule.Name, rule, r)
+		w.WriteHeader(http.StatusOK)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
+
+func removeRule(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	name := util.GetStringParamValue(r, "name")
+	msg := ""
+	if pv := getPortValidations(port, false); pv != nil && pv.removeRule(name) {
+		msg = fmt.Sprintf("Port [%d] removed validation rule [%s]", port, name)
+		events.SendRequestEvent("Validation Rule Removed", msg, r)
+		w.WriteHeader(http.StatusOK)
+	} else {
+		msg = fmt.Sprintf("Port [%d] has no validation rule [%s]", port, name)
+		w.WriteHeader(http.StatusNotFound)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
+
+func clearRules(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	validationsLock.Lock()
+	delete(portValidations, port)
+	validationsLock.Unlock()
+	msg := fmt.Sprintf("Port [%d] validation rules cleared", port)
+	events.SendRequestEvent("Validation Rules Cleared", msg, r)
+	util.AddLogMessage(msg, r)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, msg)
+}
+
+func getRules(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	pv := getPortValidations(port, false)
+	if pv == nil {
+		pv = &PortValidations{Port: port, Rules: []*ValidationRule{}}
+	}
+	util.WriteJsonPayload(w, pv.snapshot())
+	util.AddLogMessage(fmt.Sprintf("Port [%d] reporting validation rules", port), r)
+}
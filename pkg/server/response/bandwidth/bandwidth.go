@@ -0,0 +1,382 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bandwidth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"goto/pkg/constants"
+	"goto/pkg/server/intercept"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/server/response/status"
+	"goto/pkg/util"
+)
+
+type BandwidthStall struct {
+	After    string `json:"after"`
+	Duration string `json:"duration"`
+	after    int
+	duration time.Duration
+}
+
+type BandwidthCounts struct {
+	Matched      int `json:"matched"`
+	BytesRead    int `json:"bytesRead"`
+	BytesWritten int `json:"bytesWritten"`
+	Stalls       int `json:"stalls"`
+}
+
+type BandwidthRule struct {
+	Name       string              `json:"name"`
+	Match      *status.StatusMatch `json:"match"`
+	ReadRate   string              `json:"readRate"`
+	WriteRate  string              `json:"writeRate"`
+	HeaderRate string              `json:"headerRate"`
+	Stalls     []*BandwidthStall   `json:"stalls"`
+	Counts     *BandwidthCounts    `json:"counts"`
+	readRate   int
+	writeRate  int
+	headerRate int
+	lock       sync.Mutex
+}
+
+// pacer spreads bytes over time at a given rate, in chunks small enough to keep the flow steady.
+type pacer struct {
+	rate  int
+	chunk int
+}
+
+type shapedReader struct {
+	io.ReadCloser
+	rule  *BandwidthRule
+	pacer *pacer
+}
+
+// shapedWriter takes the place of the intercept writer's underlying response writer, so that whatever
+// the intercept writer sends out (held payload, streamed chunks, or status and headers) gets paced.
+type shapedWriter struct {
+	http.ResponseWriter
+	hijacker    http.Hijacker
+	flusher     http.Flusher
+	r           *http.Request
+	rule        *BandwidthRule
+	pacer       *pacer
+	headerPacer *pacer
+	conn        net.Conn
+	stalls      []*BandwidthStall
+	written     int
+	wroteHeader bool
+}
+
+var (
+	bandwidthRules = rules.NewRegistry[*BandwidthRule]("rules")
+)
+
+func parseRate(value, field string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if rate := util.ParseSize(value); rate > 0 {
+		return rate, nil
+	}
+	return 0, fmt.Errorf("invalid %s [%s]", field, value)
+}
+
+func (br *BandwidthRule) init() error {
+	if br.Name == "" {
+		return errors.New("bandwidth rule needs a name")
+	}
+	var err error
+	if br.readRate, err = parseRate(br.ReadRate, "readRate"); err != nil {
+		return err
+	}
+	if br.writeRate, err = parseRate(br.WriteRate, "writeRate"); err != nil {
+		return err
+	}
+	if br.headerRate, err = parseRate(br.HeaderRate, "headerRate"); err != nil {
+		return err
+	}
+	for _, s := range br.Stalls {
+		if s.After != "0" {
+			if s.after = util.ParseSize(s.After); s.after <= 0 {
+				return fmt.Errorf("invalid stall offset [%s]", s.After)
+			}
+		}
+		if s.duration, err = time.ParseDuration(s.Duration); err != nil || s.duration <= 0 {
+			return fmt.Errorf("invalid stall duration [%s]", s.Duration)
+		}
+	}
+	sort.SliceStable(br.Stalls, func(i, j int) bool { return br.Stalls[i].after < br.Stalls[j].after })
+	if br.readRate == 0 && br.writeRate == 0 && br.headerRate == 0 && len(br.Stalls) == 0 {
+		return errors.New("bandwidth rule needs at least one of readRate, writeRate, headerRate or stalls")
+	}
+	if br.Match == nil {
+		br.Match = &status.StatusMatch{}
+	}
+	if err := br.Match.Prepare(); err != nil {
+		return err
+	}
+	br.Counts = &BandwidthCounts{}
+	return nil
+}
+
+func (br *BandwidthRule) shapesResponse() bool {
+	return br.writeRate > 0 || br.headerRate > 0 || len(br.Stalls) > 0
+}
+
+func (br *BandwidthRule) count(read, written, stalls int) {
+	br.lock.Lock()
+	br.Counts.BytesRead += read
+	br.Counts.BytesWritten += written
+	br.Counts.Stalls += stalls
+	br.lock.Unlock()
+}
+
+func (br *BandwidthRule) RuleName() string {
+	return br.Name
+}
+
+func (br *BandwidthRule) ClearCounts() {
+	br.lock.Lock()
+	defer br.lock.Unlock()
+	br.Counts = &BandwidthCounts{}
+}
+
+func (br *BandwidthRule) Snapshot() *BandwidthRule {
+	br.lock.Lock()
+	defer br.lock.Unlock()
+	counts := *br.Counts
+	return &BandwidthRule{Name: br.Name, Match: br.Match, ReadRate: br.ReadRate, WriteRate: br.WriteRate,
+		HeaderRate: br.HeaderRate, Stalls: br.Stalls, Counts: &counts}
+}
+
+// match returns the first rule of the port that matches the request.
+func match(port int, r *http.Request, count bool) *BandwidthRule {
+	for _, br := range bandwidthRules.List(port) {
+		if br.Match.Matches(r.RequestURI, r.Header, r.Method) {
+			if count {
+				br.lock.Lock()
+				br.Counts.Matched++
+				br.lock.Unlock()
+			}
+			return br
+		}
+	}
+	return nil
+}
+
+func newPacer(rate int) *pacer {
+	if rate <= 0 {
+		return nil
+	}
+	return &pacer{rate: rate, chunk: max(1, rate/10)}
+}
+
+func (p *pacer) limit(n int) int {
+	if p != nil && n > p.chunk {
+		return p.chunk
+	}
+	return n
+}
+
+func (p *pacer) wait(n int) {
+	if p != nil && n > 0 {
+		time.Sleep(time.Duration(n) * time.Second / time.Duration(p.rate))
+	}
+}
+
+func init() {
+	util.ShapeBody = shapeBody
+}
+
+// shapeBody paces the reading of request bodies for the rules that have a read rate. Request bodies are
+// read in full when the request store is set up, before any middleware sees the request, so the body
+// gets wrapped right there.
+func shapeBody(r *http.Request, rs *util.RequestStore) io.ReadCloser {
+	if rs.IsAdminRequest || rs.IsKnownNonTraffic || rs.IsTunnelRequest {
+		return r.Body
+	}
+	if rule := match(util.GetRequestOrListenerPortNum(r), r, false); rule != nil && rule.readRate > 0 {
+		return newShapedReader(r.Body, rule)
+	}
+	return r.Body
+}
+
+func newShapedReader(body io.ReadCloser, rule *BandwidthRule) *shapedReader {
+	return &shapedReader{ReadCloser: body, rule: rule, pacer: newPacer(rule.readRate)}
+}
+
+func (sr *shapedReader) Read(p []byte) (int, error) {
+	n, err := sr.ReadCloser.Read(p[:sr.pacer.limit(len(p))])
+	if n > 0 {
+		sr.rule.count(n, 0, 0)
+		sr.pacer.wait(n)
+	}
+	return n, err
+}
+
+func newShapedWriter(w http.ResponseWriter, rule *BandwidthRule, r *http.Request) *shapedWriter {
+	hijacker, _ := w.(http.Hijacker)
+	flusher, _ := w.(http.Flusher)
+	return &shapedWriter{
+		ResponseWriter: w,
+		hijacker:       hijacker,
+		flusher:        flusher,
+		r:              r,
+		rule:           rule,
+		pacer:          newPacer(rule.writeRate),
+		headerPacer:    newPacer(rule.headerRate),
+		stalls:         rule.Stalls,
+	}
+}
+
+func (sw *shapedWriter) headerBytes(statusCode int) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "HTTP/1.1 %03d %s\r\n", statusCode, http.StatusText(statusCode))
+	h := sw.Header().Clone()
+	if h.Get("Date") == "" {
+		h.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+	h.Set("Connection", "close")
+	h.Write(buf)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// WriteHeader trickles the status line and headers over an HTTP/1.x connection taken over from the server,
+// after which the body is written to the same connection and the connection is closed once the response is done.
+// Other protocols don't allow writing the headers piecemeal, so the headers are held back for as long as trickling
+// them would take.
+func (sw *shapedWriter) WriteHeader(statusCode int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+	if sw.headerPacer == nil {
+		sw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	header := sw.headerBytes(statusCode)
+	if sw.hijacker != nil && sw.r.ProtoMajor == 1 {
+		if conn, _, err := sw.hijacker.Hijack(); err == nil && conn != nil {
+			sw.conn = conn
+			for len(header) > 0 {
+				n := sw.headerPacer.limit(len(header))
+				if _, err := conn.Write(header[:n]); err != nil {
+					return
+				}
+				sw.headerPacer.wait(n)
+				header = header[n:]
+			}
+			return
+		}
+	}
+	sw.headerPacer.wait(len(header))
+	sw.ResponseWriter.WriteHeader(statusCode)
+	sw.Flush()
+}
+
+func (sw *shapedWriter) stall() int {
+	stalls := 0
+	for len(sw.stalls) > 0 && sw.stalls[0].after <= sw.written {
+		sw.Flush()
+		time.Sleep(sw.stalls[0].duration)
+		sw.stalls = sw.stalls[1:]
+		stalls++
+	}
+	return stalls
+}
+
+func (sw *shapedWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if sw.conn != nil && sw.r.Method == http.MethodHead {
+		return len(b), nil
+	}
+	total := 0
+	for len(b) > 0 {
+		stalls := sw.stall()
+		n := sw.pacer.limit(len(b))
+		if len(sw.stalls) > 0 && sw.written+n > sw.stalls[0].after {
+			n = sw.stalls[0].after - sw.written
+		}
+		var err error
+		if sw.conn != nil {
+			n, err = sw.conn.Write(b[:n])
+		} else {
+			n, err = sw.ResponseWriter.Write(b[:n])
+			if sw.pacer != nil || len(sw.stalls) > 0 {
+				sw.Flush()
+			}
+		}
+		sw.written += n
+		total += n
+		sw.rule.count(0, n, stalls)
+		if err != nil {
+			return total, err
+		}
+		sw.pacer.wait(n)
+		b = b[n:]
+	}
+	return total, nil
+}
+
+func (sw *shapedWriter) Flush() {
+	if sw.conn == nil && sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+}
+
+func (sw *shapedWriter) Close() error {
+	if sw.conn != nil {
+		return sw.conn.Close()
+	}
+	return nil
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		var rule *BandwidthRule
+		if !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest {
+			rule = match(util.GetRequestOrListenerPortNum(r), r, true)
+		}
+		if rule != nil {
+			if irw := intercept.GetInterceptWriter(r); irw != nil && rule.shapesResponse() {
+				sw := newShapedWriter(irw.ResponseWriter, rule, r)
+				irw.ResponseWriter = sw
+				irw.Flusher = sw
+			}
+			msg := fmt.Sprintf("Shaping bandwidth with rule [%s] for URI [%s]", rule.Name, r.RequestURI)
+			util.AddLogMessage(msg, r)
+			util.UpdateTrafficEventDetails(r, msg)
+			w.Header().Add(constants.HeaderGotoBandwidth, rule.Name)
+		}
+		if next != nil {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
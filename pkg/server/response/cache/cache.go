@@ -0,0 +1,300 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goto/pkg/constants"
+	"goto/pkg/server/intercept"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/server/response/status"
+	"goto/pkg/util"
+)
+
+const (
+	ETagAuto = "auto"
+	ETagNone = "none"
+)
+
+type CacheCounts struct {
+	Matched       int `json:"matched"`
+	Conditional   int `json:"conditional"`
+	NotModified   int `json:"notModified"`
+	Partial       int `json:"partial"`
+	Unsatisfiable int `json:"unsatisfiable"`
+}
+
+// CacheRule adds validators and caching headers to the successful GET/HEAD responses of the requests it matches,
+// and answers conditional and range requests over the response body.
+type CacheRule struct {
+	Name         string              `json:"name"`
+	Match        *status.StatusMatch `json:"match"`
+	ETag         string              `json:"etag"`
+	WeakETag     bool                `json:"weakETag,omitempty"`
+	LastModified string              `json:"lastModified"`
+	CacheControl string              `json:"cacheControl,omitempty"`
+	Vary         []string            `json:"vary,omitempty"`
+	Ranges       bool                `json:"ranges,omitempty"`
+	Counts       *CacheCounts        `json:"counts"`
+	lastModified time.Time
+	lock         sync.Mutex
+}
+
+var cacheRules = rules.NewRegistry[*CacheRule]("rules")
+
+func (c *CacheRule) init() error {
+	if c.Name == "" {
+		return errors.New("cache rule needs a name")
+	}
+	if c.ETag == "" {
+		c.ETag = ETagAuto
+	}
+	if c.LastModified == "" {
+		c.lastModified = time.Now().UTC().Truncate(time.Second)
+		c.LastModified = c.lastModified.Format(http.TimeFormat)
+	} else if t, err := http.ParseTime(c.LastModified); err == nil {
+		c.lastModified = t.UTC()
+	} else if t, err := time.Parse(time.RFC3339, c.LastModified); err == nil {
+		c.lastModified = t.UTC().Truncate(time.Second)
+	} else {
+		return fmt.Errorf("invalid lastModified [%s]", c.LastModified)
+	}
+	if c.Match == nil {
+		c.Match = &status.StatusMatch{}
+	}
+	if err := c.Match.Prepare(); err != nil {
+		return err
+	}
+	c.Counts = &CacheCounts{}
+	return nil
+}
+
+func (c *CacheRule) etagFor(body []byte) string {
+	etag := c.ETag
+	switch etag {
+	case ETagNone:
+		return ""
+	case ETagAuto:
+		sum := sha1.Sum(body)
+		etag = "\"" + hex.EncodeToString(sum[:8]) + "\""
+	default:
+		if !strings.HasPrefix(etag, "\"") && !strings.HasPrefix(etag, "W/") {
+			etag = "\"" + etag + "\""
+		}
+	}
+	if c.WeakETag && !strings.HasPrefix(etag, "W/") {
+		etag = "W/" + etag
+	}
+	return etag
+}
+
+func (c *CacheRule) count(f func(*CacheCounts)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	f(c.Counts)
+}
+
+// notModified tells whether the request's If-None-Match (or, without it, If-Modified-Since) is satisfied by the response validators.
+func (c *CacheRule) notModified(r *http.Request, etag string) (conditional, hit bool) {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return true, false
+		}
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true, true
+			}
+		}
+		return true, false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil {
+			return true, !c.lastModified.After(t)
+		}
+	}
+	return false, false
+}
+
+// ifRange tells whether a Range request should be served as a range, which is when there's no If-Range or it matches the validators.
+func (c *CacheRule) ifRange(r *http.Request, etag string) bool {
+	ir := r.Header.Get("If-Range")
+	if ir == "" {
+		return true
+	}
+	if strings.HasPrefix(ir, "\"") || strings.HasPrefix(ir, "W/") {
+		return etag != "" && !strings.HasPrefix(ir, "W/") && !strings.HasPrefix(etag, "W/") && ir == etag
+	}
+	t, err := http.ParseTime(ir)
+	return err == nil && t.Equal(c.lastModified)
+}
+
+// parseRange parses a single byte range against the given length, returning ok false for a header that should be ignored
+// and satisfiable false for a range that falls outside the body.
+func parseRange(header string, length int) (start, end int, satisfiable, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, false
+	}
+	from, to, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false, false
+	}
+	if from == "" {
+		n, err := strconv.Atoi(to)
+		if err != nil || n < 0 {
+			return 0, 0, false, false
+		}
+		if n == 0 || length == 0 {
+			return 0, 0, false, true
+		}
+		if n > length {
+			n = length
+		}
+		return length - n, length - 1, true, true
+	}
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 0 {
+		return 0, 0, false, false
+	}
+	end = length - 1
+	if to != "" {
+		if end, err = strconv.Atoi(to); err != nil || end < start {
+			return 0, 0, false, false
+		}
+		if end > length-1 {
+			end = length - 1
+		}
+	}
+	if start >= length {
+		return 0, 0, false, true
+	}
+	return start, end, true, true
+}
+
+func (c *CacheRule) RuleName() string {
+	return c.Name
+}
+
+func (c *CacheRule) ClearCounts() {
+	c.count(func(counts *CacheCounts) { *counts = CacheCounts{} })
+}
+
+func (c *CacheRule) Snapshot() *CacheRule {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	counts := *c.Counts
+	return &CacheRule{Name: c.Name, Match: c.Match, ETag: c.ETag, WeakETag: c.WeakETag,
+		LastModified: c.LastModified, CacheControl: c.CacheControl, Vary: c.Vary, Ranges: c.Ranges, Counts: &counts}
+}
+
+func match(port int, r *http.Request) *CacheRule {
+	for _, c := range cacheRules.List(port) {
+		if c.Match.Matches(r.RequestURI, r.Header, r.Method) {
+			return c
+		}
+	}
+	return nil
+}
+
+func apply(rule *CacheRule, irw *intercept.InterceptResponseWriter, r *http.Request) {
+	header := irw.Header()
+	etag := rule.etagFor(irw.Data)
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+	header.Set("Last-Modified", rule.LastModified)
+	if rule.CacheControl != "" {
+		header.Set(constants.HeaderCacheControl, rule.CacheControl)
+	}
+	if len(rule.Vary) > 0 {
+		header.Set("Vary", strings.Join(rule.Vary, ", "))
+	}
+	if rule.Ranges {
+		header.Set("Accept-Ranges", "bytes")
+	}
+	statusCode := http.StatusOK
+	conditional, hit := rule.notModified(r, etag)
+	if hit {
+		statusCode = http.StatusNotModified
+		irw.Data = []byte{}
+		header.Del(constants.HeaderContentLength)
+		header.Del(constants.HeaderContentType)
+	} else if rng := r.Header.Get("Range"); rng != "" && rule.Ranges && rule.ifRange(r, etag) {
+		length := len(irw.Data)
+		if start, end, satisfiable, ok := parseRange(rng, length); ok && satisfiable {
+			statusCode = http.StatusPartialContent
+			irw.Data = irw.Data[start : end+1]
+			header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, length))
+			header.Set(constants.HeaderContentLength, strconv.Itoa(len(irw.Data)))
+		} else if ok {
+			statusCode = http.StatusRequestedRangeNotSatisfiable
+			irw.Data = []byte{}
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", length))
+			header.Del(constants.HeaderContentLength)
+		}
+	}
+	rule.count(func(counts *CacheCounts) {
+		counts.Matched++
+		if conditional {
+			counts.Conditional++
+		}
+		switch statusCode {
+		case http.StatusNotModified:
+			counts.NotModified++
+		case http.StatusPartialContent:
+			counts.Partial++
+		case http.StatusRequestedRangeNotSatisfiable:
+			counts.Unsatisfiable++
+		}
+	})
+	header.Set(constants.HeaderGotoCache, fmt.Sprintf("%s:%d", rule.Name, statusCode))
+	if statusCode != http.StatusOK {
+		irw.StatusCode = statusCode
+		util.GetRequestStore(r).StatusCode = statusCode
+		msg := fmt.Sprintf("Cache rule [%s] responding with [%d] for URI [%s]", rule.Name, statusCode, r.RequestURI)
+		util.AddLogMessage(msg, r)
+		util.UpdateTrafficEventDetails(r, msg)
+	}
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		var rule *CacheRule
+		irw := intercept.GetInterceptWriter(r)
+		if irw != nil && (r.Method == http.MethodGet || r.Method == http.MethodHead) &&
+			!rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest {
+			rule = match(util.GetRequestOrListenerPortNum(r), r)
+		}
+		if next != nil {
+			next.ServeHTTP(w, r)
+		}
+		if rule != nil && !irw.Hijacked && !irw.Chunked && (irw.StatusCode == 0 || irw.StatusCode == http.StatusOK) {
+			apply(rule, irw, r)
+		}
+	})
+}
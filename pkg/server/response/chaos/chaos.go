@@ -0,0 +1,398 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chaos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goto/pkg/constants"
+	"goto/pkg/server/intercept"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/server/response/status"
+	"goto/pkg/util"
+
+	"golang.org/x/net/http2"
+)
+
+type ChaosCounts struct {
+	Matched int `json:"matched"`
+	Applied int `json:"applied"`
+}
+
+type ChaosRule struct {
+	Name          string              `json:"name"`
+	Match         *status.StatusMatch `json:"match"`
+	Action        string              `json:"action"`
+	After         string              `json:"after"`
+	ContentLength int                 `json:"contentLength"`
+	ErrorCode     string              `json:"errorCode,omitempty"`
+	Percent       float64             `json:"percent"`
+	Times         int                 `json:"times"`
+	Counts        *ChaosCounts        `json:"counts"`
+	after         int
+	lock          sync.Mutex
+}
+
+const (
+	ActionReset     = "reset"
+	ActionFin       = "fin"
+	ActionTruncate  = "truncate"
+	ActionBadChunk  = "badChunk"
+	ActionGoAway    = "goaway"
+	ActionRSTStream = "rstStream"
+
+	halfCloseLinger = 30 * time.Second
+	h2FlushWait     = 50 * time.Millisecond
+)
+
+var (
+	chaosRules = rules.NewRegistry[*ChaosRule]("rules")
+	actions    = []string{ActionReset, ActionFin, ActionTruncate, ActionBadChunk, ActionGoAway, ActionRSTStream}
+
+	// serverErrorCodes are the error codes that the listener's HTTP/2 server sends for the frame actions: NO_ERROR
+	// with the GOAWAY that follows a "Connection: close" response, and INTERNAL_ERROR when resetting the stream of
+	// an aborted handler. The server doesn't let any other code be chosen.
+	serverErrorCodes = map[string]http2.ErrCode{ActionGoAway: http2.ErrCodeNo, ActionRSTStream: http2.ErrCodeInternal}
+)
+
+func parseErrorCode(code string) (http2.ErrCode, bool) {
+	if n, err := strconv.ParseUint(code, 0, 32); err == nil {
+		return http2.ErrCode(n), true
+	}
+	for c := http2.ErrCodeNo; c <= http2.ErrCodeHTTP11Required; c++ {
+		if strings.EqualFold(c.String(), code) {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+func (cr *ChaosRule) init() error {
+	if cr.Name == "" {
+		return errors.New("chaos rule needs a name")
+	}
+	valid := false
+	for _, a := range actions {
+		if strings.EqualFold(cr.Action, a) {
+			cr.Action = a
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("chaos rule action must be one of %+v", actions)
+	}
+	if serverCode, ok := serverErrorCodes[cr.Action]; ok {
+		if cr.ErrorCode != "" {
+			if code, ok := parseErrorCode(cr.ErrorCode); !ok {
+				return fmt.Errorf("invalid errorCode [%s]", cr.ErrorCode)
+			} else if code != serverCode {
+				return fmt.Errorf("errorCode [%s] can't be sent, %s only sends [%s]", cr.ErrorCode, cr.Action, serverCode)
+			}
+		}
+		cr.ErrorCode = serverCode.String()
+	} else if cr.ErrorCode != "" {
+		return fmt.Errorf("errorCode doesn't apply to %s", cr.Action)
+	}
+	cr.after = -1
+	if cr.After != "" {
+		if cr.after = util.ParseSize(cr.After); cr.after <= 0 && cr.After != "0" {
+			return fmt.Errorf("invalid after [%s]", cr.After)
+		}
+	}
+	if cr.ContentLength < 0 {
+		return errors.New("invalid contentLength")
+	}
+	if cr.Percent == 0 {
+		cr.Percent = 100
+	} else if cr.Percent < 0 || cr.Percent > 100 {
+		return errors.New("invalid percent")
+	}
+	if cr.Times < 0 {
+		return errors.New("invalid times")
+	}
+	if cr.Match == nil {
+		cr.Match = &status.StatusMatch{}
+	}
+	if err := cr.Match.Prepare(); err != nil {
+		return err
+	}
+	cr.Counts = &ChaosCounts{}
+	return nil
+}
+
+// bodyCut returns how many bytes of the body to send before breaking the response. Unless given, it's half the body,
+// or the whole body for a truncate with an explicit content length so that the length alone is wrong.
+func (cr *ChaosRule) bodyCut(length int) int {
+	switch {
+	case cr.after >= 0:
+		return min(cr.after, length)
+	case cr.Action == ActionTruncate && cr.ContentLength > 0:
+		return length
+	default:
+		return length / 2
+	}
+}
+
+func (cr *ChaosRule) needsBody() bool {
+	return cr.Action == ActionFin || cr.Action == ActionTruncate || cr.Action == ActionBadChunk
+}
+
+func (cr *ChaosRule) RuleName() string {
+	return cr.Name
+}
+
+func (cr *ChaosRule) ClearCounts() {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	cr.Counts = &ChaosCounts{}
+}
+
+func (cr *ChaosRule) Snapshot() *ChaosRule {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+	counts := *cr.Counts
+	return &ChaosRule{Name: cr.Name, Match: cr.Match, Action: cr.Action, After: cr.After, ContentLength: cr.ContentLength,
+		ErrorCode: cr.ErrorCode, Percent: cr.Percent, Times: cr.Times, Counts: &counts}
+}
+
+// evaluate returns the first matching rule of the port that rolls its percent and hasn't yet been applied `times` times.
+func evaluate(port int, r *http.Request) *ChaosRule {
+	for _, cr := range chaosRules.List(port) {
+		if !cr.Match.Matches(r.RequestURI, r.Header, r.Method) {
+			continue
+		}
+		cr.lock.Lock()
+		cr.Counts.Matched++
+		apply := (cr.Times == 0 || cr.Counts.Applied < cr.Times) && (cr.Percent >= 100 || rand.Float64()*100 < cr.Percent)
+		if apply {
+			cr.Counts.Applied++
+		}
+		cr.lock.Unlock()
+		if apply {
+			return cr
+		}
+	}
+	return nil
+}
+
+func netConn(conn net.Conn) net.Conn {
+	if nc, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		return nc.NetConn()
+	}
+	return conn
+}
+
+func resetConn(conn net.Conn) {
+	if tcp, ok := netConn(conn).(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// halfClose sends a FIN while leaving the read side open. A connection taken over from the server is drained until
+// the client closes its side, whereas an HTTP/2 connection is left to the server that's still reading from it.
+func halfClose(conn net.Conn, drain bool) {
+	cw, ok := conn.(interface{ CloseWrite() error })
+	if !ok || cw.CloseWrite() != nil {
+		conn.Close()
+		return
+	}
+	if drain {
+		go func() {
+			conn.SetReadDeadline(time.Now().Add(halfCloseLinger))
+			io.Copy(io.Discard, conn)
+			conn.Close()
+		}()
+	}
+}
+
+func responseHead(statusCode int, header http.Header) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "HTTP/1.1 %03d %s\r\n", statusCode, http.StatusText(statusCode))
+	if header.Get("Date") == "" {
+		header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+	header.Write(buf)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// breakHTTP1 takes over the connection and writes the response with broken framing: a chunked body that
+// stops midway followed by a FIN (fin), a Content-Length that doesn't match the body sent (truncate), or a
+// chunk with an invalid size line (badChunk).
+func breakHTTP1(rule *ChaosRule, irw *intercept.InterceptResponseWriter, statusCode int, body []byte) bool {
+	conn, _, err := irw.Hijack()
+	if err != nil || conn == nil {
+		return false
+	}
+	irw.Proceeded = true
+	cut := rule.bodyCut(len(body))
+	header := irw.Header().Clone()
+	header.Del("Content-Length")
+	header.Del("Transfer-Encoding")
+	header.Set("Connection", "close")
+	out := &bytes.Buffer{}
+	switch rule.Action {
+	case ActionTruncate:
+		length := len(body)
+		if rule.ContentLength > 0 {
+			length = rule.ContentLength
+		}
+		header.Set("Content-Length", strconv.Itoa(length))
+		out.Write(responseHead(statusCode, header))
+		out.Write(body[:cut])
+	default:
+		header.Set("Transfer-Encoding", "chunked")
+		out.Write(responseHead(statusCode, header))
+		if cut > 0 {
+			fmt.Fprintf(out, "%x\r\n", cut)
+			out.Write(body[:cut])
+			out.WriteString("\r\n")
+		}
+		if rule.Action == ActionBadChunk {
+			fmt.Fprintf(out, "x%x\r\n", len(body)-cut)
+			out.Write(body[cut:])
+		}
+	}
+	conn.Write(out.Bytes())
+	if rule.Action == ActionFin {
+		halfClose(conn, true)
+	} else {
+		conn.Close()
+	}
+	return true
+}
+
+// breakHTTP2 sends part of the body over the stream and then closes (truncate) or half-closes (fin) the
+// underlying connection, since HTTP/2 framing can't be broken from within a stream.
+func breakHTTP2(rule *ChaosRule, irw *intercept.InterceptResponseWriter, r *http.Request, statusCode int, body []byte) bool {
+	conn := util.GetConn(r)
+	if conn == nil || rule.Action == ActionBadChunk {
+		return false
+	}
+	irw.Proceeded = true
+	if rule.Action == ActionTruncate {
+		length := len(body)
+		if rule.ContentLength > 0 {
+			length = rule.ContentLength
+		}
+		irw.Header().Set("Content-Length", strconv.Itoa(length))
+	}
+	irw.ResponseWriter.WriteHeader(statusCode)
+	irw.ResponseWriter.Write(body[:rule.bodyCut(len(body))])
+	if irw.Flusher != nil {
+		irw.Flusher.Flush()
+	}
+	time.Sleep(h2FlushWait)
+	if rule.Action == ActionFin {
+		halfClose(netConn(conn), false)
+	} else {
+		conn.Close()
+	}
+	return true
+}
+
+// goAway lets the request be served, and has the HTTP/2 server follow the response with a GOAWAY, which it does
+// for a response that carries a "Connection: close" header. The server closes the connection once its streams are done.
+func goAway(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	w.Header().Set("Connection", "close")
+	if next != nil {
+		next.ServeHTTP(w, r)
+	}
+}
+
+func resetRequest(irw *intercept.InterceptResponseWriter, r *http.Request) bool {
+	if r.ProtoMajor == 1 && irw.Hijacker != nil {
+		if conn, _, err := irw.Hijack(); err == nil && conn != nil {
+			resetConn(conn)
+			irw.Proceeded = true
+			return true
+		}
+	} else if conn := util.GetConn(r); conn != nil && r.ProtoMajor == 2 {
+		resetConn(conn)
+	}
+	return false
+}
+
+// apply carries out the rule's action. Whatever can't be done for the request's protocol falls back to aborting
+// the handler, which resets the HTTP/2 or HTTP/3 stream, or closes the HTTP/1.x connection. That is also how
+// rstStream resets an HTTP/2 stream.
+func apply(rule *ChaosRule, irw *intercept.InterceptResponseWriter, r *http.Request) {
+	switch rule.Action {
+	case ActionReset:
+		if resetRequest(irw, r) {
+			return
+		}
+	case ActionGoAway, ActionRSTStream:
+		if r.ProtoMajor == 1 && resetRequest(irw, r) {
+			return
+		}
+	default:
+		statusCode := irw.StatusCode
+		if statusCode <= 0 {
+			statusCode = http.StatusOK
+		}
+		if r.ProtoMajor == 1 && irw.Hijacker != nil && breakHTTP1(rule, irw, statusCode, irw.Data) {
+			return
+		} else if r.ProtoMajor == 2 && breakHTTP2(rule, irw, r, statusCode, irw.Data) {
+			return
+		}
+	}
+	panic(http.ErrAbortHandler)
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		var rule *ChaosRule
+		irw := intercept.GetInterceptWriter(r)
+		// gRPC calls run through the chain outside of the HTTP server, where there's no connection to break
+		// and an aborted handler isn't recovered.
+		if irw != nil && !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest && !rs.IsGRPC {
+			rule = evaluate(util.GetRequestOrListenerPortNum(r), r)
+		}
+		if rule == nil {
+			if next != nil {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		msg := fmt.Sprintf("Applying chaos [%s] of rule [%s] for URI [%s]", rule.Action, rule.Name, r.RequestURI)
+		util.AddLogMessage(msg, r)
+		util.UpdateTrafficEventDetails(r, msg)
+		w.Header().Add(constants.HeaderGotoChaos, rule.Name+":"+rule.Action)
+		if rule.Action == ActionGoAway && r.ProtoMajor == 2 {
+			goAway(w, r, next)
+			return
+		}
+		if rule.needsBody() && next != nil {
+			next.ServeHTTP(w, r)
+		}
+		apply(rule, irw, r)
+	})
+}
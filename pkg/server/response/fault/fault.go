@@ -0,0 +1,246 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"goto/pkg/constants"
+	"goto/pkg/server/intercept"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/server/response/status"
+	"goto/pkg/types"
+	"goto/pkg/util"
+)
+
+// FaultAbort and FaultDelay are shared with the proxy faults, which give the abort status their own meaning per protocol.
+type FaultAbort struct {
+	Status  int     `yaml:"status" json:"status"`
+	Percent float64 `yaml:"percent" json:"percent"`
+}
+
+type FaultDelay struct {
+	Delay   string  `yaml:"delay" json:"delay"`
+	Percent float64 `yaml:"percent" json:"percent"`
+	min     time.Duration
+	max     time.Duration
+}
+
+type FaultReset struct {
+	Percent float64 `json:"percent"`
+}
+
+type FaultCounts struct {
+	Matched int `json:"matched"`
+	Aborted int `json:"aborted"`
+	Delayed int `json:"delayed"`
+	Reset   int `json:"reset"`
+}
+
+type FaultRule struct {
+	Name   string              `json:"name"`
+	Match  *status.StatusMatch `json:"match"`
+	Abort  *FaultAbort         `json:"abort"`
+	Delay  *FaultDelay         `json:"delay"`
+	Reset  *FaultReset         `json:"reset"`
+	Counts *FaultCounts        `json:"counts"`
+	lock   sync.Mutex
+}
+
+// injection is the outcome of evaluating all fault rules of a port for a request.
+type injection struct {
+	delay  time.Duration
+	abort  int
+	reset  bool
+	faults []string
+}
+
+var (
+	faults = rules.NewRegistry[*FaultRule]("rules")
+)
+
+func Rolled(percent float64) bool {
+	return percent >= 100 || (percent > 0 && rand.Float64()*100 < percent)
+}
+
+func ValidPercent(percent float64) bool {
+	return percent >= 0 && percent <= 100
+}
+
+// Prepare parses the delay range, rejecting an invalid range or percent.
+func (fd *FaultDelay) Prepare() error {
+	var ok bool
+	if fd.min, fd.max, _, ok = types.ParseDurationRange(fd.Delay); !ok || fd.min <= 0 || !ValidPercent(fd.Percent) {
+		return errors.New("invalid delay fault")
+	}
+	return nil
+}
+
+// Roll gives a random delay within the range when the delay is rolled, and 0 otherwise.
+func (fd *FaultDelay) Roll() time.Duration {
+	if fd == nil || !Rolled(fd.Percent) {
+		return 0
+	}
+	return types.RandomDuration(fd.min, fd.max)
+}
+
+func (fr *FaultRule) init() error {
+	if fr.Name == "" {
+		return errors.New("fault rule needs a name")
+	}
+	if fr.Abort == nil && fr.Delay == nil && fr.Reset == nil {
+		return errors.New("fault rule needs at least one of abort, delay or reset")
+	}
+	if fr.Abort != nil {
+		if fr.Abort.Status < 100 || fr.Abort.Status > 599 || !ValidPercent(fr.Abort.Percent) {
+			return errors.New("invalid abort fault")
+		}
+	}
+	if fr.Delay != nil {
+		if err := fr.Delay.Prepare(); err != nil {
+			return err
+		}
+	}
+	if fr.Reset != nil && !ValidPercent(fr.Reset.Percent) {
+		return errors.New("invalid reset fault")
+	}
+	if fr.Match == nil {
+		fr.Match = &status.StatusMatch{}
+	}
+	if err := fr.Match.Prepare(); err != nil {
+		return err
+	}
+	fr.Counts = &FaultCounts{}
+	return nil
+}
+
+func (fr *FaultRule) RuleName() string {
+	return fr.Name
+}
+
+func (fr *FaultRule) ClearCounts() {
+	fr.lock.Lock()
+	defer fr.lock.Unlock()
+	fr.Counts = &FaultCounts{}
+}
+
+func (fr *FaultRule) Snapshot() *FaultRule {
+	fr.lock.Lock()
+	defer fr.lock.Unlock()
+	counts := *fr.Counts
+	return &FaultRule{Name: fr.Name, Match: fr.Match, Abort: fr.Abort, Delay: fr.Delay, Reset: fr.Reset, Counts: &counts}
+}
+
+// evaluate rolls the faults of every rule of the port that matches the request, each rule independently.
+// Delays of all matching rules add up, and the first rule that rolls a reset or abort decides
+// how the request ends, with a reset taking precedence over an abort of the same rule. Resets aren't rolled for
+// gRPC calls, which run through the chain outside of the HTTP server that would recover the aborted handler.
+func evaluate(port int, r *http.Request, grpc bool) *injection {
+	var inj *injection
+	for _, fr := range faults.List(port) {
+		if !fr.Match.Matches(r.RequestURI, r.Header, r.Method) {
+			continue
+		}
+		fr.lock.Lock()
+		fr.Counts.Matched++
+		if inj == nil {
+			inj = &injection{}
+		}
+		if delay := fr.Delay.Roll(); delay > 0 {
+			fr.Counts.Delayed++
+			inj.delay += delay
+			inj.faults = append(inj.faults, fr.Name+":delay")
+		}
+		if !inj.reset && inj.abort == 0 {
+			if fr.Reset != nil && !grpc && Rolled(fr.Reset.Percent) {
+				fr.Counts.Reset++
+				inj.reset = true
+				inj.faults = append(inj.faults, fr.Name+":reset")
+			} else if fr.Abort != nil && Rolled(fr.Abort.Percent) {
+				fr.Counts.Aborted++
+				inj.abort = fr.Abort.Status
+				inj.faults = append(inj.faults, fr.Name+":abort")
+			}
+		}
+		fr.lock.Unlock()
+	}
+	return inj
+}
+
+// resetConnection closes an HTTP/1.x connection with SO_LINGER set to 0 so that the client receives a TCP RST.
+// Other protocols can't take over the connection, so the handler is aborted instead, which resets the stream.
+func resetConnection(irw *intercept.InterceptResponseWriter, r *http.Request) {
+	if irw != nil && irw.Hijacker != nil && r.ProtoMajor == 1 {
+		if conn, _, err := irw.Hijack(); err == nil && conn != nil {
+			c := conn
+			if nc, ok := c.(interface{ NetConn() net.Conn }); ok {
+				c = nc.NetConn()
+			}
+			if tcp, ok := c.(*net.TCPConn); ok {
+				tcp.SetLinger(0)
+			}
+			conn.Close()
+			irw.Proceeded = true
+			return
+		}
+	}
+	panic(http.ErrAbortHandler)
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		var inj *injection
+		if !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest {
+			inj = evaluate(util.GetRequestOrListenerPortNum(r), r, rs.IsGRPC)
+		}
+		if inj == nil || len(inj.faults) == 0 {
+			if next != nil {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		msg := fmt.Sprintf("Injecting faults %+v for URI [%s]", inj.faults, r.RequestURI)
+		util.AddLogMessage(msg, r)
+		util.UpdateTrafficEventDetails(r, msg)
+		for _, f := range inj.faults {
+			w.Header().Add(constants.HeaderGotoFault, f)
+		}
+		if inj.delay > 0 {
+			w.Header().Add(constants.HeaderGotoResponseDelay, inj.delay.String())
+			time.Sleep(inj.delay)
+		}
+		if inj.reset {
+			resetConnection(intercept.GetInterceptWriter(r), r)
+			return
+		}
+		if inj.abort > 0 {
+			w.WriteHeader(inj.abort)
+			fmt.Fprintln(w, "fault filter abort")
+			return
+		}
+		if next != nil {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
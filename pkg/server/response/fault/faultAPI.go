@@ -0,0 +1,37 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fault
+
+import (
+	"goto/pkg/server/middleware"
+	"goto/pkg/server/response/rules"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	Middleware = middleware.NewMiddleware("fault", setRoutes, middlewareFunc)
+	api        = &rules.API[*FaultRule]{
+		Rules: faults,
+		Kind:  "fault rule",
+		Read:  rules.ReadJSON(func() *FaultRule { return &FaultRule{} }, (*FaultRule).init),
+	}
+)
+
+func setRoutes(r *mux.Router) {
+	api.SetRoutes(r, "/fault")
+}
@@ -0,0 +1,532 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"goto/pkg/constants"
+	"goto/pkg/server/response/payload"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/util"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"sigs.k8s.io/yaml"
+)
+
+type OperationCounts struct {
+	Requests int `json:"requests"`
+	Invalid  int `json:"invalid"`
+}
+
+type Operation struct {
+	ID        string           `json:"id"`
+	Method    string           `json:"method"`
+	Path      string           `json:"path"`
+	Counts    *OperationCounts `json:"counts"`
+	spec      *OpenAPISpec
+	regex     *regexp.Regexp
+	pathKeys  []string
+	params    []*parameter
+	body      *requestBody
+	responses map[string]any
+	lock      sync.Mutex
+}
+
+type OpenAPISpec struct {
+	Name       string       `json:"name"`
+	Title      string       `json:"title"`
+	Version    string       `json:"version"`
+	BasePath   string       `json:"basePath"`
+	Operations []*Operation `json:"operations"`
+	doc        map[string]any
+	defs       map[string]any
+}
+
+type parameter struct {
+	name      string
+	in        string
+	required  bool
+	schema    any
+	validator *jsonschema.Resolved
+}
+
+type requestBody struct {
+	required bool
+	content  map[string]*jsonschema.Resolved
+}
+
+var (
+	specs          = rules.NewRegistry[*OpenAPISpec]("specs")
+	pathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
+	httpMethods    = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+)
+
+// newSpec parses an OpenAPI 3.x document given as JSON or YAML. The base path that the spec's paths are served under
+// defaults to the path of the spec's first server URL.
+func newSpec(name string, doc []byte, basePath string) (*OpenAPISpec, error) {
+	if name == "" {
+		return nil, errors.New("spec needs a name")
+	}
+	b, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	spec := &OpenAPISpec{Name: name, doc: map[string]any{}, Operations: []*Operation{}}
+	if err := json.Unmarshal(b, &spec.doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(asString(spec.doc["openapi"]), "3.") {
+		return nil, errors.New("only OpenAPI 3.x documents are supported")
+	}
+	info := asMap(spec.doc["info"])
+	spec.Title = asString(info["title"])
+	spec.Version = asString(info["version"])
+	spec.defs = map[string]any{}
+	for k, v := range asMap(asMap(spec.doc["components"])["schemas"]) {
+		spec.defs[k] = toJSONSchema(v)
+	}
+	if basePath == "" {
+		if servers, ok := spec.doc["servers"].([]any); ok && len(servers) > 0 {
+			if u, err := url.Parse(asString(asMap(servers[0])["url"])); err == nil && !strings.Contains(u.Path, "{") {
+				basePath = u.Path
+			}
+		}
+	}
+	spec.BasePath = strings.TrimSuffix(basePath, "/")
+	paths := asMap(spec.doc["paths"])
+	for _, path := range sortedKeys(paths) {
+		item := spec.deref(paths[path])
+		common, _ := item["parameters"].([]any)
+		for _, method := range httpMethods {
+			if op := asMap(item[method]); op != nil {
+				if err := spec.addOperation(method, path, common, op); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if len(spec.Operations) == 0 {
+		return nil, errors.New("spec has no operations")
+	}
+	// Paths without templates take precedence over templated ones, e.g. /pets/mine over /pets/{id}.
+	sort.SliceStable(spec.Operations, func(i, j int) bool {
+		return len(spec.Operations[i].pathKeys) < len(spec.Operations[j].pathKeys)
+	})
+	return spec, nil
+}
+
+func (s *OpenAPISpec) addOperation(method, path string, common []any, op map[string]any) error {
+	operation := &Operation{
+		ID:        asString(op["operationId"]),
+		Method:    strings.ToUpper(method),
+		Path:      path,
+		Counts:    &OperationCounts{},
+		spec:      s,
+		responses: asMap(op["responses"]),
+	}
+	if operation.ID == "" {
+		operation.ID = operation.Method + " " + path
+	}
+	expr := "^" + regexp.QuoteMeta(s.BasePath)
+	last := 0
+	for _, m := range pathParamRegex.FindAllStringSubmatchIndex(path, -1) {
+		expr += regexp.QuoteMeta(path[last:m[0]]) + "([^/]+)"
+		operation.pathKeys = append(operation.pathKeys, path[m[2]:m[3]])
+		last = m[1]
+	}
+	operation.regex = regexp.MustCompile(expr + regexp.QuoteMeta(path[last:]) + "$")
+
+	opParams, _ := op["parameters"].([]any)
+	params := map[string]*parameter{}
+	order := []string{}
+	for _, p := range append(append([]any{}, common...), opParams...) {
+		pm := s.deref(p)
+		if pm == nil {
+			continue
+		}
+		param := &parameter{name: asString(pm["name"]), in: asString(pm["in"]), schema: pm["schema"]}
+		param.required = pm["required"] == true || param.in == "path"
+		if param.schema != nil {
+			var err error
+			if param.validator, err = s.compile(param.schema); err != nil {
+				return fmt.Errorf("operation [%s] parameter [%s]: %s", operation.ID, param.name, err.Error())
+			}
+		}
+		key := param.in + ":" + param.name
+		if params[key] == nil {
+			order = append(order, key)
+		}
+		params[key] = param
+	}
+	for _, key := range order {
+		operation.params = append(operation.params, params[key])
+	}
+
+	if rb := s.deref(op["requestBody"]); rb != nil {
+		operation.body = &requestBody{required: rb["required"] == true, content: map[string]*jsonschema.Resolved{}}
+		for contentType, media := range asMap(rb["content"]) {
+			validator, err := s.compile(asMap(media)["schema"])
+			if err != nil {
+				return fmt.Errorf("operation [%s] request body [%s]: %s", operation.ID, contentType, err.Error())
+			}
+			operation.body.content[baseMediaType(contentType)] = validator
+		}
+	}
+	s.Operations = append(s.Operations, operation)
+	return nil
+}
+
+func baseMediaType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == constants.ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+func (s *OpenAPISpec) RuleName() string {
+	return s.Name
+}
+
+func (s *OpenAPISpec) ClearCounts() {
+	for _, op := range s.Operations {
+		op.lock.Lock()
+		op.Counts = &OperationCounts{}
+		op.lock.Unlock()
+	}
+}
+
+func (s *OpenAPISpec) Snapshot() *OpenAPISpec {
+	spec := &OpenAPISpec{Name: s.Name, Title: s.Title, Version: s.Version, BasePath: s.BasePath, Operations: []*Operation{}}
+	for _, op := range s.Operations {
+		op.lock.Lock()
+		counts := *op.Counts
+		spec.Operations = append(spec.Operations, &Operation{ID: op.ID, Method: op.Method, Path: op.Path, Counts: &counts})
+		op.lock.Unlock()
+	}
+	return spec
+}
+
+// find returns the operation of the port's specs that serves the request's method and path along with the path parameter values.
+// If the path matches some operations but not for the request's method, the methods allowed for the path are returned.
+// HEAD requests fall back to the GET operation of a path that doesn't declare HEAD.
+func find(port int, r *http.Request) (*Operation, map[string]string, []string) {
+	var fallback *Operation
+	var fallbackValues []string
+	allowed := []string{}
+	for _, s := range specs.List(port) {
+		for _, op := range s.Operations {
+			values := op.regex.FindStringSubmatch(r.URL.Path)
+			if values == nil {
+				continue
+			}
+			if op.Method == r.Method {
+				return op, op.pathValues(values), nil
+			}
+			if r.Method == http.MethodHead && op.Method == http.MethodGet && fallback == nil {
+				fallback, fallbackValues = op, values
+			}
+			allowed = append(allowed, op.Method)
+		}
+	}
+	if fallback != nil {
+		return fallback, fallback.pathValues(fallbackValues), nil
+	}
+	return nil, nil, allowed
+}
+
+func (op *Operation) pathValues(values []string) map[string]string {
+	pathValues := map[string]string{}
+	for i, k := range op.pathKeys {
+		pathValues[k] = values[i+1]
+	}
+	return pathValues
+}
+
+// validate checks the request's parameters and body against the operation, and returns the violations found.
+func (op *Operation) validate(r *http.Request, pathValues map[string]string, body []byte) (violations []string) {
+	for _, p := range op.params {
+		var values []string
+		switch p.in {
+		case "path":
+			if v, ok := pathValues[p.name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = r.URL.Query()[p.name]
+		case "header":
+			values = r.Header.Values(p.name)
+		case "cookie":
+			if c, err := r.Cookie(p.name); err == nil {
+				values = []string{c.Value}
+			}
+		}
+		if len(values) == 0 {
+			if p.required {
+				violations = append(violations, fmt.Sprintf("%s parameter [%s] is required", p.in, p.name))
+			}
+			continue
+		}
+		if p.validator == nil {
+			continue
+		}
+		if value, err := op.spec.coerce(values, p.schema); err != nil {
+			violations = append(violations, fmt.Sprintf("%s parameter [%s]: %s", p.in, p.name, err.Error()))
+		} else if err := p.validator.Validate(value); err != nil {
+			violations = append(violations, fmt.Sprintf("%s parameter [%s]: %s", p.in, p.name, err.Error()))
+		}
+	}
+	if op.body == nil {
+		return
+	}
+	if len(body) == 0 {
+		if op.body.required {
+			violations = append(violations, "request body is required")
+		}
+		return
+	}
+	mediaType := baseMediaType(r.Header.Get(constants.HeaderContentType))
+	validator, found := op.body.content[mediaType]
+	if !found {
+		for _, wildcard := range []string{strings.Split(mediaType, "/")[0] + "/*", "*/*"} {
+			if validator, found = op.body.content[wildcard]; found {
+				break
+			}
+		}
+	}
+	if !found {
+		accepted := []string{}
+		for contentType := range op.body.content {
+			accepted = append(accepted, contentType)
+		}
+		sort.Strings(accepted)
+		violations = append(violations, fmt.Sprintf("request content type [%s] is not one of %v", mediaType, accepted))
+	} else if validator != nil && isJSONMediaType(mediaType) {
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			violations = append(violations, fmt.Sprintf("request body is not valid JSON: %s", err.Error()))
+		} else if err := validator.Validate(v); err != nil {
+			violations = append(violations, fmt.Sprintf("request body: %s", err.Error()))
+		}
+	}
+	return
+}
+
+func parsePrefer(r *http.Request) map[string]string {
+	prefs := map[string]string{}
+	for _, h := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(h, ",") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(pref), "="); ok {
+				prefs[strings.ToLower(k)] = strings.Trim(v, `"`)
+			}
+		}
+	}
+	return prefs
+}
+
+// pickResponse selects the response to send: the one for the status code requested via a `Prefer: code=<status>` header,
+// else the lowest declared 2xx response, else the default response.
+func (op *Operation) pickResponse(code string) (int, map[string]any) {
+	if code != "" {
+		if status, err := strconv.Atoi(code); err == nil {
+			for _, k := range []string{code, code[:1] + "XX", "default"} {
+				if resp := op.spec.deref(op.responses[k]); resp != nil {
+					return status, resp
+				}
+			}
+		}
+	}
+	codes := sortedKeys(op.responses)
+	for _, k := range codes {
+		if strings.HasPrefix(k, "2") {
+			status, err := strconv.Atoi(k)
+			if err != nil {
+				status = http.StatusOK
+			}
+			return status, op.spec.deref(op.responses[k])
+		}
+	}
+	if resp := op.spec.deref(op.responses["default"]); resp != nil {
+		return http.StatusOK, resp
+	}
+	for _, k := range codes {
+		if status, err := strconv.Atoi(k); err == nil {
+			return status, op.spec.deref(op.responses[k])
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// pickContent selects the response media type that best matches the request's Accept header,
+// preferring JSON when the client accepts anything.
+func pickContent(content map[string]any, accept string) (string, map[string]any) {
+	keys := sortedKeys(content)
+	if len(keys) == 0 {
+		return "", nil
+	}
+	preferred := keys[0]
+	for _, k := range keys {
+		if isJSONMediaType(baseMediaType(k)) {
+			preferred = k
+			break
+		}
+	}
+	for _, a := range strings.Split(accept, ",") {
+		a = baseMediaType(a)
+		if a == "" || a == "*/*" {
+			break
+		}
+		for _, k := range keys {
+			mt := baseMediaType(k)
+			if mt == a || strings.HasSuffix(a, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(a, "*")) {
+				return k, asMap(content[k])
+			}
+		}
+	}
+	return preferred, asMap(content[preferred])
+}
+
+// example returns the example to send for a response media: the named example if requested via a `Prefer: example=<name>` header,
+// else the media's example, else its first example, else an example derived from the media's schema.
+func (s *OpenAPISpec) example(media map[string]any, name string) (any, bool) {
+	examples := asMap(media["examples"])
+	if name != "" {
+		if ex := s.deref(examples[name]); ex != nil {
+			return ex["value"], true
+		}
+	}
+	if v, ok := media["example"]; ok {
+		return v, true
+	}
+	for _, k := range sortedKeys(examples) {
+		if ex := s.deref(examples[k]); ex != nil {
+			if v, ok := ex["value"]; ok {
+				return v, true
+			}
+		}
+	}
+	if schema, ok := media["schema"]; ok {
+		return s.generate(schema, 0), true
+	}
+	return nil, false
+}
+
+func (op *Operation) respond(w http.ResponseWriter, r *http.Request, pathValues map[string]string) int {
+	prefs := parsePrefer(r)
+	status, resp := op.pickResponse(prefs["code"])
+	for _, h := range sortedKeys(asMap(resp["headers"])) {
+		if strings.EqualFold(h, constants.HeaderContentType) {
+			continue
+		}
+		header := op.spec.deref(asMap(resp["headers"])[h])
+		if v, ok := op.spec.example(header, ""); ok && v != nil {
+			if s, ok := v.(string); ok {
+				w.Header().Set(h, s)
+			} else if b, err := json.Marshal(v); err == nil {
+				w.Header().Set(h, string(b))
+			}
+		}
+	}
+	contentType, media := pickContent(asMap(resp["content"]), r.Header.Get("Accept"))
+	var body []byte
+	if media != nil {
+		if v, ok := op.spec.example(media, prefs["example"]); ok {
+			if s, ok := v.(string); ok && !isJSONMediaType(baseMediaType(contentType)) {
+				body = []byte(s)
+			} else {
+				body, _ = json.Marshal(v)
+			}
+		}
+	}
+	if contentType != "" {
+		w.Header().Set(constants.HeaderContentType, contentType)
+	}
+	w.WriteHeader(status)
+	if contentType != "" {
+		payload.Respond(w, r, payload.NewContentPayload(body, contentType), pathValues)
+	}
+	return status
+}
+
+func serve(port int, w http.ResponseWriter, r *http.Request) bool {
+	op, pathValues, allowed := find(port, r)
+	if op == nil {
+		if len(allowed) == 0 {
+			return false
+		}
+		msg := fmt.Sprintf("OpenAPI: method [%s] not allowed for URI [%s], allowed %v", r.Method, r.RequestURI, allowed)
+		util.AddLogMessage(msg, r)
+		util.UpdateTrafficEventDetails(r, msg)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSON)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		util.WriteJson(w, map[string]any{"error": "method not allowed", "allowed": allowed})
+		return true
+	}
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	violations := op.validate(r, pathValues, body)
+	op.lock.Lock()
+	op.Counts.Requests++
+	if len(violations) > 0 {
+		op.Counts.Invalid++
+	}
+	op.lock.Unlock()
+	w.Header().Set(constants.HeaderGotoOpenAPIOperation, op.spec.Name+":"+op.ID)
+	if len(violations) > 0 {
+		msg := fmt.Sprintf("OpenAPI: request for operation [%s] of spec [%s] failed validation: %v", op.ID, op.spec.Name, violations)
+		util.AddLogMessage(msg, r)
+		util.UpdateTrafficEventDetails(r, msg)
+		w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSON)
+		w.WriteHeader(http.StatusBadRequest)
+		util.WriteJson(w, map[string]any{"error": "request validation failed", "operation": op.ID, "violations": violations})
+		return true
+	}
+	status := op.respond(w, r, pathValues)
+	msg := fmt.Sprintf("OpenAPI: responded with status [%d] for operation [%s] of spec [%s]", status, op.ID, op.spec.Name)
+	util.AddLogMessage(msg, r)
+	util.UpdateTrafficEventDetails(r, msg)
+	return true
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		if !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest && serve(util.GetRequestOrListenerPortNum(r), w, r) {
+			return
+		}
+		if next != nil {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
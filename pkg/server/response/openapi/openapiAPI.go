@@ -0,0 +1,58 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"goto/pkg/server/middleware"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/util"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	Middleware = middleware.NewMiddleware("openapi", setRoutes, middlewareFunc)
+	api        = &rules.API[*OpenAPISpec]{
+		Rules: specs,
+		Kind:  "OpenAPI spec",
+		Read:  readSpec,
+		Added: func(port int, spec *OpenAPISpec) string {
+			return fmt.Sprintf("Port [%d] added OpenAPI spec [%s] with [%d] operations under base path [%s]", port, spec.Name, len(spec.Operations), spec.BasePath)
+		},
+	}
+)
+
+func setRoutes(r *mux.Router) {
+	openapiRouter := util.PathRouter(r, "/openapi")
+	util.AddRouteQO(openapiRouter, "/add/{name}", api.AddRule, "basePath", "POST", "PUT")
+	util.AddRoute(openapiRouter, "/remove/{name}", api.RemoveRule, "POST", "PUT")
+	util.AddRoute(openapiRouter, "/clear", api.ClearRules, "POST")
+	util.AddRoute(openapiRouter, "/counts/clear", api.ClearCounts, "POST")
+	util.AddRoute(openapiRouter, "", api.GetRules, "GET")
+}
+
+func readSpec(r *http.Request, port int) (*OpenAPISpec, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return newSpec(util.GetStringParamValue(r, "name"), body, util.GetStringParamValue(r, "basePath"))
+}
@@ -0,0 +1,355 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/google/uuid"
+)
+
+const (
+	componentSchemasRef = "#/components/schemas/"
+	defsRef             = "#/$defs/"
+	maxExampleDepth     = 6
+	maxRefHops          = 16
+)
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func asString(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pointer resolves a local JSON pointer reference against the spec document.
+func (s *OpenAPISpec) pointer(ref string) any {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var cur any = s.doc
+	for _, token := range strings.Split(ref[2:], "/") {
+		if t, err := url.PathUnescape(token); err == nil {
+			token = t
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch c := cur.(type) {
+		case map[string]any:
+			cur = c[token]
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil
+			}
+			cur = c[i]
+		default:
+			return nil
+		}
+	}
+	return cur
+}
+
+// deref follows the $ref chain of a spec object until it reaches an object without a $ref.
+func (s *OpenAPISpec) deref(v any) map[string]any {
+	m := asMap(v)
+	for i := 0; m != nil && i < maxRefHops; i++ {
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m
+		}
+		m = asMap(s.pointer(ref))
+	}
+	return m
+}
+
+// toJSONSchema deep copies a spec schema, rewriting component refs to $defs and converting the
+// OpenAPI 3.0 flavors of nullable and exclusive bounds to their JSON Schema equivalents.
+func toJSONSchema(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, val := range t {
+			m[k] = toJSONSchema(val)
+		}
+		if ref, ok := m["$ref"].(string); ok && strings.HasPrefix(ref, componentSchemasRef) {
+			m["$ref"] = defsRef + strings.TrimPrefix(ref, componentSchemasRef)
+		}
+		if nullable, ok := m["nullable"].(bool); ok {
+			delete(m, "nullable")
+			if nullable {
+				switch typ := m["type"].(type) {
+				case string:
+					m["type"] = []any{typ, "null"}
+				case []any:
+					m["type"] = append(typ, "null")
+				}
+				if enum, ok := m["enum"].([]any); ok {
+					m["enum"] = append(enum, nil)
+				}
+			}
+		}
+		for exclusive, bound := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+			if b, ok := m[exclusive].(bool); ok {
+				delete(m, exclusive)
+				if n, ok := m[bound]; ok && b {
+					m[exclusive] = n
+					delete(m, bound)
+				}
+			}
+		}
+		return m
+	case []any:
+		arr := make([]any, len(t))
+		for i, val := range t {
+			arr[i] = toJSONSchema(val)
+		}
+		return arr
+	}
+	return v
+}
+
+// compile builds a validator for a spec schema, carrying the spec's component schemas along as $defs.
+func (s *OpenAPISpec) compile(schema any) (*jsonschema.Resolved, error) {
+	root := asMap(toJSONSchema(schema))
+	if root == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Contains(b, []byte(defsRef)) {
+		root["$defs"] = s.defs
+		if b, err = json.Marshal(root); err != nil {
+			return nil, err
+		}
+	}
+	js := &jsonschema.Schema{}
+	if err := json.Unmarshal(b, js); err != nil {
+		return nil, err
+	}
+	return js.Resolve(nil)
+}
+
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if schema["properties"] != nil {
+		return "object"
+	}
+	if schema["items"] != nil {
+		return "array"
+	}
+	return ""
+}
+
+// coerce converts the text values of a parameter to the JSON type that the parameter's schema expects,
+// so that they can be validated like a JSON value. Arrays accept repeated or comma separated values.
+func (s *OpenAPISpec) coerce(values []string, schema any) (any, error) {
+	m := s.deref(schema)
+	if schemaType(m) == "array" {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		items := s.deref(m["items"])
+		arr := make([]any, len(values))
+		for i, v := range values {
+			item, err := coerceValue(v, items)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = item
+		}
+		return arr, nil
+	}
+	return coerceValue(values[0], m)
+}
+
+func coerceValue(v string, schema map[string]any) (any, error) {
+	switch schemaType(schema) {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return float64(n), nil
+		}
+		return nil, fmt.Errorf("[%s] is not an integer", v)
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n, nil
+		}
+		return nil, fmt.Errorf("[%s] is not a number", v)
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b, nil
+		}
+		return nil, fmt.Errorf("[%s] is not a boolean", v)
+	}
+	return v, nil
+}
+
+// generate produces an example value for a schema, preferring the examples, defaults and enums
+// declared in the schema over synthesized values.
+func (s *OpenAPISpec) generate(schema any, depth int) any {
+	m := s.deref(schema)
+	if m == nil || depth > maxExampleDepth {
+		return nil
+	}
+	if v, ok := m["example"]; ok {
+		return v
+	}
+	if arr, ok := m["examples"].([]any); ok && len(arr) > 0 {
+		return arr[0]
+	}
+	for _, k := range []string{"default", "const"} {
+		if v, ok := m[k]; ok {
+			return v
+		}
+	}
+	if enum, ok := m["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if all, ok := m["allOf"].([]any); ok && len(all) > 0 {
+		merged := map[string]any{}
+		for _, sub := range all {
+			v := s.generate(sub, depth+1)
+			if obj, ok := v.(map[string]any); ok {
+				for k, val := range obj {
+					merged[k] = val
+				}
+			} else if v != nil {
+				return v
+			}
+		}
+		if props := asMap(m["properties"]); props != nil {
+			for k, val := range s.generate(map[string]any{"type": "object", "properties": props}, depth).(map[string]any) {
+				merged[k] = val
+			}
+		}
+		return merged
+	}
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if arr, ok := m[k].([]any); ok && len(arr) > 0 {
+			return s.generate(arr[0], depth+1)
+		}
+	}
+	switch schemaType(m) {
+	case "object":
+		obj := map[string]any{}
+		props := asMap(m["properties"])
+		for _, k := range sortedKeys(props) {
+			if p := s.deref(props[k]); p != nil && p["writeOnly"] != true {
+				obj[k] = s.generate(p, depth+1)
+			}
+		}
+		return obj
+	case "array":
+		count := 1
+		if n, ok := m["minItems"].(float64); ok && int(n) > count {
+			count = int(n)
+		}
+		arr := make([]any, count)
+		for i := range arr {
+			arr[i] = s.generate(m["items"], depth+1)
+		}
+		return arr
+	case "string":
+		return exampleString(m)
+	case "integer":
+		return int64(exampleNumber(m, 1))
+	case "number":
+		return exampleNumber(m, 0.5)
+	case "boolean":
+		return true
+	}
+	return nil
+}
+
+func exampleNumber(schema map[string]any, step float64) float64 {
+	if n, ok := schema["exclusiveMinimum"].(float64); ok {
+		return n + step
+	}
+	if n, ok := schema["minimum"].(float64); ok {
+		if schema["exclusiveMinimum"] == true {
+			return n + step
+		}
+		return n
+	}
+	if n, ok := schema["maximum"].(float64); ok && n < 0 {
+		return n
+	}
+	return 0
+}
+
+func exampleString(schema map[string]any) string {
+	s := "string"
+	switch asString(schema["format"]) {
+	case "date-time":
+		return time.Now().UTC().Format(time.RFC3339)
+	case "date":
+		return time.Now().UTC().Format(time.DateOnly)
+	case "time":
+		return time.Now().UTC().Format(time.TimeOnly)
+	case "uuid":
+		return uuid.NewString()
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+	if n, ok := schema["minLength"].(float64); ok && int(n) > len(s) {
+		s += strings.Repeat("x", int(n)-len(s))
+	}
+	if n, ok := schema["maxLength"].(float64); ok && int(n) < len(s) {
+		s = s[:int(n)]
+	}
+	return s
+}
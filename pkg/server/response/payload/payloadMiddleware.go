@@ -61,6 +61,11 @@ func middlewareFunc(next http.Handler) http.Handler {
 	})
 }
 
+// Respond writes the given payload as the response, filling in the given captures.
+func Respond(w http.ResponseWriter, r *http.Request, rp *ResponsePayload, captures map[string]string) {
+	processPayload(w, r, rp, captures)
+}
+
 func processPayload(w http.ResponseWriter, r *http.Request, rp *ResponsePayload, captures map[string]string) {
 	var payload []byte
 	contentType := ""
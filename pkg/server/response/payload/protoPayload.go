@@ -204,6 +204,16 @@ func NewResponsePayload(payload []byte, matches []*RequestMatch, capture *Reques
 	}
 }
 
+// NewContentPayload creates a payload that's served as is with the given content type, for features that decide on their own when to serve it.
+func NewContentPayload(payload []byte, contentType string) *ResponsePayload {
+	return &ResponsePayload{
+		Payload:     payload,
+		ContentType: contentType,
+		IsJSON:      strings.EqualFold(contentType, constants.ContentTypeJSON),
+		IsBinary:    util.IsBinaryContentType(contentType),
+	}
+}
+
 func (rp *ResponsePayload) Process() error {
 	if len(rp.RequestMatches) == 0 {
 		return fmt.Errorf("Matches required")
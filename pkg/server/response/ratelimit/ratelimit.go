@@ -0,0 +1,359 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goto/pkg/server/response/rules"
+	"goto/pkg/server/response/status"
+	"goto/pkg/util"
+)
+
+type LimitCounts struct {
+	Allowed int `json:"allowed"`
+	Limited int `json:"limited"`
+}
+
+type RateLimit struct {
+	Name        string                  `json:"name"`
+	Match       *status.StatusMatch     `json:"match"`
+	Algorithm   string                  `json:"algorithm"`
+	Limit       int                     `json:"limit"`
+	Window      string                  `json:"window"`
+	Burst       int                     `json:"burst"`
+	By          string                  `json:"by"`
+	Status      int                     `json:"status"`
+	RetryAfter  string                  `json:"retryAfter"`
+	NoHeaders   bool                    `json:"noHeaders"`
+	Counts      *LimitCounts            `json:"counts"`
+	CountsByKey map[string]*LimitCounts `json:"countsByKey,omitempty"`
+	windowD     time.Duration
+	retryAfterD time.Duration
+	keyHeader   string
+	buckets     map[string]*bucket
+	lock        sync.Mutex
+}
+
+// bucket holds the state of one key of a limit: the available tokens for a token bucket,
+// or the request count of the current window for a fixed window.
+type bucket struct {
+	tokens      float64
+	count       int
+	windowStart time.Time
+	updated     time.Time
+}
+
+// decision is the outcome of a limit check, used to fill the rate limit response headers.
+type decision struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+const (
+	AlgorithmTokenBucket = "tokenBucket"
+	AlgorithmFixedWindow = "fixedWindow"
+
+	maxLimitKeys = 10000
+)
+
+var (
+	limits     = rules.NewRegistry[*RateLimit]("limits")
+	algorithms = map[string]string{"tokenbucket": AlgorithmTokenBucket, "token-bucket": AlgorithmTokenBucket, "fixedwindow": AlgorithmFixedWindow, "fixed-window": AlgorithmFixedWindow}
+)
+
+func (rl *RateLimit) init() (err error) {
+	if rl.Name == "" {
+		return errors.New("rate limit needs a name")
+	}
+	if rl.Limit <= 0 {
+		return errors.New("rate limit needs a positive limit")
+	}
+	if rl.Window == "" {
+		rl.Window = "1s"
+	}
+	if rl.windowD, err = time.ParseDuration(rl.Window); err != nil || rl.windowD <= 0 {
+		return errors.New("invalid rate limit window")
+	}
+	if rl.Algorithm == "" {
+		rl.Algorithm = AlgorithmTokenBucket
+	} else if a := algorithms[strings.ToLower(rl.Algorithm)]; a != "" {
+		rl.Algorithm = a
+	} else {
+		return fmt.Errorf("invalid rate limit algorithm [%s]", rl.Algorithm)
+	}
+	if rl.Burst < 0 {
+		return errors.New("invalid rate limit burst")
+	} else if rl.Burst == 0 {
+		rl.Burst = rl.Limit
+	}
+	by := strings.ToLower(strings.TrimSpace(rl.By))
+	if strings.HasPrefix(by, "header:") {
+		if rl.keyHeader = strings.TrimSpace(rl.By[len("header:"):]); rl.keyHeader == "" {
+			return errors.New("rate limit needs a header name to limit by")
+		}
+	} else if by != "" && by != "ip" {
+		return fmt.Errorf("invalid rate limit key [%s]", rl.By)
+	}
+	if rl.Status == 0 {
+		rl.Status = http.StatusTooManyRequests
+	} else if rl.Status < 100 || rl.Status > 599 {
+		return errors.New("invalid rate limit status")
+	}
+	if rl.RetryAfter != "" {
+		if rl.retryAfterD, err = time.ParseDuration(rl.RetryAfter); err != nil || rl.retryAfterD < 0 {
+			return errors.New("invalid rate limit retryAfter")
+		}
+	}
+	if rl.Match == nil {
+		rl.Match = &status.StatusMatch{}
+	}
+	if err = rl.Match.Prepare(); err != nil {
+		return err
+	}
+	rl.Counts = &LimitCounts{}
+	rl.CountsByKey = map[string]*LimitCounts{}
+	rl.buckets = map[string]*bucket{}
+	return nil
+}
+
+func (rl *RateLimit) key(r *http.Request) string {
+	if rl.keyHeader != "" {
+		return r.Header.Get(rl.keyHeader)
+	} else if strings.EqualFold(rl.By, "ip") {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			return host
+		}
+		return r.RemoteAddr
+	}
+	return ""
+}
+
+// decide checks the request against the limit's bucket for the given key without consuming from it.
+// The limit must be locked by the caller.
+func (rl *RateLimit) decide(key string, now time.Time) (*bucket, *decision) {
+	b := rl.buckets[key]
+	if b == nil {
+		if len(rl.buckets) >= maxLimitKeys {
+			rl.evictIdle(now)
+		}
+		b = &bucket{tokens: float64(rl.Burst), windowStart: now, updated: now}
+		rl.buckets[key] = b
+	}
+	d := &decision{}
+	if rl.Algorithm == AlgorithmFixedWindow {
+		d.limit = rl.Limit
+		if elapsed := now.Sub(b.windowStart); elapsed >= rl.windowD {
+			b.windowStart = b.windowStart.Add(elapsed.Truncate(rl.windowD))
+			b.count = 0
+		}
+		d.reset = b.windowStart.Add(rl.windowD).Sub(now)
+		if b.count < rl.Limit {
+			d.allowed = true
+		} else {
+			d.retryAfter = d.reset
+		}
+		d.remaining = rl.Limit - b.count
+	} else {
+		d.limit = rl.Burst
+		b.tokens = math.Min(float64(rl.Burst), b.tokens+now.Sub(b.updated).Seconds()*rl.perSecond())
+		b.updated = now
+		if b.tokens >= 1 {
+			d.allowed = true
+		} else {
+			d.retryAfter = time.Duration((1 - b.tokens) / rl.perSecond() * float64(time.Second))
+		}
+		d.remaining = int(b.tokens)
+		d.reset = time.Duration((float64(rl.Burst) - b.tokens) / rl.perSecond() * float64(time.Second))
+	}
+	if rl.retryAfterD > 0 && !d.allowed {
+		d.retryAfter = rl.retryAfterD
+	}
+	return b, d
+}
+
+// consume takes a token (or a slot in the current window) from the bucket of an allowed request.
+func (rl *RateLimit) consume(b *bucket, d *decision) {
+	if rl.Algorithm == AlgorithmFixedWindow {
+		b.count++
+		d.remaining = rl.Limit - b.count
+	} else {
+		b.tokens--
+		d.remaining = int(b.tokens)
+		d.reset = time.Duration((float64(rl.Burst) - b.tokens) / rl.perSecond() * float64(time.Second))
+	}
+}
+
+func (rl *RateLimit) perSecond() float64 {
+	return float64(rl.Limit) / rl.windowD.Seconds()
+}
+
+func (rl *RateLimit) count(key string, allowed bool) {
+	counts := rl.CountsByKey[key]
+	if counts == nil && len(rl.CountsByKey) < maxLimitKeys {
+		counts = &LimitCounts{}
+		rl.CountsByKey[key] = counts
+	}
+	if allowed {
+		rl.Counts.Allowed++
+		if counts != nil {
+			counts.Allowed++
+		}
+	} else {
+		rl.Counts.Limited++
+		if counts != nil {
+			counts.Limited++
+		}
+	}
+}
+
+// evictIdle drops the keys whose bucket has fully refilled or whose window has ended, since their
+// state is the same as that of a new key.
+func (rl *RateLimit) evictIdle(now time.Time) {
+	for key, b := range rl.buckets {
+		if rl.Algorithm == AlgorithmFixedWindow {
+			if now.Sub(b.windowStart) >= rl.windowD {
+				delete(rl.buckets, key)
+			}
+		} else if float64(rl.Limit)*now.Sub(b.updated).Seconds()/rl.windowD.Seconds()+b.tokens >= float64(rl.Burst) {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+func (rl *RateLimit) RuleName() string {
+	return rl.Name
+}
+
+func (rl *RateLimit) ClearCounts() {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	rl.Counts = &LimitCounts{}
+	rl.CountsByKey = map[string]*LimitCounts{}
+}
+
+func (rl *RateLimit) Snapshot() *RateLimit {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	rl2 := &RateLimit{Name: rl.Name, Match: rl.Match, Algorithm: rl.Algorithm, Limit: rl.Limit, Window: rl.Window, Burst: rl.Burst,
+		By: rl.By, Status: rl.Status, RetryAfter: rl.RetryAfter, NoHeaders: rl.NoHeaders, CountsByKey: map[string]*LimitCounts{}}
+	counts := *rl.Counts
+	rl2.Counts = &counts
+	if rl.By != "" {
+		for key, c := range rl.CountsByKey {
+			kc := *c
+			rl2.CountsByKey[key] = &kc
+		}
+	}
+	return rl2
+}
+
+// check runs the request through every matching limit of the port. If any limit has no capacity left,
+// the request is rejected by the first such limit and nothing is consumed from the other limits.
+// Otherwise the request consumes from every matching limit, and the limit with the fewest remaining
+// requests is returned.
+func check(port int, r *http.Request) (*RateLimit, *decision) {
+	matched := []*RateLimit{}
+	for _, rl := range limits.List(port) {
+		if rl.Match.Matches(r.RequestURI, r.Header, r.Method) {
+			matched = append(matched, rl)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+	// Limits are locked in name order so that concurrent checks of overlapping limits can't deadlock.
+	locked := append([]*RateLimit{}, matched...)
+	sort.Slice(locked, func(i, j int) bool { return locked[i].Name < locked[j].Name })
+	for _, rl := range locked {
+		rl.lock.Lock()
+		defer rl.lock.Unlock()
+	}
+	now := time.Now()
+	keys := make([]string, len(matched))
+	buckets := make([]*bucket, len(matched))
+	decisions := make([]*decision, len(matched))
+	var rejected *RateLimit
+	var rejectedDecision *decision
+	for i, rl := range matched {
+		keys[i] = rl.key(r)
+		buckets[i], decisions[i] = rl.decide(keys[i], now)
+		if !decisions[i].allowed {
+			rl.count(keys[i], false)
+			if rejected == nil {
+				rejected, rejectedDecision = rl, decisions[i]
+			}
+		}
+	}
+	if rejected != nil {
+		return rejected, rejectedDecision
+	}
+	var tightest *RateLimit
+	var tightestDecision *decision
+	for i, rl := range matched {
+		rl.consume(buckets[i], decisions[i])
+		rl.count(keys[i], true)
+		if tightestDecision == nil || decisions[i].remaining < tightestDecision.remaining {
+			tightest, tightestDecision = rl, decisions[i]
+		}
+	}
+	return tightest, tightestDecision
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		var rl *RateLimit
+		var d *decision
+		if !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest {
+			rl, d = check(util.GetRequestOrListenerPortNum(r), r)
+		}
+		if d != nil && !rl.NoHeaders {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.remaining))
+			w.Header().Set("X-RateLimit-Reset", seconds(d.reset))
+		}
+		if d != nil && !d.allowed {
+			w.Header().Set("Retry-After", seconds(d.retryAfter))
+			msg := fmt.Sprintf("Rate limit [%s] rejected URI [%s] with status [%d]", rl.Name, r.RequestURI, rl.Status)
+			util.AddLogMessage(msg, r)
+			util.UpdateTrafficEventDetails(r, msg)
+			w.WriteHeader(rl.Status)
+			fmt.Fprintln(w, "rate limit exceeded")
+			return
+		}
+		if next != nil {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
@@ -0,0 +1,493 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"goto/pkg/constants"
+	"goto/pkg/memory"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/util"
+
+	"github.com/google/uuid"
+)
+
+type ResourceCounts struct {
+	Listed             int `json:"listed"`
+	Read               int `json:"read"`
+	Created            int `json:"created"`
+	Updated            int `json:"updated"`
+	Deleted            int `json:"deleted"`
+	NotFound           int `json:"notFound"`
+	PreconditionFailed int `json:"preconditionFailed"`
+}
+
+// Resource is an in-memory REST collection served under a URI prefix, with its items kept as JSON
+// in a memory context of the port, keyed by item ID.
+type Resource struct {
+	Name     string           `json:"name"`
+	Prefix   string           `json:"prefix"`
+	IDField  string           `json:"idField"`
+	IDType   string           `json:"idType"`
+	PageSize int              `json:"pageSize"`
+	Seed     []map[string]any `json:"seed,omitempty"`
+	Items    int              `json:"items"`
+	Counts   *ResourceCounts  `json:"counts"`
+	memory   *memory.Memory
+	nextID   int
+	lock     sync.Mutex
+}
+
+var (
+	resources = rules.NewRegistry[*Resource]("resources")
+)
+
+func memoryContext(name string) string {
+	return "resource:" + name
+}
+
+func (res *Resource) init() error {
+	if res.Name == "" {
+		return errors.New("resource needs a name")
+	}
+	res.Prefix = "/" + strings.Trim(res.Prefix, "/")
+	if res.Prefix == "/" {
+		return errors.New("resource needs a URI prefix")
+	}
+	if res.IDField == "" {
+		res.IDField = "id"
+	}
+	switch res.IDType {
+	case "":
+		res.IDType = "int"
+	case "int", "uuid":
+	default:
+		return fmt.Errorf("invalid id type [%s], must be int or uuid", res.IDType)
+	}
+	if res.PageSize < 0 {
+		return errors.New("invalid page size")
+	}
+	return nil
+}
+
+// reset replaces the resource's memory context on the port with a fresh one holding the seed items.
+func (res *Resource) reset(port int) {
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	res.memory = memory.GetMemoryManager(port).AddContext(memoryContext(res.Name))
+	res.nextID = 1
+	res.Counts = &ResourceCounts{}
+	for _, item := range res.Seed {
+		copied := make(map[string]any, len(item))
+		for k, v := range item {
+			copied[k] = v
+		}
+		res.store(res.assignID(copied), copied)
+	}
+}
+
+// assignID returns the ID of the item, generating one and setting it on the item if the item doesn't carry one.
+func (res *Resource) assignID(item map[string]any) string {
+	if id := idString(item[res.IDField]); id != "" {
+		return id
+	}
+	var id string
+	if res.IDType == "uuid" {
+		id = uuid.NewString()
+		item[res.IDField] = id
+	} else {
+		for {
+			id = strconv.Itoa(res.nextID)
+			if _, found := res.memory.Lookup(id); !found {
+				break
+			}
+			res.nextID++
+		}
+		item[res.IDField] = res.nextID
+		res.nextID++
+	}
+	return id
+}
+
+func idString(v any) string {
+	switch id := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return id
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// store saves the item's JSON, and keeps the next generated integer ID ahead of the integer IDs in use.
+func (res *Resource) store(id string, item map[string]any) string {
+	b, _ := json.Marshal(item)
+	res.memory.Add(id, string(b))
+	if n, err := strconv.Atoi(id); err == nil && n >= res.nextID {
+		res.nextID = n + 1
+	}
+	return string(b)
+}
+
+func etag(value string) string {
+	sum := sha1.Sum([]byte(value))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches checks an If-Match or If-None-Match header value against the current item's ETag,
+// where an empty tag means that the item doesn't exist.
+func etagMatches(header, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" && tag != "" || t == tag && tag != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (res *Resource) RuleName() string {
+	return res.Name
+}
+
+func (res *Resource) ClearCounts() {
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	res.Counts = &ResourceCounts{}
+}
+
+func (res *Resource) Snapshot() *Resource {
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	counts := *res.Counts
+	return &Resource{Name: res.Name, Prefix: res.Prefix, IDField: res.IDField, IDType: res.IDType,
+		PageSize: res.PageSize, Seed: res.Seed, Items: len(res.memory.Snapshot()), Counts: &counts}
+}
+
+// find returns the port's resource whose prefix the request's path falls under, along with the item ID from the path
+// if the path addresses an item. Paths nested deeper than an item are left alone.
+func find(port int, path string) (*Resource, string, bool) {
+	for _, res := range resources.List(port) {
+		if path == res.Prefix || path == res.Prefix+"/" {
+			return res, "", true
+		}
+		if id, ok := strings.CutPrefix(path, res.Prefix+"/"); ok && !strings.Contains(id, "/") {
+			return res, id, true
+		}
+	}
+	return nil, "", false
+}
+
+func readItem(r *http.Request) (map[string]any, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	item := map[string]any{}
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, fmt.Errorf("request body must be a JSON object: %s", err.Error())
+	}
+	return item, nil
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to the target.
+func mergePatch(target, patch map[string]any) {
+	for k, v := range patch {
+		if v == nil {
+			delete(target, k)
+		} else if p, ok := v.(map[string]any); ok {
+			t, ok := target[k].(map[string]any)
+			if !ok {
+				t = map[string]any{}
+			}
+			mergePatch(t, p)
+			target[k] = t
+		} else {
+			target[k] = v
+		}
+	}
+}
+
+func writeItem(w http.ResponseWriter, status int, value string) {
+	w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSON)
+	w.Header().Set("ETag", etag(value))
+	w.WriteHeader(status)
+	fmt.Fprintln(w, value)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSON)
+	w.WriteHeader(status)
+	util.WriteJson(w, map[string]any{"error": msg})
+}
+
+func (res *Resource) list(w http.ResponseWriter, r *http.Request) string {
+	items := res.memory.Snapshot()
+	query := r.URL.Query()
+	ids := []string{}
+	for id, value := range items {
+		if len(query) > 0 && !matchesFilters(value, query) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sortIDs(ids)
+	total := len(ids)
+	pageSize := res.PageSize
+	if n, err := strconv.Atoi(query.Get("pageSize")); err == nil && n > 0 {
+		pageSize = n
+	}
+	page := 1
+	if n, err := strconv.Atoi(query.Get("page")); err == nil && n > 0 {
+		page = n
+	}
+	if pageSize > 0 {
+		from := min((page-1)*pageSize, total)
+		to := min(from+pageSize, total)
+		ids = ids[from:to]
+		links := []string{}
+		if to < total {
+			links = append(links, pageLink(r, page+1, pageSize, "next"))
+		}
+		if page > 1 {
+			links = append(links, pageLink(r, page-1, pageSize, "prev"))
+		}
+		if len(links) > 0 {
+			w.Header().Set("Link", strings.Join(links, ", "))
+		}
+	}
+	list := make([]json.RawMessage, len(ids))
+	for i, id := range ids {
+		list[i] = json.RawMessage(items[id])
+	}
+	b, _ := json.Marshal(list)
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, string(b))
+	res.lock.Lock()
+	res.Counts.Listed++
+	res.lock.Unlock()
+	return fmt.Sprintf("listed [%d] of [%d] items", len(ids), total)
+}
+
+// matchesFilters checks the item's top-level fields against the request's query params, other than the paging params.
+func matchesFilters(value string, query map[string][]string) bool {
+	item := map[string]any{}
+	if json.Unmarshal([]byte(value), &item) != nil {
+		return false
+	}
+	for k, values := range query {
+		if k == "page" || k == "pageSize" {
+			continue
+		}
+		if idString(item[k]) != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortIDs sorts integer IDs numerically, and any other IDs as text after the integer ones.
+func sortIDs(ids []string) {
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil:
+			return true
+		case errB == nil:
+			return false
+		}
+		return ids[i] < ids[j]
+	})
+}
+
+func pageLink(r *http.Request, page, pageSize int, rel string) string {
+	u := *r.URL
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("pageSize", strconv.Itoa(pageSize))
+	u.RawQuery = query.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), rel)
+}
+
+func (res *Resource) create(w http.ResponseWriter, r *http.Request) string {
+	item, err := readItem(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return err.Error()
+	}
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	id := res.assignID(item)
+	if _, found := res.memory.Lookup(id); found {
+		writeError(w, http.StatusConflict, fmt.Sprintf("item [%s] already exists", id))
+		return fmt.Sprintf("conflict on existing item [%s]", id)
+	}
+	value := res.store(id, item)
+	res.Counts.Created++
+	w.Header().Set("Location", res.Prefix+"/"+id)
+	writeItem(w, http.StatusCreated, value)
+	return fmt.Sprintf("created item [%s]", id)
+}
+
+func (res *Resource) get(w http.ResponseWriter, r *http.Request, id string) string {
+	value, found := res.memory.Lookup(id)
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	if !found {
+		res.Counts.NotFound++
+		writeError(w, http.StatusNotFound, fmt.Sprintf("item [%s] not found", id))
+		return fmt.Sprintf("item [%s] not found", id)
+	}
+	res.Counts.Read++
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag(value)) {
+		w.Header().Set("ETag", etag(value))
+		w.WriteHeader(http.StatusNotModified)
+		return fmt.Sprintf("item [%s] not modified", id)
+	}
+	writeItem(w, http.StatusOK, value)
+	return fmt.Sprintf("read item [%s]", id)
+}
+
+// preconditionFailed evaluates If-Match and If-None-Match headers of a write request against the current item.
+func (res *Resource) preconditionFailed(r *http.Request, current string, found bool) bool {
+	tag := ""
+	if found {
+		tag = etag(current)
+	}
+	if im := r.Header.Get("If-Match"); im != "" && !etagMatches(im, tag) {
+		return true
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, tag) {
+		return true
+	}
+	return false
+}
+
+func (res *Resource) write(w http.ResponseWriter, r *http.Request, id string) string {
+	var item map[string]any
+	var err error
+	if r.Method != http.MethodDelete {
+		if item, err = readItem(r); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return err.Error()
+		}
+	}
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	current, found := res.memory.Lookup(id)
+	if res.preconditionFailed(r, current, found) {
+		res.Counts.PreconditionFailed++
+		writeError(w, http.StatusPreconditionFailed, fmt.Sprintf("precondition failed for item [%s]", id))
+		return fmt.Sprintf("precondition failed for item [%s]", id)
+	}
+	if !found && r.Method != http.MethodPut {
+		res.Counts.NotFound++
+		writeError(w, http.StatusNotFound, fmt.Sprintf("item [%s] not found", id))
+		return fmt.Sprintf("item [%s] not found", id)
+	}
+	switch r.Method {
+	case http.MethodDelete:
+		res.memory.Remove(id)
+		res.Counts.Deleted++
+		w.WriteHeader(http.StatusNoContent)
+		return fmt.Sprintf("deleted item [%s]", id)
+	case http.MethodPatch:
+		existing := map[string]any{}
+		json.Unmarshal([]byte(current), &existing)
+		mergePatch(existing, item)
+		item = existing
+	}
+	if n, err := strconv.Atoi(id); err == nil && res.IDType == "int" {
+		item[res.IDField] = n
+	} else {
+		item[res.IDField] = id
+	}
+	value := res.store(id, item)
+	if !found {
+		res.Counts.Created++
+		w.Header().Set("Location", res.Prefix+"/"+id)
+		writeItem(w, http.StatusCreated, value)
+		return fmt.Sprintf("created item [%s]", id)
+	}
+	res.Counts.Updated++
+	writeItem(w, http.StatusOK, value)
+	return fmt.Sprintf("updated item [%s]", id)
+}
+
+func (res *Resource) serve(w http.ResponseWriter, r *http.Request, id string) {
+	w.Header().Set(constants.HeaderGotoResource, res.Name)
+	msg := ""
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			msg = res.list(w, r)
+		case http.MethodPost:
+			msg = res.create(w, r)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			msg = fmt.Sprintf("method [%s] not allowed", r.Method)
+		}
+	} else {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			msg = res.get(w, r, id)
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			msg = res.write(w, r, id)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, PATCH, DELETE")
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			msg = fmt.Sprintf("method [%s] not allowed", r.Method)
+		}
+	}
+	msg = fmt.Sprintf("Resource [%s] %s for [%s %s]", res.Name, msg, r.Method, r.RequestURI)
+	util.AddLogMessage(msg, r)
+	util.UpdateTrafficEventDetails(r, msg)
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		if !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest {
+			if res, id, found := find(util.GetRequestOrListenerPortNum(r), r.URL.Path); found {
+				res.serve(w, r, id)
+				return
+			}
+		}
+		if next != nil {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
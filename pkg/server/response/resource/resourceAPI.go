@@ -0,0 +1,78 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+
+	"goto/pkg/events"
+	"goto/pkg/memory"
+	"goto/pkg/server/middleware"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/util"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	Middleware   = middleware.NewMiddleware("resource", setRoutes, middlewareFunc)
+	readResource = rules.ReadJSON(func() *Resource { return &Resource{} }, (*Resource).init)
+	api          = &rules.API[*Resource]{
+		Rules: resources,
+		Kind:  "resource",
+		Read: func(r *http.Request, port int) (*Resource, error) {
+			res, err := readResource(r, port)
+			if err == nil {
+				res.reset(port)
+			}
+			return res, err
+		},
+		Added: func(port int, res *Resource) string {
+			return fmt.Sprintf("Port [%d] added resource [%s] at [%s] with [%d] seed items", port, res.Name, res.Prefix, len(res.Seed))
+		},
+		Removed: func(port int, res *Resource) {
+			memory.GetMemoryManager(port).RemoveContext(memoryContext(res.Name))
+		},
+	}
+)
+
+func setRoutes(r *mux.Router) {
+	resourceRouter := api.SetRoutes(r, "/resource")
+	util.AddRoute(resourceRouter, "/reset/{name}", resetResource, "POST")
+}
+
+func resetResource(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	name := util.GetStringParamValue(r, "name")
+	msg := ""
+	var res *Resource
+	if pr := resources.Get(port, false); pr != nil {
+		res, _ = pr.Find(name)
+	}
+	if res != nil {
+		res.reset(port)
+		msg = fmt.Sprintf("Port [%d] reset resource [%s] to [%d] seed items", port, name, len(res.Seed))
+		events.SendRequestEvent("Resource Reset", msg, r)
+		w.WriteHeader(http.StatusOK)
+	} else {
+		msg = fmt.Sprintf("Port [%d] has no resource [%s]", port, name)
+		w.WriteHeader(http.StatusNotFound)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
@@ -20,9 +20,17 @@ import (
 	"net/http"
 
 	"goto/pkg/server/middleware"
+	"goto/pkg/server/response/bandwidth"
+	"goto/pkg/server/response/cache"
+	"goto/pkg/server/response/chaos"
 	"goto/pkg/server/response/delay"
+	"goto/pkg/server/response/fault"
 	"goto/pkg/server/response/header"
+	"goto/pkg/server/response/openapi"
 	"goto/pkg/server/response/payload"
+	"goto/pkg/server/response/ratelimit"
+	"goto/pkg/server/response/resource"
+	"goto/pkg/server/response/sequence"
 	"goto/pkg/server/response/status"
 	"goto/pkg/server/response/trigger"
 	"goto/pkg/util"
@@ -32,8 +40,8 @@ import (
 
 var (
 	Middleware          = middleware.NewMiddleware("response", setRoutes, middlewareFunc)
-	responseMiddlewares = []*middleware.Middleware{payload.Middleware, trigger.Middleware}
-	CoreMiddlewares     = []*middleware.Middleware{status.Middleware, delay.Middleware, header.Middleware}
+	responseMiddlewares = []*middleware.Middleware{sequence.Middleware, resource.Middleware, openapi.Middleware, payload.Middleware, trigger.Middleware}
+	CoreMiddlewares     = []*middleware.Middleware{status.Middleware, ratelimit.Middleware, fault.Middleware, chaos.Middleware, bandwidth.Middleware, delay.Middleware, header.Middleware, cache.Middleware}
 )
 
 func setRoutes(r *mux.Router) {
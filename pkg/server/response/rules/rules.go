@@ -0,0 +1,163 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Rule is a named rule kept per port. Snapshot returns a copy of the rule that is safe to serialize
+// while requests keep updating the rule's counts.
+type Rule[T any] interface {
+	RuleName() string
+	Snapshot() T
+}
+
+// counted is implemented by rules that track counts which can be cleared.
+type counted interface {
+	ClearCounts()
+}
+
+// PortRules holds the rules of a port in the order they were added. The list is replaced on every change,
+// so the slice returned by List can be iterated without holding a lock.
+type PortRules[T Rule[T]] struct {
+	Port  int
+	rules []T
+	key   string
+	lock  sync.RWMutex
+}
+
+// Registry keeps the rules of one kind for each port. The key names the rules list in the JSON of a port's rules.
+type Registry[T Rule[T]] struct {
+	key   string
+	ports map[int]*PortRules[T]
+	lock  sync.RWMutex
+}
+
+func NewRegistry[T Rule[T]](key string) *Registry[T] {
+	return &Registry[T]{key: key, ports: map[int]*PortRules[T]{}}
+}
+
+func (reg *Registry[T]) Get(port int, create bool) *PortRules[T] {
+	if !create {
+		reg.lock.RLock()
+		defer reg.lock.RUnlock()
+		return reg.ports[port]
+	}
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	if reg.ports[port] == nil {
+		reg.ports[port] = &PortRules[T]{Port: port, rules: []T{}, key: reg.key}
+	}
+	return reg.ports[port]
+}
+
+func (reg *Registry[T]) List(port int) []T {
+	if pr := reg.Get(port, false); pr != nil {
+		return pr.List()
+	}
+	return nil
+}
+
+// Clear removes all rules of the port and returns them.
+func (reg *Registry[T]) Clear(port int) []T {
+	reg.lock.Lock()
+	pr := reg.ports[port]
+	delete(reg.ports, port)
+	reg.lock.Unlock()
+	if pr == nil {
+		return nil
+	}
+	return pr.List()
+}
+
+func (reg *Registry[T]) Snapshot(port int) *PortRules[T] {
+	if pr := reg.Get(port, false); pr != nil {
+		return pr.Snapshot()
+	}
+	return &PortRules[T]{Port: port, rules: []T{}, key: reg.key}
+}
+
+func (pr *PortRules[T]) List() []T {
+	pr.lock.RLock()
+	defer pr.lock.RUnlock()
+	return pr.rules
+}
+
+// Add appends the rule, or replaces the existing rule with the same name in place.
+func (pr *PortRules[T]) Add(rule T) {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+	rules := append([]T{}, pr.rules...)
+	for i, existing := range rules {
+		if existing.RuleName() == rule.RuleName() {
+			rules[i] = rule
+			pr.rules = rules
+			return
+		}
+	}
+	pr.rules = append(rules, rule)
+}
+
+func (pr *PortRules[T]) Remove(name string) (T, bool) {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+	for i, rule := range pr.rules {
+		if rule.RuleName() == name {
+			pr.rules = append(append([]T{}, pr.rules[:i]...), pr.rules[i+1:]...)
+			return rule, true
+		}
+	}
+	var none T
+	return none, false
+}
+
+func (pr *PortRules[T]) Find(name string) (T, bool) {
+	for _, rule := range pr.List() {
+		if rule.RuleName() == name {
+			return rule, true
+		}
+	}
+	var none T
+	return none, false
+}
+
+func (pr *PortRules[T]) ClearCounts() {
+	for _, rule := range pr.List() {
+		if c, ok := any(rule).(counted); ok {
+			c.ClearCounts()
+		}
+	}
+}
+
+func (pr *PortRules[T]) Snapshot() *PortRules[T] {
+	snapshot := &PortRules[T]{Port: pr.Port, rules: []T{}, key: pr.key}
+	for _, rule := range pr.List() {
+		snapshot.rules = append(snapshot.rules, rule.Snapshot())
+	}
+	return snapshot
+}
+
+func (pr *PortRules[T]) MarshalJSON() ([]byte, error) {
+	rules, err := json.Marshal(pr.List())
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, `{"port":%d,%q:%s}`, pr.Port, pr.key, rules), nil
+}
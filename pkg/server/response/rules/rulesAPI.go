@@ -0,0 +1,147 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rules
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"goto/pkg/events"
+	"goto/pkg/util"
+
+	"github.com/gorilla/mux"
+)
+
+// API serves the add, remove, clear and get APIs of a registry's rules. Kind names a single rule in the
+// messages and events of the API, e.g. "fault rule".
+type API[T Rule[T]] struct {
+	Rules *Registry[T]
+	Kind  string
+	// Read reads and validates the rule of an add request.
+	Read func(r *http.Request, port int) (T, error)
+	// Added optionally describes a rule added to a port, for the add API's response.
+	Added func(port int, rule T) string
+	// Removed optionally releases what a rule removed or cleared from a port holds.
+	Removed func(port int, rule T)
+}
+
+// ReadJSON returns a Read func that decodes the request body as JSON into a new rule and validates it with init.
+func ReadJSON[T any](newRule func() T, init func(T) error) func(*http.Request, int) (T, error) {
+	return func(r *http.Request, port int) (T, error) {
+		rule := newRule()
+		if err := util.ReadJsonPayload(r, rule); err != nil {
+			return rule, err
+		}
+		return rule, init(rule)
+	}
+}
+
+// SetRoutes adds the standard routes of the API under the given path, and returns the path's router
+// for any routes specific to the rule kind.
+func (api *API[T]) SetRoutes(r *mux.Router, path string) *mux.Router {
+	router := util.PathRouter(r, path)
+	util.AddRoute(router, "/add", api.AddRule, "POST", "PUT")
+	util.AddRoute(router, "/remove/{name}", api.RemoveRule, "POST", "PUT")
+	util.AddRoute(router, "/clear", api.ClearRules, "POST")
+	util.AddRoute(router, "/counts/clear", api.ClearCounts, "POST")
+	util.AddRoute(router, "", api.GetRules, "GET")
+	return router
+}
+
+func (api *API[T]) event(suffix string) string {
+	words := strings.Fields(api.Kind)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ") + suffix
+}
+
+func (api *API[T]) AddRule(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	msg := ""
+	if rule, err := api.Read(r, port); err != nil {
+		msg = fmt.Sprintf("Invalid %s: %s", api.Kind, err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		api.Rules.Get(port, true).Add(rule)
+		if api.Added != nil {
+			msg = api.Added(port, rule)
+		} else {
+			msg = fmt.Sprintf("Port [%d] added %s [%s]", port, api.Kind, rule.RuleName())
+		}
+		events.SendRequestEventJSON(api.event(" Added"), rule.RuleName(), rule, r)
+		w.WriteHeader(http.StatusOK)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
+
+func (api *API[T]) RemoveRule(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	name := util.GetStringParamValue(r, "name")
+	msg := ""
+	var rule T
+	removed := false
+	if pr := api.Rules.Get(port, false); pr != nil {
+		rule, removed = pr.Remove(name)
+	}
+	if removed {
+		if api.Removed != nil {
+			api.Removed(port, rule)
+		}
+		msg = fmt.Sprintf("Port [%d] removed %s [%s]", port, api.Kind, name)
+		events.SendRequestEvent(api.event(" Removed"), msg, r)
+		w.WriteHeader(http.StatusOK)
+	} else {
+		msg = fmt.Sprintf("Port [%d] has no %s [%s]", port, api.Kind, name)
+		w.WriteHeader(http.StatusNotFound)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
+
+func (api *API[T]) ClearRules(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	for _, rule := range api.Rules.Clear(port) {
+		if api.Removed != nil {
+			api.Removed(port, rule)
+		}
+	}
+	msg := fmt.Sprintf("Port [%d] %ss cleared", port, api.Kind)
+	events.SendRequestEvent(api.event("s Cleared"), msg, r)
+	util.AddLogMessage(msg, r)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, msg)
+}
+
+func (api *API[T]) ClearCounts(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	if pr := api.Rules.Get(port, false); pr != nil {
+		pr.ClearCounts()
+	}
+	msg := fmt.Sprintf("Port [%d] %s counts cleared", port, api.Kind)
+	util.AddLogMessage(msg, r)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, msg)
+}
+
+func (api *API[T]) GetRules(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	util.WriteJsonPayload(w, api.Rules.Snapshot(port))
+	util.AddLogMessage(fmt.Sprintf("Port [%d] reporting %ss", port, api.Kind), r)
+}
@@ -0,0 +1,290 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sequence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"goto/pkg/constants"
+	"goto/pkg/server/response/payload"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/server/response/status"
+	"goto/pkg/types"
+	"goto/pkg/util"
+)
+
+const (
+	AfterEndRepeatLast   = "repeatLast"
+	AfterEndRestart      = "restart"
+	AfterEndPassthrough  = "passthrough"
+	contentTypeTextPlain = "text/plain"
+)
+
+type SequenceStep struct {
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Payload     any               `json:"payload,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	Delay       string            `json:"delay,omitempty"`
+	Times       int               `json:"times"`
+	Passthrough bool              `json:"passthrough,omitempty"`
+	body        []byte
+	delayMin    time.Duration
+	delayMax    time.Duration
+}
+
+type SequenceCounts struct {
+	Requests  int `json:"requests"`
+	Sessions  int `json:"sessions"`
+	Completed int `json:"completed"`
+}
+
+// Sequence walks each client session through its steps in order, one step per request of the session.
+// Sessions are identified by a request header, a cookie, or the client IP.
+type Sequence struct {
+	Name          string              `json:"name"`
+	Match         *status.StatusMatch `json:"match"`
+	SessionHeader string              `json:"sessionHeader,omitempty"`
+	SessionCookie string              `json:"sessionCookie,omitempty"`
+	Steps         []*SequenceStep     `json:"steps"`
+	AfterEnd      string              `json:"afterEnd"`
+	Sessions      map[string]int      `json:"sessions"`
+	Counts        *SequenceCounts     `json:"counts"`
+	length        int
+	lock          sync.Mutex
+}
+
+// position is the step chosen for a request of a session, with index -1 when the session's sequence has ended
+// and the request should pass through.
+type position struct {
+	step    *SequenceStep
+	index   int
+	session string
+}
+
+var sequences = rules.NewRegistry[*Sequence]("sequences")
+
+func (s *Sequence) init() error {
+	if s.Name == "" {
+		return errors.New("sequence needs a name")
+	}
+	if len(s.Steps) == 0 {
+		return errors.New("sequence needs at least one step")
+	}
+	if s.SessionHeader != "" && s.SessionCookie != "" {
+		return errors.New("sequence sessions can be keyed by either a header or a cookie")
+	}
+	switch s.AfterEnd {
+	case "":
+		s.AfterEnd = AfterEndRepeatLast
+	case AfterEndRepeatLast, AfterEndRestart, AfterEndPassthrough:
+	default:
+		return fmt.Errorf("invalid afterEnd [%s]", s.AfterEnd)
+	}
+	s.length = 0
+	for i, step := range s.Steps {
+		if step.Times < 0 {
+			return fmt.Errorf("invalid times for step [%d]", i+1)
+		}
+		if step.Times == 0 {
+			step.Times = 1
+		}
+		if step.Status == 0 && !step.Passthrough {
+			step.Status = http.StatusOK
+		}
+		if step.Status != 0 && (step.Status < 100 || step.Status > 599) {
+			return fmt.Errorf("invalid status for step [%d]", i+1)
+		}
+		if step.Delay != "" {
+			var ok bool
+			if step.delayMin, step.delayMax, _, ok = types.ParseDurationRange(step.Delay); !ok {
+				return fmt.Errorf("invalid delay for step [%d]", i+1)
+			}
+		}
+		switch p := step.Payload.(type) {
+		case nil:
+		case string:
+			step.body = []byte(p)
+			if step.ContentType == "" {
+				step.ContentType = contentTypeTextPlain
+			}
+		default:
+			step.body, _ = json.Marshal(p)
+			if step.ContentType == "" {
+				step.ContentType = constants.ContentTypeJSON
+			}
+		}
+		s.length += step.Times
+	}
+	if s.Match == nil {
+		s.Match = &status.StatusMatch{}
+	}
+	if err := s.Match.Prepare(); err != nil {
+		return err
+	}
+	s.Sessions = map[string]int{}
+	s.Counts = &SequenceCounts{}
+	return nil
+}
+
+// sessionOf returns the session that the request belongs to, or false if the request doesn't carry the session header or cookie.
+func (s *Sequence) sessionOf(r *http.Request) (string, bool) {
+	if s.SessionHeader != "" {
+		v := r.Header.Get(s.SessionHeader)
+		return v, v != ""
+	}
+	if s.SessionCookie != "" {
+		if c, err := r.Cookie(s.SessionCookie); err == nil && c.Value != "" {
+			return c.Value, true
+		}
+		return "", false
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host, true
+	}
+	return r.RemoteAddr, true
+}
+
+// next advances the session to its next request and returns the step for it.
+func (s *Sequence) next(session string) *position {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	n, found := s.Sessions[session]
+	if !found {
+		s.Counts.Sessions++
+	}
+	s.Counts.Requests++
+	s.Sessions[session] = n + 1
+	if (n+1)%s.length == 0 && (n < s.length || s.AfterEnd == AfterEndRestart) {
+		s.Counts.Completed++
+	}
+	if n >= s.length {
+		switch s.AfterEnd {
+		case AfterEndPassthrough:
+			return &position{index: -1, session: session}
+		case AfterEndRestart:
+			n = n % s.length
+		default:
+			n = s.length - 1
+		}
+	}
+	for i, step := range s.Steps {
+		if n < step.Times {
+			return &position{step: step, index: i, session: session}
+		}
+		n -= step.Times
+	}
+	return &position{index: -1, session: session}
+}
+
+func (s *Sequence) reset(session string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if session == "" {
+		s.Sessions = map[string]int{}
+		s.Counts = &SequenceCounts{}
+	} else {
+		delete(s.Sessions, session)
+	}
+}
+
+func (s *Sequence) RuleName() string {
+	return s.Name
+}
+
+func (s *Sequence) ClearCounts() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Counts = &SequenceCounts{}
+}
+
+func (s *Sequence) Snapshot() *Sequence {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	counts := *s.Counts
+	sessions := make(map[string]int, len(s.Sessions))
+	for k, v := range s.Sessions {
+		sessions[k] = v
+	}
+	return &Sequence{Name: s.Name, Match: s.Match, SessionHeader: s.SessionHeader, SessionCookie: s.SessionCookie,
+		Steps: s.Steps, AfterEnd: s.AfterEnd, Sessions: sessions, Counts: &counts}
+}
+
+// match returns the first sequence that matches the request and can tell the request's session, along with the session's next step.
+func match(port int, r *http.Request) (*Sequence, *position) {
+	for _, s := range sequences.List(port) {
+		if !s.Match.Matches(r.RequestURI, r.Header, r.Method) {
+			continue
+		}
+		if session, ok := s.sessionOf(r); ok {
+			return s, s.next(session)
+		}
+	}
+	return nil, nil
+}
+
+func middlewareFunc(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs := util.GetRequestStore(r)
+		var seq *Sequence
+		var pos *position
+		if !rs.IsAdminRequest && !rs.IsKnownNonTraffic && !rs.IsTunnelRequest {
+			seq, pos = match(util.GetRequestOrListenerPortNum(r), r)
+		}
+		if seq == nil || pos.step == nil {
+			if next != nil {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		step := pos.step
+		msg := fmt.Sprintf("Sequence [%s] session [%s] at step [%d/%d] for URI [%s]", seq.Name, pos.session, pos.index+1, len(seq.Steps), r.RequestURI)
+		util.AddLogMessage(msg, r)
+		util.UpdateTrafficEventDetails(r, msg)
+		w.Header().Set(constants.HeaderGotoSequence, fmt.Sprintf("%s:%d", seq.Name, pos.index+1))
+		for k, v := range step.Headers {
+			w.Header().Set(k, v)
+		}
+		if step.delayMax > 0 {
+			delay := types.RandomDuration(step.delayMin, step.delayMax)
+			w.Header().Add(constants.HeaderGotoResponseDelay, delay.String())
+			time.Sleep(delay)
+		}
+		if step.Passthrough {
+			if step.Status > 0 {
+				w.WriteHeader(step.Status)
+			}
+			if next != nil {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		if step.ContentType != "" {
+			w.Header().Set(constants.HeaderContentType, step.ContentType)
+		}
+		w.WriteHeader(step.Status)
+		if step.body != nil {
+			payload.Respond(w, r, payload.NewContentPayload(step.body, step.ContentType), nil)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sequence
+
+import (
+	"fmt"
+	"net/http"
+
+	"goto/pkg/events"
+	"goto/pkg/server/middleware"
+	"goto/pkg/server/response/rules"
+	"goto/pkg/util"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	Middleware = middleware.NewMiddleware("sequence", setRoutes, middlewareFunc)
+	api        = &rules.API[*Sequence]{
+		Rules: sequences,
+		Kind:  "sequence",
+		Read:  rules.ReadJSON(func() *Sequence { return &Sequence{} }, (*Sequence).init),
+		Added: func(port int, seq *Sequence) string {
+			return fmt.Sprintf("Port [%d] added sequence [%s] with [%d] steps", port, seq.Name, len(seq.Steps))
+		},
+	}
+)
+
+func setRoutes(r *mux.Router) {
+	sequenceRouter := api.SetRoutes(r, "/sequence")
+	util.AddRoute(sequenceRouter, "/reset/{name}/{session}?", resetSequence, "POST")
+}
+
+func resetSequence(w http.ResponseWriter, r *http.Request) {
+	port := util.GetRequestOrListenerPortNum(r)
+	name := util.GetStringParamValue(r, "name")
+	session := util.GetStringParamValue(r, "session")
+	var seq *Sequence
+	if ps := sequences.Get(port, false); ps != nil {
+		seq, _ = ps.Find(name)
+	}
+	msg := ""
+	if seq == nil {
+		msg = fmt.Sprintf("Port [%d] has no sequence [%s]", port, name)
+		w.WriteHeader(http.StatusNotFound)
+	} else {
+		seq.reset(session)
+		if session != "" {
+			msg = fmt.Sprintf("Port [%d] reset session [%s] of sequence [%s]", port, session, name)
+		} else {
+			msg = fmt.Sprintf("Port [%d] reset all sessions of sequence [%s]", port, name)
+		}
+		events.SendRequestEvent("Sequence Reset", msg, r)
+		w.WriteHeader(http.StatusOK)
+	}
+	util.AddLogMessage(msg, r)
+	fmt.Fprintln(w, msg)
+}
@@ -139,22 +139,8 @@ func (s *StatusManager) ApplyStatusConfig(port int, sc *StatusConfig) (err error
 	if sc.Match == nil {
 		sc.Match = &StatusMatch{}
 	}
-	if sc.Match.URIMatch == nil {
-		sc.Match.URIMatch = &StatusURIMatch{}
-	}
-	if sc.Match.URIMatch.Regex != "" {
-		if sc.Match.URIMatch.regexp, err = regexp.Compile(sc.Match.URIMatch.Regex); err != nil {
-			return err
-		}
-	}
-	if sc.Match.HeaderMatches == nil {
-		sc.Match.HeaderMatches = []*StatusHeaderMatch{}
-	}
-	t := true
-	for _, m := range sc.Match.HeaderMatches {
-		if m.Present == nil {
-			m.Present = &t
-		}
+	if err = sc.Match.Prepare(); err != nil {
+		return err
 	}
 	if sc.Port <= 0 {
 		sc.Port = port
@@ -226,19 +212,62 @@ func (s *StatusManager) GetStatusFor(port int, uri string, headers map[string][]
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 	statuses := s.PortStatus[port]
-	uri = strings.ToLower(uri)
 	lowerHeaders := util.ToLowerHeadersValues(headers)
 	for _, sc := range statuses {
-		if sc.Match.match(uri, lowerHeaders) {
+		if sc.Match.match(uri, lowerHeaders, method) {
 			return sc.GetStatus()
 		}
 	}
 	return 0, 0
 }
 
-func (sm *StatusMatch) match(uri string, headers map[string]string) bool {
+// Prepare fills in the defaults of a match config and compiles its URI regex.
+func (sm *StatusMatch) Prepare() (err error) {
+	if sm.URIMatch == nil {
+		sm.URIMatch = &StatusURIMatch{}
+	}
+	sm.URIMatch.Prefix = strings.ToLower(sm.URIMatch.Prefix)
+	sm.URIMatch.Exact = strings.ToLower(sm.URIMatch.Exact)
+	if sm.URIMatch.Regex != "" {
+		if sm.URIMatch.regexp, err = regexp.Compile(sm.URIMatch.Regex); err != nil {
+			return err
+		}
+	}
+	if sm.HeaderMatches == nil {
+		sm.HeaderMatches = []*StatusHeaderMatch{}
+	}
+	t := true
+	for _, m := range sm.HeaderMatches {
+		m.Header = strings.ToLower(strings.TrimSpace(m.Header))
+		if m.Present == nil {
+			m.Present = &t
+		}
+	}
+	return nil
+}
+
+func (sm *StatusMatch) Matches(uri string, headers map[string][]string, method string) bool {
+	return sm.match(uri, util.ToLowerHeadersValues(headers), method)
+}
+
+// match checks the URI regex against the URI as received, and the prefix and exact URIs case-insensitively.
+func (sm *StatusMatch) match(uri string, headers map[string]string, method string) bool {
+	if len(sm.MethodMatch) > 0 && method != "" {
+		matched := false
+		for _, m := range sm.MethodMatch {
+			matched = matched || strings.EqualFold(m, method)
+		}
+		if !matched {
+			return false
+		}
+	}
+	if sm.URIMatch.regexp != nil {
+		if sm.URIMatch.regexp.MatchString(uri) == sm.URIMatch.Not {
+			return false
+		}
+	}
 	if sm.URIMatch.Prefix != "" {
-		hasPrefix := strings.HasPrefix(uri, sm.URIMatch.Prefix)
+		hasPrefix := strings.HasPrefix(strings.ToLower(uri), sm.URIMatch.Prefix)
 		if hasPrefix == sm.URIMatch.Not {
 			return false
 		}
@@ -46,6 +46,7 @@ import (
 	grpcapi "goto/pkg/rpc/grpc/server"
 	"goto/pkg/rpc/jsonrpc"
 	"goto/pkg/scripts"
+	"goto/pkg/server/auth"
 	"goto/pkg/server/catchall"
 	"goto/pkg/server/conn"
 	"goto/pkg/server/echo"
@@ -70,6 +71,7 @@ func init() {
 	middleware.Core = []*middleware.Middleware{conn.Middleware, hooks.Middleware}
 
 	middleware.InterceptedCore = append(middleware.InterceptedCore, request.CoreMiddlewares...)
+	middleware.InterceptedCore = append(middleware.InterceptedCore, auth.Middleware)
 	middleware.InterceptedCore = append(middleware.InterceptedCore, response.CoreMiddlewares...)
 
 	middleware.Unintercepted = []*middleware.Middleware{
@@ -25,6 +25,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
 	"golang.org/x/net/http2"
 	"google.golang.org/grpc"
 )
@@ -74,6 +76,13 @@ type HTTP2TransportIntercept struct {
 	responseIntercept IHTTPResponseIntercept
 }
 
+type HTTP3TransportIntercept struct {
+	*http3.Transport
+	*BaseTransportIntercept
+	requestIntercept  IHTTPRequestIntercept
+	responseIntercept IHTTPResponseIntercept
+}
+
 type GRPCIntercept struct {
 	*BaseTransportIntercept
 	dialOpts []grpc.DialOption
@@ -127,6 +136,31 @@ func NewHTTP2TransportIntercept(orig any, label string, newConnNotifierChan chan
 	return t
 }
 
+func NewHTTP3TransportIntercept(orig any, label string, newConnNotifierChan chan string) *HTTP3TransportIntercept {
+	ht := orig.(*http3.Transport)
+	t := &HTTP3TransportIntercept{
+		Transport:              ht,
+		BaseTransportIntercept: &BaseTransportIntercept{},
+	}
+	t.tlsConfigPtr = &ht.TLSClientConfig
+	dial := ht.Dial
+	if dial == nil {
+		dial = quic.DialAddrEarly
+	}
+	t.Transport.Dial = func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+		if conn, err := dial(ctx, addr, tlsCfg, cfg); err == nil {
+			if newConnNotifierChan != nil {
+				newConnNotifierChan <- label
+			}
+			t.trackQUICConn(conn)
+			return conn, nil
+		} else {
+			return nil, err
+		}
+	}
+	return t
+}
+
 func NewGRPCIntercept(label string, dialOpts []grpc.DialOption, newConnNotifierChan chan string) *GRPCIntercept {
 	g := &GRPCIntercept{
 		dialOpts: dialOpts,
@@ -228,6 +262,47 @@ func (t *HTTP2TransportIntercept) Original() any {
 	return t.Transport
 }
 
+func (t *HTTP3TransportIntercept) AsHTTP() IHTTPTransportIntercept {
+	return t
+}
+
+func (t *HTTP3TransportIntercept) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.requestIntercept != nil {
+		t.requestIntercept.InterceptRequest(req)
+	}
+	resp, err := t.Transport.RoundTrip(req)
+	if resp != nil && t.responseIntercept != nil {
+		t.responseIntercept.InterceptResponse(resp)
+	}
+	return resp, err
+}
+
+func (t *HTTP3TransportIntercept) SetRequestIntercept(ri IHTTPRequestIntercept) {
+	t.requestIntercept = ri
+}
+
+func (t *HTTP3TransportIntercept) SetResponseIntercept(ri IHTTPResponseIntercept) {
+	t.responseIntercept = ri
+}
+
+func (t *HTTP3TransportIntercept) Original() any {
+	return t.Transport
+}
+
+// trackQUICConn counts a QUIC connection as open until its context is done, since QUIC
+// connections aren't net.Conn and can't be wrapped by ConnTracker.
+func (t *BaseTransportIntercept) trackQUICConn(conn *quic.Conn) {
+	t.lock.Lock()
+	t.ConnCount++
+	t.lock.Unlock()
+	go func() {
+		<-conn.Context().Done()
+		t.lock.Lock()
+		t.ConnCount--
+		t.lock.Unlock()
+	}()
+}
+
 func (t *BaseTransportIntercept) GetOpenConnectionCount() int {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
@@ -30,6 +30,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
 	"golang.org/x/net/http2"
 	"google.golang.org/grpc"
 )
@@ -56,9 +58,11 @@ type DefaultClientTransport struct {
 	SNI                string
 	TLSVersion         uint16
 	isH2               bool
+	isH3               bool
 	PeerCertInfo       *gototls.PeerCertInfo
 	h1Transport        *http.Transport
 	h2Transport        *http2.Transport
+	h3Transport        *http3.Transport
 	port               int
 	label              string
 	alpn               *gototls.ALPN
@@ -134,7 +138,7 @@ func (c *DefaultClientTransport) UpdateTLSConfig(sni string, tlsVersion uint16,
 	if alpn != nil {
 		if !alpn.KeepDefault {
 			tlsConfig.NextProtos = alpn.Protos
-		} else if !c.isH2 {
+		} else if !c.isH2 && !c.isH3 {
 			tlsConfig.NextProtos = append(alpn.Protos, "http/1.1")
 		} else if len(alpn.Protos) > 0 {
 			tlsConfig.NextProtos = append(tlsConfig.NextProtos, alpn.Protos...)
@@ -215,6 +219,10 @@ func (c *DefaultClientTransport) Close() {
 		c.CloseIdleConnections()
 		c.Client = nil
 	}
+	if c.h3Transport != nil {
+		c.h3Transport.Close()
+		c.h3Transport = nil
+	}
 }
 
 func (c *DefaultClientTransport) Transport() ITransportIntercept {
@@ -247,6 +255,10 @@ func (c *DefaultClientTransport) IsH2() bool {
 	return c.HTTP() != nil && c.isH2
 }
 
+func (c *DefaultClientTransport) IsH3() bool {
+	return c.HTTP() != nil && c.isH3
+}
+
 func CreateRequest(method string, url string, headers http.Header, payload []byte, payloadReader io.ReadCloser) (*http.Request, error) {
 	if payloadReader == nil {
 		if payload == nil {
@@ -366,3 +378,39 @@ func CreateHTTPClient(port int, label string, h2, autoUpgrade, isTLS, noSNI bool
 	}
 	return ct
 }
+
+// CreateHTTP3Client creates a client transport that sends requests over HTTP/3 (QUIC). HTTP/3 always uses TLS,
+// with the TLS config shared with the QUIC transport so that SNI, TLS version, ALPN and cert updates apply to new connections.
+func CreateHTTP3Client(port int, label string, noSNI bool, serverName string,
+	requestTimeout, connTimeout, connIdleTimeout time.Duration, newConnNotifierChan chan string) ClientTransport {
+	ct := &DefaultClientTransport{
+		port:  port,
+		label: label,
+		isH3:  true,
+	}
+	if noSNI {
+		serverName = ""
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         serverName,
+		NextProtos:         []string{http3.NextProtoH3},
+	}
+	h3t := &http3.Transport{
+		TLSClientConfig:    tlsConfig,
+		DisableCompression: true,
+		QUICConfig: &quic.Config{
+			HandshakeIdleTimeout: connTimeout,
+			MaxIdleTimeout:       connIdleTimeout,
+		},
+		Dial: func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+			tlsCfg.VerifyConnection = gototls.ExtractSNI("udp", "Client-"+label, addr, ct.StoreSNI, ct.StorePeerCertInfo, ct.UpdatePeerStatus)
+			tlsCfg.VerifyPeerCertificate = gototls.ExtractPeerCertInfo("udp", "Client-"+label, addr, ct.StorePeerCertInfo, ct.UpdatePeerStatus)
+			return quic.DialAddrEarly(ctx, addr, tlsCfg, cfg)
+		},
+	}
+	ht := NewHTTP3TransportIntercept(h3t, label, newConnNotifierChan)
+	ct.UpdateTransport(&http.Client{Timeout: requestTimeout, Transport: ht}, nil, nil, nil, ht, nil, false)
+	ct.h3Transport = h3t
+	return ct
+}
@@ -76,6 +76,10 @@ type CmdCtlConfig struct {
 	ConfigFile  string
 	Name        string
 	RemoteURL   string
+	Port        string
+	Speed       string
+	URL         string
+	Timeout     string
 }
 
 type CmdClientConfig struct {
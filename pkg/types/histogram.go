@@ -0,0 +1,228 @@
+/**
+ * Copyright 2026 uk
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Histogram is an HDR (high dynamic range) histogram of durations, recorded at microsecond
+// resolution with 3 significant digits of precision. Counts are kept sparse so that histograms
+// stay small for narrow distributions and can be serialized and merged across peers.
+type Histogram struct {
+	count  int64
+	min    int64
+	max    int64
+	sum    int64
+	counts map[int32]int64
+}
+
+type HistogramSummary struct {
+	Count int64  `json:"count"`
+	Min   string `json:"min"`
+	Max   string `json:"max"`
+	Mean  string `json:"mean"`
+	P50   string `json:"p50"`
+	P90   string `json:"p90"`
+	P95   string `json:"p95"`
+	P99   string `json:"p99"`
+	P999  string `json:"p99.9"`
+}
+
+type histogramJSON struct {
+	HistogramSummary
+	MinMicros int64            `json:"minMicros"`
+	MaxMicros int64            `json:"maxMicros"`
+	SumMicros int64            `json:"sumMicros"`
+	Buckets   map[string]int64 `json:"buckets,omitempty"`
+}
+
+const (
+	// 2048 sub-buckets per bucket gives 3 significant digits
+	histSubBucketHalfCountMagnitude = 10
+	histSubBucketCount              = 1 << (histSubBucketHalfCountMagnitude + 1)
+	histSubBucketHalfCount          = histSubBucketCount / 2
+	histSubBucketMask               = histSubBucketCount - 1
+)
+
+func NewHistogram() *Histogram {
+	return &Histogram{counts: map[int32]int64{}}
+}
+
+func histogramIndex(v int64) int32 {
+	bucket := int32(64-bits.LeadingZeros64(uint64(v|histSubBucketMask))) - (histSubBucketHalfCountMagnitude + 1)
+	subBucket := int32(v >> uint(bucket))
+	return (bucket+1)<<histSubBucketHalfCountMagnitude + subBucket - histSubBucketHalfCount
+}
+
+func histogramValue(index int32) int64 {
+	bucket := (index >> histSubBucketHalfCountMagnitude) - 1
+	subBucket := (index & (histSubBucketHalfCount - 1)) + histSubBucketHalfCount
+	if bucket < 0 {
+		subBucket -= histSubBucketHalfCount
+		bucket = 0
+	}
+	return int64(subBucket) << uint(bucket)
+}
+
+// histogramHighestEquivalent returns the largest value that maps to the same bucket slot as the given index
+func histogramHighestEquivalent(index int32) int64 {
+	bucket := (index >> histSubBucketHalfCountMagnitude) - 1
+	if bucket < 0 {
+		bucket = 0
+	}
+	return histogramValue(index) + (int64(1) << uint(bucket)) - 1
+}
+
+func (h *Histogram) Record(d time.Duration) {
+	v := d.Microseconds()
+	if v < 0 {
+		v = 0
+	}
+	if h.counts == nil {
+		h.counts = map[int32]int64{}
+	}
+	h.counts[histogramIndex(v)]++
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	if h.counts == nil {
+		h.counts = map[int32]int64{}
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+}
+
+func (h *Histogram) Count() int64 {
+	return h.count
+}
+
+func (h *Histogram) Min() time.Duration {
+	return time.Duration(h.min) * time.Microsecond
+}
+
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max) * time.Microsecond
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(float64(h.sum)/float64(h.count)) * time.Microsecond
+}
+
+// Percentile returns the value at or below which the given percentage (0-100) of recorded values fall.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	p = math.Min(math.Max(p, 0), 100)
+	target := int64(math.Ceil(p / 100 * float64(h.count)))
+	if target < 1 {
+		target = 1
+	}
+	indexes := make([]int32, 0, len(h.counts))
+	for i := range h.counts {
+		indexes = append(indexes, i)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	total := int64(0)
+	for _, i := range indexes {
+		total += h.counts[i]
+		if total >= target {
+			v := histogramHighestEquivalent(i)
+			if v > h.max {
+				v = h.max
+			}
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+	return h.Max()
+}
+
+func (h *Histogram) Summary() *HistogramSummary {
+	return &HistogramSummary{
+		Count: h.count,
+		Min:   h.Min().String(),
+		Max:   h.Max().String(),
+		Mean:  h.Mean().String(),
+		P50:   h.Percentile(50).String(),
+		P90:   h.Percentile(90).String(),
+		P95:   h.Percentile(95).String(),
+		P99:   h.Percentile(99).String(),
+		P999:  h.Percentile(99.9).String(),
+	}
+}
+
+func (h *Histogram) MarshalJSON() ([]byte, error) {
+	data := &histogramJSON{
+		HistogramSummary: *h.Summary(),
+		MinMicros:        h.min,
+		MaxMicros:        h.max,
+		SumMicros:        h.sum,
+		Buckets:          map[string]int64{},
+	}
+	for i, c := range h.counts {
+		data.Buckets[strconv.Itoa(int(i))] = c
+	}
+	return json.Marshal(data)
+}
+
+func (h *Histogram) UnmarshalJSON(b []byte) error {
+	data := &histogramJSON{}
+	if err := json.Unmarshal(b, data); err != nil {
+		return err
+	}
+	h.count = 0
+	h.min = data.MinMicros
+	h.max = data.MaxMicros
+	h.sum = data.SumMicros
+	h.counts = map[int32]int64{}
+	for k, c := range data.Buckets {
+		if i, err := strconv.Atoi(k); err == nil {
+			h.counts[int32(i)] += c
+			h.count += c
+		}
+	}
+	return nil
+}
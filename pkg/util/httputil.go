@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"goto/pkg/constants"
 	"goto/pkg/global"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -32,6 +33,7 @@ var (
 	WillTunnel    func(*http.Request, *RequestStore) bool
 	WillProxyGRPC func(int, any) bool
 	WillProxyMCP  func(*http.Request, *RequestStore) bool
+	ShapeBody     func(*http.Request, *RequestStore) io.ReadCloser
 	LowerViaGoto  = strings.ToLower(constants.HeaderViaGoto)
 )
 
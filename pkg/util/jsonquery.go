@@ -185,7 +185,7 @@ func (jp *JSONPath) Apply(j JSON) JSON {
 	return JSONFromJSON(out)
 }
 
-func (jp *JSONPath) FindResults(json map[string]any) (captures map[string]string, allMatched bool) {
+func (jp *JSONPath) FindResults(json any) (captures map[string]string, allMatched bool) {
 	captures = map[string]string{}
 	allMatched = true
 	for key, p := range jp.Paths {
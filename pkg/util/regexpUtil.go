@@ -36,6 +36,7 @@ var (
 	QueryParamRegex         = `(\?.*)?$`
 	GlobRegex               = `(.*)?`
 	fillerRegexp            = regexp.MustCompile("{({[^{}]+?})}|{([^{}]+?)}")
+	placeholderRegexp       = regexp.MustCompile(`\$\{([^{}]+?)\}`)
 	contentRegexp           = regexp.MustCompile("(?i)content")
 	hostRegexp              = regexp.MustCompile("(?i)^host$")
 	tunnelRegexp            = regexp.MustCompile("(?i)tunnel")
@@ -146,6 +147,20 @@ func FillValues(text string, values map[string]string) string {
 	return text
 }
 
+// FillPlaceholders replaces `${name}` placeholders in the text with values from the given map,
+// leaving placeholders without a value untouched.
+func FillPlaceholders(text string, values map[string]string) string {
+	if len(values) == 0 || !strings.Contains(text, "${") {
+		return text
+	}
+	return placeholderRegexp.ReplaceAllStringFunc(text, func(m string) string {
+		if value, present := values[m[2:len(m)-1]]; present {
+			return value
+		}
+		return m
+	})
+}
+
 func SubstitutePayloadMarkers(payload string, keys []string, values map[string]string) string {
 	for _, key := range keys {
 		if values[key] != "" {
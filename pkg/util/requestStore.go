@@ -177,6 +177,9 @@ func WithRequestStore(r *http.Request, w http.ResponseWriter) (context.Context,
 	port := GetRequestOrListenerPortNum(r)
 	r = r.WithContext(context.WithValue(ctx, CurrentPortKey, port))
 	if !rs.IsGRPC {
+		if ShapeBody != nil && r.Body != nil {
+			r.Body = ShapeBody(r, rs)
+		}
 		rs.ReReader = CreateOrGetReReader(r.Body)
 		r.Body = rs.ReReader
 		rs.BodyLength = rs.ReReader.Length()